@@ -0,0 +1,31 @@
+// Command mockoidc is a stand-in OpenID Connect provider for trying single
+// sign-on locally. It signs in whoever the form says, so never expose it.
+//
+//	go run ./cmd/mockoidc
+//	OIDC_ISSUER=http://localhost:9000 OIDC_CLIENT_ID=forum go run ./cmd/server
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"web-forum/internal/auth"
+	"web-forum/internal/oidc/mockoidc"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:9000", "address to listen on")
+	issuer := flag.String("issuer", "http://localhost:9000", "issuer URL, as the forum reaches it")
+	clientID := flag.String("client-id", "forum", "the only client_id accepted")
+	clientSecret := flag.String("client-secret", "", "client secret required at the token endpoint, if any")
+	alg := flag.String("alg", auth.AlgRS256, "ID token signing algorithm: RS256 or EdDSA")
+	flag.Parse()
+
+	p, err := mockoidc.New(*issuer, *clientID, *clientSecret, *alg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("Mock OpenID Connect provider %s for client %q on http://%s", p.Issuer, p.ClientID, *addr)
+	log.Fatal(http.ListenAndServe(*addr, p))
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"web-forum/internal/auth"
+)
+
+const keygenUsage = "usage: server keygen EdDSA|RS256 > key.pem"
+
+// runKeygen implements the keygen subcommand, which writes a new private key
+// for JWT_KEYS to stdout as PEM
+func runKeygen(args []string) error {
+	if len(args) != 1 {
+		return errors.New(keygenUsage)
+	}
+
+	var private crypto.PrivateKey
+	var err error
+	switch args[0] {
+	case auth.AlgEdDSA:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	case auth.AlgRS256:
+		private, err = rsa.GenerateKey(rand.Reader, 2048)
+	default:
+		return errors.New(keygenUsage)
+	}
+	if err != nil {
+		return err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return err
+	}
+	return pem.Encode(os.Stdout, &pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
@@ -1,23 +1,132 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"log"
 	"os"
+	"strings"
+	"web-forum/internal/auth"
 	"web-forum/internal/database"
+	"web-forum/internal/mailer"
+	"web-forum/internal/oidc"
+	"web-forum/internal/passwords"
 	"web-forum/internal/router"
+	"web-forum/internal/store"
+	"web-forum/internal/store/sqlstore"
+
+	"github.com/joho/godotenv"
 )
 
 func main() {
-	database.InitDB()
-	r := router.SetUpRouter()
+	err := godotenv.Load()
+	if err != nil {
+		log.Println("No .env file found, using environment variables instead")
+	}
+
+	driver := flag.String("db", os.Getenv("DB_DRIVER"), "storage backend: postgres, sqlite or memory")
+	flag.Parse()
+
+	// Generating a key needs neither the database nor a configured keyring
+	if flag.Arg(0) == "keygen" {
+		if err := runKeygen(flag.Args()[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	db := database.InitDB(*driver)
+
+	switch flag.Arg(0) {
+	case "":
+	case "migrate":
+		if err := runMigrate(db, flag.Args()[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	case "role":
+		migrateSQLite(db)
+		if err := runRole(db, flag.Args()[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	default:
+		log.Fatalf("Unknown command %q", flag.Arg(0))
+	}
+
+	migrateSQLite(db)
+
+	// Fail on start rather than on the first login if the keys are misconfigured
+	if _, err := auth.Keys(); err != nil {
+		log.Fatal("Failed to load JWT signing keys: ", err)
+	}
+
+	hasher, err := passwords.DefaultHasher()
+	if err != nil {
+		log.Fatal("Failed to set up password hashing: ", err)
+	}
+
+	if err := seedAdmin(db, hasher); err != nil {
+		log.Fatal("Failed to create the admin: ", err)
+	}
+
+	m, err := mailer.FromEnv()
+	if err != nil {
+		log.Fatal("Failed to set up the mailer: ", err)
+	}
+
+	provider, err := oidc.FromEnv()
+	if err != nil {
+		log.Fatal("Failed to set up single sign-on: ", err)
+	}
+
+	rules, err := passwords.FromEnv(hasher)
+	if err != nil {
+		log.Fatal("Failed to set up the password policy: ", err)
+	}
+
+	r, err := router.SetUpRouter(db, m, provider, rules, trustedProxies())
+	if err != nil {
+		log.Fatal("Failed to set up the router: ", err)
+	}
 
 	port := os.Getenv("PORT")
-    if port == "" {
-        port = "8080"  // Default port
-    }
+	if port == "" {
+		port = "8080" // Default port
+	}
 	log.Printf("Server is running on http://localhost:%s", port)
 
 	if err := r.Run(":" + port); err != nil {
 		log.Fatalf("Error starting server: %s", err)
 	}
-}
\ No newline at end of file
+}
+
+// trustedProxies returns the addresses or CIDR ranges in TRUSTED_PROXIES, the
+// reverse proxies in front of the server whose X-Forwarded-For is believed
+func trustedProxies() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
+
+// migrateSQLite brings the schema of a SQLite database up to date. A SQLite
+// file is the whole deployment, so this happens on start. PostgreSQL is
+// migrated explicitly with the migrate command.
+func migrateSQLite(db store.Store) {
+	sqlDB, ok := db.(*sqlstore.Store)
+	if !ok || sqlDB.Dialect() != "sqlite" {
+		return
+	}
+
+	applied, err := sqlDB.MigrateUp(context.Background())
+	if err != nil {
+		log.Fatal("Failed to migrate the database: ", err)
+	}
+	for _, m := range applied {
+		log.Printf("Applied migration %04d_%s", m.Version, m.Name)
+	}
+}
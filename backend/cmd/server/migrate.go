@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"web-forum/internal/store"
+	"web-forum/internal/store/sqlstore"
+)
+
+const migrateUsage = "usage: server [-db driver] migrate up|down [steps]|status"
+
+// runMigrate implements the migrate subcommand
+func runMigrate(db store.Store, args []string) error {
+	sqlDB, ok := db.(*sqlstore.Store)
+	if !ok {
+		return errors.New("migrations only apply to the postgres and sqlite backends")
+	}
+
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		applied, err := sqlDB.MigrateUp(ctx)
+		for _, m := range applied {
+			fmt.Printf("Applied %04d_%s\n", m.Version, m.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Println("Database is up to date")
+		}
+		return err
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return errors.New(migrateUsage)
+			}
+			steps = n
+		}
+
+		rolledBack, err := sqlDB.MigrateDown(ctx, steps)
+		for _, m := range rolledBack {
+			fmt.Printf("Rolled back %04d_%s\n", m.Version, m.Name)
+		}
+		if err == nil && len(rolledBack) == 0 {
+			fmt.Println("No migrations to roll back")
+		}
+		return err
+
+	case "status":
+		status, err := sqlDB.MigrationStatus(ctx)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+		for _, m := range status {
+			applied := "pending"
+			if m.AppliedAt != nil {
+				applied = m.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", m.Version, m.Name, applied)
+		}
+		return w.Flush()
+	}
+
+	return errors.New(migrateUsage)
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"web-forum/internal/passwords"
+	"web-forum/internal/store"
+	"web-forum/internal/store/memory"
+)
+
+const roleUsage = "usage: server [-db driver] role <username> member|moderator|admin"
+
+// runRole implements the role subcommand, which is how the first admin is made
+func runRole(db store.Store, args []string) error {
+	if len(args) != 2 {
+		return errors.New(roleUsage)
+	}
+	if _, ok := db.(*memory.Store); ok {
+		// The role would be gone when the command exits, along with the user
+		return errors.New("the memory backend keeps no users between runs; set ADMIN_USERNAME and ADMIN_PASSWORD to start it with an admin instead")
+	}
+
+	role, err := store.ParseRole(args[1])
+	if err != nil {
+		return errors.New(roleUsage)
+	}
+
+	ctx := context.Background()
+
+	user, err := db.GetUserByUsername(ctx, args[0])
+	if errors.Is(err, store.ErrNotFound) {
+		return fmt.Errorf("no user named %q", args[0])
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := db.SetRole(ctx, user.ID, role); err != nil {
+		return err
+	}
+
+	fmt.Printf("%s now has the %s role\n", user.Username, role)
+	return nil
+}
+
+// seedAdmin creates an admin named ADMIN_USERNAME with ADMIN_PASSWORD when the
+// server runs on the memory backend, which starts with no users every time
+func seedAdmin(db store.Store, hasher *passwords.Hasher) error {
+	if _, ok := db.(*memory.Store); !ok {
+		return nil
+	}
+
+	username, password := os.Getenv("ADMIN_USERNAME"), os.Getenv("ADMIN_PASSWORD")
+	if username == "" && password == "" {
+		return nil
+	}
+	if username == "" || password == "" {
+		return errors.New("ADMIN_USERNAME and ADMIN_PASSWORD must be set together")
+	}
+
+	hash, err := hasher.Hash(password)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	user, err := db.CreateUser(ctx, username, hash)
+	if err != nil {
+		return err
+	}
+	if err := db.SetRole(ctx, user.ID, store.RoleAdmin); err != nil {
+		return err
+	}
+
+	log.Printf("Created the admin %s", user.Username)
+	return nil
+}
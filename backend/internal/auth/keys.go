@@ -0,0 +1,245 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Signing algorithms a key may use. HS256 keys are shared secrets and never
+// published; EdDSA and RS256 keys are published at the JWKS endpoint so other
+// services can verify forum tokens.
+const (
+	AlgHS256 = "HS256"
+	AlgEdDSA = "EdDSA"
+	AlgRS256 = "RS256"
+)
+
+// Key is one signing key, named by the kid it puts in token headers. A key
+// loaded from a public key can verify tokens but not sign them.
+type Key struct {
+	ID        string
+	Algorithm string
+	sign      any
+	verify    any
+}
+
+// Keyring holds every key tokens are accepted from. The first key signs new
+// tokens, the rest only verify, so a key can be rotated out without logging
+// everyone out: add the new key in front, keep the old one until the tokens
+// it signed have expired, then drop it.
+type Keyring struct {
+	signer Key
+	keys   map[string]Key
+	order  []string
+}
+
+// NewKeyring returns a keyring that signs with the first key
+func NewKeyring(keys ...Key) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("auth: a keyring needs at least one key")
+	}
+	if keys[0].sign == nil {
+		return nil, fmt.Errorf("auth: signing key %q has no private key", keys[0].ID)
+	}
+
+	ring := &Keyring{signer: keys[0], keys: make(map[string]Key, len(keys))}
+	for _, key := range keys {
+		if _, ok := ring.keys[key.ID]; ok {
+			return nil, fmt.Errorf("auth: duplicate key id %q", key.ID)
+		}
+		ring.keys[key.ID] = key
+		ring.order = append(ring.order, key.ID)
+	}
+	return ring, nil
+}
+
+// LoadKeyring reads the keyring from the environment. JWT_KEYS is a comma
+// separated list of kid:algorithm:value, where value is the secret of an HS256
+// key or the path to a PEM file for EdDSA and RS256. Without JWT_KEYS,
+// JWT_SECRET is used as a single HS256 key with kid "default".
+func LoadKeyring() (*Keyring, error) {
+	spec := strings.TrimSpace(os.Getenv("JWT_KEYS"))
+	if spec == "" {
+		secret := os.Getenv("JWT_SECRET")
+		if secret == "" {
+			return nil, errors.New("auth: set JWT_KEYS or JWT_SECRET")
+		}
+		return NewKeyring(Key{ID: "default", Algorithm: AlgHS256, sign: []byte(secret), verify: []byte(secret)})
+	}
+
+	var keys []Key
+	for _, entry := range strings.Split(spec, ",") {
+		parts := strings.SplitN(strings.TrimSpace(entry), ":", 3)
+		if len(parts) != 3 || parts[0] == "" || parts[2] == "" {
+			return nil, fmt.Errorf("auth: JWT_KEYS entries must be kid:algorithm:value, got %q", entry)
+		}
+
+		key, err := parseKey(parts[0], parts[1], parts[2])
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return NewKeyring(keys...)
+}
+
+// NewPrivateKey makes a signing key from a private key held in memory rather
+// than a PEM file. The algorithm follows from the key: EdDSA for Ed25519 and
+// RS256 for RSA.
+func NewPrivateKey(id string, private crypto.Signer) (Key, error) {
+	key := Key{ID: id, sign: private, verify: private.Public()}
+	switch key.verify.(type) {
+	case ed25519.PublicKey:
+		key.Algorithm = AlgEdDSA
+	case *rsa.PublicKey:
+		key.Algorithm = AlgRS256
+	default:
+		return Key{}, fmt.Errorf("auth: key %q: unsupported key type", id)
+	}
+	return key, nil
+}
+
+func parseKey(id, algorithm, value string) (Key, error) {
+	if algorithm == AlgHS256 {
+		return Key{ID: id, Algorithm: algorithm, sign: []byte(value), verify: []byte(value)}, nil
+	}
+	if algorithm != AlgEdDSA && algorithm != AlgRS256 {
+		return Key{}, fmt.Errorf("auth: key %q: algorithm must be HS256, EdDSA or RS256", id)
+	}
+
+	data, err := os.ReadFile(value)
+	if err != nil {
+		return Key{}, fmt.Errorf("auth: key %q: %w", id, err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return Key{}, fmt.Errorf("auth: key %q: %s is not a PEM file", id, value)
+	}
+
+	key := Key{ID: id, Algorithm: algorithm}
+	switch block.Type {
+	case "PRIVATE KEY":
+		private, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return Key{}, fmt.Errorf("auth: key %q: %w", id, err)
+		}
+		signer, ok := private.(crypto.Signer)
+		if !ok {
+			return Key{}, fmt.Errorf("auth: key %q: unsupported private key", id)
+		}
+		key.sign, key.verify = private, signer.Public()
+	case "RSA PRIVATE KEY":
+		private, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return Key{}, fmt.Errorf("auth: key %q: %w", id, err)
+		}
+		key.sign, key.verify = private, &private.PublicKey
+	case "PUBLIC KEY":
+		public, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return Key{}, fmt.Errorf("auth: key %q: %w", id, err)
+		}
+		key.verify = public
+	default:
+		return Key{}, fmt.Errorf("auth: key %q: unsupported PEM block %q", id, block.Type)
+	}
+
+	// The algorithm has to match the key, or tokens would fail in confusing ways later
+	switch key.verify.(type) {
+	case ed25519.PublicKey:
+		if algorithm != AlgEdDSA {
+			return Key{}, fmt.Errorf("auth: key %q is an Ed25519 key, use EdDSA", id)
+		}
+	case *rsa.PublicKey:
+		if algorithm != AlgRS256 {
+			return Key{}, fmt.Errorf("auth: key %q is an RSA key, use RS256", id)
+		}
+	default:
+		return Key{}, fmt.Errorf("auth: key %q: unsupported key type", id)
+	}
+	return key, nil
+}
+
+// Sign signs claims with the signing key, naming it in the kid header
+func (k *Keyring) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(k.signer.Algorithm), claims)
+	token.Header["kid"] = k.signer.ID
+	return token.SignedString(k.signer.sign)
+}
+
+// Keyfunc selects the key a token names in its kid header for jwt.Parse. A
+// token has to use the algorithm its key was configured with.
+func (k *Keyring) Keyfunc(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := k.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("auth: unknown key id %q", kid)
+	}
+	if token.Method.Alg() != key.Algorithm {
+		return nil, fmt.Errorf("auth: key %q does not sign with %s", kid, token.Method.Alg())
+	}
+	return key.verify, nil
+}
+
+// JWK is a public key in JSON Web Key format
+type JWK struct {
+	KeyType   string `json:"kty"`
+	ID        string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+}
+
+// PublicKeys returns the asymmetric keys of the keyring as JWKs. Shared
+// secrets are left out.
+func (k *Keyring) PublicKeys() []JWK {
+	keys := make([]JWK, 0)
+	for _, id := range k.order {
+		key := k.keys[id]
+		jwk := JWK{ID: key.ID, Algorithm: key.Algorithm, Use: "sig"}
+
+		switch public := key.verify.(type) {
+		case ed25519.PublicKey:
+			jwk.KeyType, jwk.Curve = "OKP", "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		default:
+			continue
+		}
+		keys = append(keys, jwk)
+	}
+	return keys
+}
+
+var (
+	keyringOnce sync.Once
+	keyring     *Keyring
+	keyringErr  error
+)
+
+// Keys returns the keyring configured in the environment, loading it the
+// first time
+func Keys() (*Keyring, error) {
+	keyringOnce.Do(func() {
+		keyring, keyringErr = LoadKeyring()
+	})
+	return keyring, keyringErr
+}
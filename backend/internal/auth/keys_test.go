@@ -0,0 +1,347 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func claims() jwt.MapClaims {
+	return jwt.MapClaims{"sub": "1", "exp": time.Now().Add(time.Minute).Unix()}
+}
+
+func secretKey(id, secret string) Key {
+	return Key{ID: id, Algorithm: AlgHS256, sign: []byte(secret), verify: []byte(secret)}
+}
+
+func ed25519Key(t *testing.T, id string) (Key, ed25519.PrivateKey) {
+	t.Helper()
+
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := NewPrivateKey(id, private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, private
+}
+
+func rsaKey(t *testing.T, id string) (Key, *rsa.PrivateKey) {
+	t.Helper()
+
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := NewPrivateKey(id, private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, private
+}
+
+func newKeyring(t *testing.T, keys ...Key) *Keyring {
+	t.Helper()
+
+	ring, err := NewKeyring(keys...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ring
+}
+
+// parse checks a token against a keyring with nothing but its Keyfunc
+func parse(ring *Keyring, token string) error {
+	_, err := jwt.Parse(token, ring.Keyfunc)
+	return err
+}
+
+func TestKeyringRotation(t *testing.T) {
+	old, _ := ed25519Key(t, "old")
+	replacement, _ := rsaKey(t, "new")
+
+	before := newKeyring(t, old)
+	token, err := before.Sign(claims())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The new key signs, the old one still verifies what it signed
+	during := newKeyring(t, replacement, old)
+	if err := parse(during, token); err != nil {
+		t.Errorf("token of the old key during rotation: %v", err)
+	}
+	fresh, err := during.Sign(claims())
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := jwt.Parse(fresh, during.Keyfunc)
+	if err != nil {
+		t.Fatalf("token of the new key: %v", err)
+	}
+	if parsed.Header["kid"] != "new" || parsed.Method.Alg() != AlgRS256 {
+		t.Errorf("new token has kid %v and alg %s, want new and RS256", parsed.Header["kid"], parsed.Method.Alg())
+	}
+	if err := parse(before, fresh); err == nil {
+		t.Errorf("the keyring before rotation accepted a token of the new key")
+	}
+
+	// Once the old key is dropped its tokens stop working
+	after := newKeyring(t, replacement)
+	if err := parse(after, token); err == nil {
+		t.Errorf("token of a dropped key was accepted")
+	}
+	if err := parse(after, fresh); err != nil {
+		t.Errorf("token of the new key after rotation: %v", err)
+	}
+}
+
+func TestKeyfuncKeyLookup(t *testing.T) {
+	first := secretKey("first", "first secret")
+	second := secretKey("second", "second secret")
+	ring := newKeyring(t, first, second)
+
+	sign := func(kid any, secret string) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims())
+		if kid != nil {
+			token.Header["kid"] = kid
+		}
+		signed, err := token.SignedString([]byte(secret))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		ok    bool
+	}{
+		{"first key", sign("first", "first secret"), true},
+		{"second key", sign("second", "second secret"), true},
+		{"signed by another key than it names", sign("first", "second secret"), false},
+		{"unknown kid", sign("third", "first secret"), false},
+		{"no kid", sign(nil, "first secret"), false},
+		{"kid of another type", sign(1, "first secret"), false},
+	}
+
+	for _, tt := range tests {
+		if err := parse(ring, tt.token); (err == nil) != tt.ok {
+			t.Errorf("%s: err = %v, want ok %v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+func TestKeyfuncRejectsAlgorithmMismatch(t *testing.T) {
+	edKey, edPrivate := ed25519Key(t, "ed")
+	rsKey, rsPrivate := rsaKey(t, "rs")
+	hsKey := secretKey("hs", "shared secret")
+	ring := newKeyring(t, edKey, rsKey, hsKey)
+
+	rsPublic, err := x509.MarshalPKIXPublicKey(&rsPrivate.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: rsPublic})
+
+	sign := func(method jwt.SigningMethod, kid string, key any) string {
+		token := jwt.NewWithClaims(method, claims())
+		token.Header["kid"] = kid
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		// The classic confusion: the published public key used as an HMAC secret
+		{"HS256 with the RSA public key as secret", sign(jwt.SigningMethodHS256, "rs", rsPEM)},
+		{"HS256 with the Ed25519 public key as secret", sign(jwt.SigningMethodHS256, "ed", []byte(edPrivate.Public().(ed25519.PublicKey)))},
+		{"RS256 under the Ed25519 kid", sign(jwt.SigningMethodRS256, "ed", rsPrivate)},
+		{"EdDSA under the RSA kid", sign(jwt.SigningMethodEdDSA, "rs", edPrivate)},
+		{"RS256 under the HS256 kid", sign(jwt.SigningMethodRS256, "hs", rsPrivate)},
+		{"HS384 under the HS256 kid", sign(jwt.SigningMethodHS384, "hs", []byte("shared secret"))},
+		{"none", sign(jwt.SigningMethodNone, "hs", jwt.UnsafeAllowNoneSignatureType)},
+	}
+
+	for _, tt := range tests {
+		if err := parse(ring, tt.token); err == nil {
+			t.Errorf("%s: token accepted", tt.name)
+		}
+	}
+
+	// Each key still takes tokens in its own algorithm
+	for _, key := range []Key{edKey, rsKey, hsKey} {
+		token := sign(jwt.GetSigningMethod(key.Algorithm), key.ID, key.sign)
+		if err := parse(ring, token); err != nil {
+			t.Errorf("%s token of its own key: %v", key.Algorithm, err)
+		}
+	}
+}
+
+func TestPublicKeys(t *testing.T) {
+	edKey, edPrivate := ed25519Key(t, "ed")
+	rsKey, rsPrivate := rsaKey(t, "rs")
+	ring := newKeyring(t, secretKey("hs", "never published"), edKey, rsKey)
+
+	keys := ring.PublicKeys()
+	if len(keys) != 2 || keys[0].ID != "ed" || keys[1].ID != "rs" {
+		t.Fatalf("PublicKeys = %+v, want ed and rs in keyring order", keys)
+	}
+
+	ed := keys[0]
+	if ed.KeyType != "OKP" || ed.Curve != "Ed25519" || ed.Algorithm != AlgEdDSA || ed.Use != "sig" || ed.N != "" {
+		t.Errorf("Ed25519 JWK = %+v", ed)
+	}
+	x, err := base64.RawURLEncoding.DecodeString(ed.X)
+	if err != nil || !edPrivate.Public().(ed25519.PublicKey).Equal(ed25519.PublicKey(x)) {
+		t.Errorf("Ed25519 JWK x does not decode to the public key: %v", err)
+	}
+
+	rs := keys[1]
+	if rs.KeyType != "RSA" || rs.Algorithm != AlgRS256 || rs.Use != "sig" || rs.Curve != "" || rs.X != "" {
+		t.Errorf("RSA JWK = %+v", rs)
+	}
+	if rs.E != "AQAB" {
+		t.Errorf("RSA JWK e = %q, want AQAB for 65537", rs.E)
+	}
+	n, err := base64.RawURLEncoding.DecodeString(rs.N)
+	if err != nil || new(big.Int).SetBytes(n).Cmp(rsPrivate.N) != 0 {
+		t.Errorf("RSA JWK n does not decode to the modulus: %v", err)
+	}
+
+	data, err := json.Marshal(keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "never published") || strings.Contains(string(data), `"kid":"hs"`) {
+		t.Errorf("JWKS %s mentions the shared secret key", data)
+	}
+	if strings.Contains(string(data), `"n":""`) || strings.Contains(string(data), `"crv":""`) {
+		t.Errorf("JWKS %s has empty members", data)
+	}
+
+	if keys := newKeyring(t, secretKey("hs", "secret")).PublicKeys(); keys == nil || len(keys) != 0 {
+		t.Errorf("PublicKeys of shared secrets only = %#v, want an empty list", keys)
+	}
+}
+
+func TestNewKeyring(t *testing.T) {
+	edKey, edPrivate := ed25519Key(t, "ed")
+	publicOnly := Key{ID: "public", Algorithm: AlgEdDSA, verify: edPrivate.Public()}
+
+	if _, err := NewKeyring(); err == nil {
+		t.Errorf("NewKeyring of no keys succeeded")
+	}
+	if _, err := NewKeyring(edKey, secretKey("ed", "secret")); err == nil {
+		t.Errorf("NewKeyring with a duplicate kid succeeded")
+	}
+	if _, err := NewKeyring(publicOnly, edKey); err == nil {
+		t.Errorf("NewKeyring signing with a public key succeeded")
+	}
+	if _, err := NewKeyring(edKey, publicOnly); err != nil {
+		t.Errorf("NewKeyring verifying with a public key: %v", err)
+	}
+}
+
+// writePEM writes a PEM block to a file and returns its path
+func writePEM(t *testing.T, name, blockType string, der []byte) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadKeyring(t *testing.T) {
+	_, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edDER, err := x509.MarshalPKCS8PrivateKey(edPrivate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPublicDER, err := x509.MarshalPKIXPublicKey(edPrivate.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsPrivate, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edFile := writePEM(t, "ed.pem", "PRIVATE KEY", edDER)
+	edPublicFile := writePEM(t, "ed.pub", "PUBLIC KEY", edPublicDER)
+	rsFile := writePEM(t, "rs.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsPrivate))
+
+	tests := []struct {
+		name   string
+		keys   string
+		secret string
+		kids   []string
+		ok     bool
+	}{
+		{"secret only", "", "secret", []string{"default"}, true},
+		{"nothing", "", "", nil, false},
+		{"keys over the secret", "a:HS256:one, b:HS256:two", "secret", []string{"a", "b"}, true},
+		{"pem files", "ed:EdDSA:" + edFile + ",rs:RS256:" + rsFile + ",old:EdDSA:" + edPublicFile, "", []string{"ed", "rs", "old"}, true},
+		{"public key first", "old:EdDSA:" + edPublicFile, "", nil, false},
+		{"wrong algorithm for the key", "rs:EdDSA:" + rsFile, "", nil, false},
+		{"Ed25519 key as RS256", "ed:RS256:" + edFile, "", nil, false},
+		{"unknown algorithm", "a:HS512:one", "", nil, false},
+		{"missing file", "ed:EdDSA:" + filepath.Join(t.TempDir(), "missing.pem"), "", nil, false},
+		{"not a pem file", "ed:EdDSA:" + "keys_test.go", "", nil, false},
+		{"malformed entry", "a:HS256", "", nil, false},
+		{"empty secret", "a:HS256:", "", nil, false},
+		{"duplicate kid", "a:HS256:one,a:HS256:two", "", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("JWT_KEYS", tt.keys)
+			t.Setenv("JWT_SECRET", tt.secret)
+
+			ring, err := LoadKeyring()
+			if (err == nil) != tt.ok {
+				t.Fatalf("LoadKeyring: %v, want ok %v", err, tt.ok)
+			}
+			if err != nil {
+				return
+			}
+			if strings.Join(ring.order, ",") != strings.Join(tt.kids, ",") {
+				t.Errorf("kids = %v, want %v", ring.order, tt.kids)
+			}
+
+			// The loaded keyring signs tokens it accepts
+			token, err := ring.Sign(claims())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := parse(ring, token); err != nil {
+				t.Errorf("token of the loaded keyring: %v", err)
+			}
+		})
+	}
+}
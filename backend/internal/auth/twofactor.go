@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// ChallengeTTL is how long a user has to enter their code after their password
+	ChallengeTTL = 5 * time.Minute
+	// RecoveryCodeCount recovery codes are handed out at a time
+	RecoveryCodeCount = 10
+
+	challengePurpose = "2fa"
+)
+
+// IssueChallengeToken signs a token saying the password of userID was checked,
+// to be exchanged for a session together with the second factor. It has no
+// sid, so it is never accepted as an access token.
+func IssueChallengeToken(userID int) (string, error) {
+	keys, err := Keys()
+	if err != nil {
+		return "", err
+	}
+
+	return keys.Sign(jwt.MapClaims{
+		"sub":     strconv.Itoa(userID),
+		"subject": userID,
+		"purpose": challengePurpose,
+		"exp":     time.Now().Add(ChallengeTTL).Unix(),
+	})
+}
+
+// ParseChallengeToken verifies a challenge token and returns the user it is for
+func ParseChallengeToken(tokenString string) (int, error) {
+	keys, err := Keys()
+	if err != nil {
+		return 0, err
+	}
+
+	token, err := jwt.Parse(tokenString, keys.Keyfunc,
+		jwt.WithValidMethods([]string{AlgHS256, AlgEdDSA, AlgRS256}), jwt.WithExpirationRequired())
+	if err != nil {
+		return 0, ErrInvalidToken
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["purpose"] != challengePurpose {
+		return 0, ErrInvalidToken
+	}
+
+	sub, ok := claims["subject"].(float64)
+	if !ok {
+		return 0, ErrInvalidToken
+	}
+	return int(sub), nil
+}
+
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// NewRecoveryCodes returns fresh recovery codes, formatted like
+// "abcde-fghij", and their hashes
+func NewRecoveryCodes() (codes, hashes []string, err error) {
+	for range RecoveryCodeCount {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+
+		code := strings.ToLower(recoveryEncoding.EncodeToString(b))[:10]
+		codes = append(codes, code[:5]+"-"+code[5:])
+		hashes = append(hashes, HashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+// HashRecoveryCode hashes a recovery code the way it is stored. Case, spaces
+// and dashes do not matter, so codes can be typed however they were copied.
+func HashRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.NewReplacer("-", "", " ", "").Replace(code)
+	return HashToken(code)
+}
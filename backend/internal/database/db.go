@@ -1,36 +1,64 @@
 package database
 
 import (
-	"fmt"
 	"log"
 	"os"
-
-	"github.com/joho/godotenv"
-	"github.com/supabase-community/supabase-go"
+	"web-forum/internal/store"
+	"web-forum/internal/store/memory"
+	"web-forum/internal/store/sqlstore"
 )
 
-var client *supabase.Client
-
-func InitDB() {
-	err := godotenv.Load()
-	if err != nil {
-		log.Println("No .env file found, using environment variables instead")
+// InitDB opens the storage backend named by driver: "postgres", "sqlite" or
+// "memory". An empty driver picks postgres when DATABASE_URL is set and the
+// in-memory store otherwise.
+func InitDB(driver string) store.Store {
+	databaseURL := os.Getenv("DATABASE_URL")
+	if driver == "" {
+		driver = "memory"
+		if databaseURL != "" {
+			driver = "postgres"
+		}
 	}
 
-	supabaseURL := os.Getenv("SUPABASE_URL")
-	supabaseKey := os.Getenv("SUPABASE_KEY")
-	if supabaseURL == "" || supabaseKey == "" {
-		log.Fatal("SUPABASE_URL or SUPABASE_URL must be set in .env or environment")
-	}
+	switch driver {
+	case "postgres":
+		if databaseURL == "" {
+			log.Fatal("DATABASE_URL must be set in .env or environment")
+		}
 
-	client, err = supabase.NewClient(supabaseURL, supabaseKey, &supabase.ClientOptions{})
-	if err != nil {
-		fmt.Println("Failed to initalize the client: ", err)
-	}
+		db, err := sqlstore.OpenPostgres(databaseURL)
+		if err != nil {
+			log.Fatal("Failed to connect to the database: ", err)
+		}
 
-	log.Println("Successfully initialized Supabase client")
-}
+		log.Println("Successfully connected to PostgreSQL")
+		return db
+
+	case "sqlite":
+		// DATABASE_URL is the path of the database file for SQLite
+		path := databaseURL
+		if path == "" {
+			path = "forum.db"
+		}
+
+		db, err := sqlstore.OpenSQLite(path)
+		if err != nil {
+			log.Fatal("Failed to open the database: ", err)
+		}
+
+		log.Printf("Successfully opened SQLite database %s", path)
+		return db
+
+	case "memory":
+		// Never silently run production off a store that forgets everything on restart
+		if os.Getenv("ENVIRONMENT") == "production" {
+			log.Fatal("The in-memory store cannot be used in production")
+		}
+
+		log.Println("Using in-memory store. Data will be lost on restart")
+		return memory.New()
+	}
 
-func GetClient() *supabase.Client {
-	return client
+	log.Fatalf("Unknown database driver %q", driver)
+	return nil
 }
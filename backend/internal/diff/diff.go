@@ -0,0 +1,166 @@
+// Package diff compares two texts line by line with Myers' algorithm, which
+// finds the fewest lines to delete and insert to turn one into the other.
+package diff
+
+import (
+	"slices"
+	"strings"
+)
+
+// Op says what happened to the lines of a Chunk
+type Op string
+
+const (
+	Equal  Op = "equal"
+	Insert Op = "insert"
+	Delete Op = "delete"
+)
+
+// Chunk is a run of lines with the same Op. Text keeps their line breaks, so
+// joining the Equal and Delete chunks gives back the old text and joining the
+// Equal and Insert chunks the new one.
+type Chunk struct {
+	Op   Op     `json:"op"`
+	Text string `json:"text"`
+}
+
+// MaxEdits bounds the work on texts that have little in common. Past it,
+// whatever lies between their common start and end is reported as deleted
+// and inserted whole.
+const MaxEdits = 1000
+
+// Lines splits text into lines, each with its line break. The last line has
+// none if the text does not end in one.
+func Lines(text string) []string {
+	if text == "" {
+		return nil
+	}
+
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// Text diffs two texts line by line
+func Text(a, b string) []Chunk {
+	return Diff(Lines(a), Lines(b))
+}
+
+// Diff diffs two lists of lines
+func Diff(a, b []string) []Chunk {
+	var out chunks
+
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	out.add(Equal, a[:prefix]...)
+	myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix], &out)
+	out.add(Equal, a[len(a)-suffix:]...)
+
+	if out == nil {
+		return []Chunk{}
+	}
+	return out
+}
+
+// myers adds the shortest edit turning a into b to out. Each round d reaches
+// every diagonal k = x - y with d edits, as far along it as matching lines
+// allow; the furthest points of each round are kept to walk the edit back.
+func myers(a, b []string, out *chunks) {
+	n, m := len(a), len(b)
+	limit := min(n+m, MaxEdits)
+	offset := limit + 1
+	// v[offset+k] is the furthest x reached on diagonal k
+	v := make([]int, 2*offset+1)
+	// trace[d] is v[offset-d-1 : offset+d+2] as it was before round d
+	var trace [][]int
+
+	found := false
+	for d := 0; d <= limit && !found; d++ {
+		trace = append(trace, slices.Clone(v[offset-d-1:offset+d+2]))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || k != d && v[offset+k-1] < v[offset+k+1] {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x, y = x+1, y+1
+			}
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+	}
+
+	if !found {
+		out.add(Delete, a...)
+		out.add(Insert, b...)
+		return
+	}
+
+	// Walk back from the end, collecting the edit in reverse
+	type step struct {
+		op   Op
+		line string
+	}
+	var steps []step
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		at := func(k int) int { return trace[d][k+d+1] }
+		k := x - y
+
+		prevK := k - 1
+		if k == -d || k != d && at(k-1) < at(k+1) {
+			prevK = k + 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			steps = append(steps, step{Equal, a[x-1]})
+			x, y = x-1, y-1
+		}
+		if d > 0 {
+			if x == prevX {
+				steps = append(steps, step{Insert, b[y-1]})
+			} else {
+				steps = append(steps, step{Delete, a[x-1]})
+			}
+		}
+		x, y = prevX, prevY
+	}
+
+	for i := len(steps) - 1; i >= 0; i-- {
+		out.add(steps[i].op, steps[i].line)
+	}
+}
+
+// chunks merges lines into the last chunk while the op stays the same
+type chunks []Chunk
+
+func (c *chunks) add(op Op, lines ...string) {
+	text := strings.Join(lines, "")
+	if text == "" {
+		return
+	}
+
+	if last := len(*c) - 1; last >= 0 && (*c)[last].Op == op {
+		(*c)[last].Text += text
+		return
+	}
+	*c = append(*c, Chunk{Op: op, Text: text})
+}
@@ -0,0 +1,161 @@
+package diff
+
+import (
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"", nil},
+		{"\n", []string{"\n"}},
+		{"a", []string{"a"}},
+		{"a\n", []string{"a\n"}},
+		{"a\nb", []string{"a\n", "b"}},
+		{"a\n\nb\n", []string{"a\n", "\n", "b\n"}},
+	}
+
+	for _, tt := range tests {
+		if got := Lines(tt.text); !slices.Equal(got, tt.want) {
+			t.Errorf("Lines(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestText(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want []Chunk
+	}{
+		{"both empty", "", "", []Chunk{}},
+		{"from empty", "", "a\nb\n", []Chunk{{Insert, "a\nb\n"}}},
+		{"to empty", "a\nb\n", "", []Chunk{{Delete, "a\nb\n"}}},
+		{"identical", "a\nb\n", "a\nb\n", []Chunk{{Equal, "a\nb\n"}}},
+		{"identical without a final newline", "a\nb", "a\nb", []Chunk{{Equal, "a\nb"}}},
+		{"newline added at the end", "a\nb", "a\nb\n", []Chunk{{Equal, "a\n"}, {Delete, "b"}, {Insert, "b\n"}}},
+		{"newline removed at the end", "a\n", "a", []Chunk{{Delete, "a\n"}, {Insert, "a"}}},
+		{"line inserted", "a\nc\n", "a\nb\nc\n", []Chunk{{Equal, "a\n"}, {Insert, "b\n"}, {Equal, "c\n"}}},
+		{"line deleted", "a\nb\nc\n", "a\nc\n", []Chunk{{Equal, "a\n"}, {Delete, "b\n"}, {Equal, "c\n"}}},
+		{"line changed", "a\nb\nc\n", "a\nx\nc\n", []Chunk{{Equal, "a\n"}, {Delete, "b\n"}, {Insert, "x\n"}, {Equal, "c\n"}}},
+		{"blank lines", "\n\n", "\n\n\n", []Chunk{{Equal, "\n\n"}, {Insert, "\n"}}},
+		{"nothing in common", "a\nb\n", "c\nd\n", []Chunk{{Delete, "a\nb\n"}, {Insert, "c\nd\n"}}},
+	}
+
+	for _, tt := range tests {
+		if got := Text(tt.a, tt.b); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: Text(%q, %q) = %q, want %q", tt.name, tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+// sides joins the chunks back into the old and the new text
+func sides(chunks []Chunk) (string, string) {
+	var a, b strings.Builder
+	for _, chunk := range chunks {
+		if chunk.Op != Insert {
+			a.WriteString(chunk.Text)
+		}
+		if chunk.Op != Delete {
+			b.WriteString(chunk.Text)
+		}
+	}
+	return a.String(), b.String()
+}
+
+// edits counts the lines a diff deletes and inserts
+func edits(chunks []Chunk) int {
+	count := 0
+	for _, chunk := range chunks {
+		if chunk.Op != Equal {
+			count += len(Lines(chunk.Text))
+		}
+	}
+	return count
+}
+
+// lcs is the length of the longest common subsequence of a and b, by the
+// textbook dynamic programme
+func lcs(a, b []string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for i := range a {
+		for j := range b {
+			if a[i] == b[j] {
+				cur[j+1] = prev[j] + 1
+			} else {
+				cur[j+1] = max(cur[j], prev[j+1])
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// randomText builds up to maxLines lines from a few words, so texts share
+// lines often, sometimes leaving out the final newline
+func randomText(r *rand.Rand, maxLines int) string {
+	words := []string{"a", "b", "c", "d", ""}
+	var sb strings.Builder
+	for range r.Intn(maxLines + 1) {
+		sb.WriteString(words[r.Intn(len(words))])
+		sb.WriteString("\n")
+	}
+	text := sb.String()
+	if text != "" && r.Intn(3) == 0 {
+		text = strings.TrimSuffix(text, "\n")
+	}
+	return text
+}
+
+func TestTextRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 2000; i++ {
+		a, b := randomText(r, 12), randomText(r, 12)
+		chunks := Text(a, b)
+
+		if gotA, gotB := sides(chunks); gotA != a || gotB != b {
+			t.Fatalf("Text(%q, %q) = %q joins back to %q and %q", a, b, chunks, gotA, gotB)
+		}
+		for j, chunk := range chunks {
+			if chunk.Text == "" {
+				t.Fatalf("Text(%q, %q) has an empty chunk at %d", a, b, j)
+			}
+			if j > 0 && chunks[j-1].Op == chunk.Op {
+				t.Fatalf("Text(%q, %q) has two %s chunks in a row", a, b, chunk.Op)
+			}
+		}
+
+		// Myers finds the fewest edits there are
+		linesA, linesB := Lines(a), Lines(b)
+		if got, want := edits(chunks), len(linesA)+len(linesB)-2*lcs(linesA, linesB); got != want {
+			t.Fatalf("Text(%q, %q) makes %d edits, want %d", a, b, got, want)
+		}
+	}
+}
+
+func TestTextPastMaxEdits(t *testing.T) {
+	var a, b strings.Builder
+	a.WriteString("same start\n")
+	b.WriteString("same start\n")
+	for i := 0; i < MaxEdits; i++ {
+		a.WriteString("old\n")
+		b.WriteString("new\n")
+	}
+	a.WriteString("same end\n")
+	b.WriteString("same end\n")
+
+	chunks := Text(a.String(), b.String())
+	if len(chunks) != 4 || chunks[0].Op != Equal || chunks[1].Op != Delete || chunks[2].Op != Insert || chunks[3].Op != Equal {
+		t.Fatalf("Text of texts with nothing in common past MaxEdits has %d chunks", len(chunks))
+	}
+	if gotA, gotB := sides(chunks); gotA != a.String() || gotB != b.String() {
+		t.Errorf("Text past MaxEdits does not join back to its texts")
+	}
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"web-forum/internal/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetAuditLog lists audit entries, newest first. Admins only. Takes limit and
+// before, the id of the last entry of the previous page.
+func GetAuditLog(audits store.AuditStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireAdmin(c) {
+			return
+		}
+
+		limit, ok := pageLimit(c)
+		if !ok {
+			return
+		}
+
+		before := 0
+		if beforeStr := c.Query("before"); beforeStr != "" {
+			var err error
+			before, err = strconv.Atoi(beforeStr)
+			if err != nil || before < 1 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid before id"})
+				return
+			}
+		}
+
+		entries, err := audits.ListAudit(c.Request.Context(), before, limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve audit log"})
+			return
+		}
+
+		c.JSON(http.StatusOK, entries)
+	}
+}
+
+// audit records an audit entry for the request, logging rather than failing
+// the request if it cannot be written
+func audit(c *gin.Context, audits store.AuditStore, entry store.AuditEntry) {
+	entry.IPAddress = c.ClientIP()
+	if err := audits.RecordAudit(c.Request.Context(), entry); err != nil {
+		log.Printf("Error recording audit entry: %v", err)
+	}
+}
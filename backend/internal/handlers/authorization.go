@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"net/http"
+	"web-forum/internal/policy"
+
+	"github.com/gin-gonic/gin"
+)
+
+// permissionsOf returns the permissions RequireAuthentication attached to the request
+func permissionsOf(c *gin.Context) policy.Permissions {
+	permissions, _ := c.Get("permissions")
+	return permissions.(policy.Permissions)
+}
+
+// canModify checks that the signed-in user may edit or delete content created
+// by createdBy in the topic. If not, it responds with 403 and message.
+func canModify(c *gin.Context, createdBy, topicID int, message string) bool {
+	if !permissionsOf(c).CanModify(createdBy, topicID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": message})
+		return false
+	}
+	return true
+}
+
+// requireModerator responds with 403 and returns false unless the signed-in
+// user moderates the topic, as admins do every topic
+func requireModerator(c *gin.Context, topicID int) bool {
+	if !permissionsOf(c).Moderates(topicID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only moderators of this topic can do this"})
+		return false
+	}
+	return true
+}
+
+// requireAdmin responds with 403 and returns false unless the signed-in user is an admin
+func requireAdmin(c *gin.Context) bool {
+	if !permissionsOf(c).IsAdmin() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only admins can do this"})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+	"web-forum/internal/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	maxCategoryName        = 50
+	maxCategoryDescription = 1000
+)
+
+// categoryInput is the body of the category create and update routes
+type categoryInput struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	// ParentID nests the category under another; leaving it out makes it top-level
+	ParentID *int `json:"parent_id"`
+	// Position orders the category among its siblings, lowest first
+	Position int `json:"position"`
+}
+
+// bindCategory reads and checks a category body. On bad input it responds
+// with 400 and returns false.
+func bindCategory(c *gin.Context) (categoryInput, bool) {
+	var input categoryInput
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return input, false
+	}
+
+	input.Name = strings.TrimSpace(input.Name)
+	input.Description = strings.TrimSpace(input.Description)
+	if input.Name == "" || utf8.RuneCountInString(input.Name) > maxCategoryName {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Name must be between 1 and 50 characters"})
+		return input, false
+	}
+	if utf8.RuneCountInString(input.Description) > maxCategoryDescription {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Description must be at most 1000 characters"})
+		return input, false
+	}
+	return input, true
+}
+
+// GetCategories lists the categories as a tree, each with its subcategories
+// under children, siblings by position
+func GetCategories(categories store.CategoryStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		result, err := categories.ListCategories(c.Request.Context())
+		if err != nil {
+			log.Printf("Error fetching categories: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve categories"})
+			return
+		}
+
+		c.JSON(http.StatusOK, store.CategoryTree(result))
+	}
+}
+
+// CreateCategory adds a category, top-level or under a parent. Admins only.
+func CreateCategory(categories store.CategoryStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireAdmin(c) {
+			return
+		}
+
+		input, ok := bindCategory(c)
+		if !ok {
+			return
+		}
+
+		category, err := categories.CreateCategory(c.Request.Context(), input.Name, input.Description, input.ParentID, input.Position)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Parent category not found"})
+				return
+			}
+			if errors.Is(err, store.ErrConflict) {
+				c.JSON(http.StatusConflict, gin.H{"error": "A category with this name already exists here"})
+				return
+			}
+
+			log.Printf("Error creating category: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create category"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"message": "Category created successfully", "category": category})
+	}
+}
+
+// UpdateCategory renames, describes, moves or reorders a category. Admins only.
+func UpdateCategory(categories store.CategoryStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireAdmin(c) {
+			return
+		}
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category id"})
+			return
+		}
+
+		input, ok := bindCategory(c)
+		if !ok {
+			return
+		}
+
+		if _, err := categories.GetCategory(c.Request.Context(), id); err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+				return
+			}
+
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve category"})
+			return
+		}
+
+		category, err := categories.UpdateCategory(c.Request.Context(), id, input.Name, input.Description, input.ParentID, input.Position)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Parent category not found"})
+				return
+			}
+			if errors.Is(err, store.ErrCategoryCycle) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "A category cannot be moved under itself or its subcategories"})
+				return
+			}
+			if errors.Is(err, store.ErrConflict) {
+				c.JSON(http.StatusConflict, gin.H{"error": "A category with this name already exists here"})
+				return
+			}
+
+			log.Printf("Error updating category: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to edit category"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Category edited successfully", "category": category})
+	}
+}
+
+// DeleteCategory deletes a category, moving its subcategories and topics up
+// to its parent. Admins only.
+func DeleteCategory(categories store.CategoryStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireAdmin(c) {
+			return
+		}
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category id"})
+			return
+		}
+
+		err = categories.DeleteCategory(c.Request.Context(), id)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+				return
+			}
+			if errors.Is(err, store.ErrConflict) {
+				c.JSON(http.StatusConflict, gin.H{"error": "A subcategory has the same name as a category it would move next to. Rename it first"})
+				return
+			}
+
+			log.Printf("Error deleting category: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete category"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Category deleted successfully"})
+	}
+}
@@ -1,145 +1,95 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
-	"strings"
-	"time"
+	"web-forum/internal/store"
 
 	"github.com/gin-gonic/gin"
-	"github.com/supabase-community/supabase-go"
 )
 
-type Comment struct {
-	ID        int    `json:"id"`
-	PostID    int    `json:"post_id"`
-	Content   string `json:"content" binding:"required"`
-	CreatedBy int    `json:"created_by"`
-	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
-	Users     struct {
-		Username string `json:"username"`
-	} `json:"users"`
-}
-
-type FlatComment struct {
-	ID           int    `json:"id"`
-	PostID       int    `json:"post_id"`
-	Content      string `json:"content" binding:"required"`
-	CreatedBy    int    `json:"created_by"`
-	CreatedAt    string `json:"created_at"`
-	UpdatedAt    string `json:"updated_at"`
-	Username     string `json:"username"`
-	LikeCount    int    `json:"like_count"`
-	DislikeCount int    `json:"dislike_count"`
-	NetScore     int    `json:"net_score"`
-	UserReaction *int   `json:"user_reaction"`
-}
-
-func GetComments(client *supabase.Client) gin.HandlerFunc {
+func GetComments(comments store.CommentStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		postID := c.Query("post_id")
-		var comments []Comment
+		postIDStr := c.Query("post_id")
 
-		if postID == "" {
+		if postIDStr == "" {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "post_id is required"})
 			return
 		}
 
-		_, err := client.From("comments").Select(`id, post_id, content, created_by, created_at, updated_at, users(username)`, "", false).Eq("post_id", postID).ExecuteTo(&comments)
-
+		postID, err := strconv.Atoi(postIDStr)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve comments"})
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post id"})
 			return
 		}
 
-		commentIDs := make([]string, 0, len(comments))
-		for _, comment := range comments {
-			commentIDs = append(commentIDs, strconv.Itoa(comment.ID))
-		}
-
-		type CommentReactionRow struct {
-			CommentID int `json:"comment_id"`
-			UserID    int `json:"user_id"`
-			Reaction  int `json:"reaction"`
+		opts, ok := listOptions(c)
+		if !ok {
+			return
 		}
 
-		var reactions []CommentReactionRow
+		user, _ := c.Get("user")
+		currentUser := user.(store.User)
+		userID := currentUser.ID
 
-		if len(commentIDs) > 0 {
-			_, err = client.From("comment_reactions").Select("comment_id, user_id, reaction", "", false).In("comment_id", commentIDs).ExecuteTo(&reactions)
+		view := c.DefaultQuery("view", "flat")
+		if view != "flat" && view != "thread" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "view must be flat or thread"})
+			return
+		}
 
+		// view=thread pages through top-level comments, each followed by its
+		// replies with their depth and per-branch reply counts
+		if view == "thread" {
+			page, err := comments.ListThread(c.Request.Context(), postID, userID, opts)
 			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reactions"})
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve comments"})
 				return
 			}
-		}
-
-		flatComments := make([]FlatComment, len(comments))
-		for i, comment := range comments {
-			flatComments[i] = FlatComment{
-				ID:           comment.ID,
-				PostID:       comment.PostID,
-				Content:      comment.Content,
-				CreatedBy:    comment.CreatedBy,
-				CreatedAt:    comment.CreatedAt,
-				UpdatedAt:    comment.UpdatedAt,
-				Username:     comment.Users.Username,
-				LikeCount:    0,
-				DislikeCount: 0,
-				NetScore:     0,
-				UserReaction: nil,
-			}
-		}
 
-		postMap := make(map[int]*FlatComment)
-		// Faster to search for specific post
-		for i := range flatComments {
-			postMap[flatComments[i].ID] = &flatComments[i]
+			c.JSON(http.StatusOK, gin.H{
+				"comments":    page.Items,
+				"next_cursor": nextCursor(page.Next),
+			})
+			return
 		}
 
-		user, _ := c.Get("user")
-		currentUser := user.(User)
-		userID := currentUser.ID
-
-		for _, reaction := range reactions {
-			comment := postMap[reaction.CommentID]
-			if comment == nil {
-				continue
-			}
-
-			if reaction.Reaction == 1 {
-				comment.LikeCount++
-				comment.NetScore++
-			} else if reaction.Reaction == -1 {
-				comment.DislikeCount++
-				comment.NetScore--
-			}
-
-			if reaction.UserID == userID {
-				r := reaction.Reaction
-				comment.UserReaction = &r
-			}
+		page, err := comments.ListComments(c.Request.Context(), postID, userID, opts)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve comments"})
+			return
 		}
 
-		c.JSON(http.StatusOK, flatComments)
+		c.JSON(http.StatusOK, gin.H{
+			"comments":    page.Items,
+			"next_cursor": nextCursor(page.Next),
+		})
 	}
 }
 
-func GetComment(client *supabase.Client) gin.HandlerFunc {
+func GetComment(comments store.CommentStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		commentID := c.Param("id")
+		commentIDStr := c.Param("id")
 
-		if commentID == "" {
+		if commentIDStr == "" {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "comment_id is required"})
 			return
 		}
-		var comment Comment
 
-		_, err := client.From("comments").Select(`id, post_id, content, created_by, created_at, updated_at, users(username)`, "", false).Eq("id", commentID).Single().ExecuteTo(&comment)
+		commentID, err := strconv.Atoi(commentIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid comment id"})
+			return
+		}
+
+		user, _ := c.Get("user")
+		currentUser := user.(store.User)
+		userID := currentUser.ID
 
+		comment, err := comments.GetComment(c.Request.Context(), commentID, userID)
 		if err != nil {
-			if strings.Contains(err.Error(), "PGRST116") || strings.Contains(err.Error(), "0 rows") {
+			if errors.Is(err, store.ErrNotFound) {
 				c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
 				return
 			}
@@ -148,80 +98,58 @@ func GetComment(client *supabase.Client) gin.HandlerFunc {
 			return
 		}
 
-		type CommentReactionRow struct {
-			CommentID int `json:"comment_id"`
-			UserID    int `json:"user_id"`
-			Reaction  int `json:"reaction"`
-		}
-
-		var reactions []CommentReactionRow
-
-		_, err = client.From("comment_reactions").Select("comment_id, user_id, reaction", "", false).Eq("comment_id", commentID).ExecuteTo(&reactions)
+		c.JSON(http.StatusOK, comment)
+	}
+}
 
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reactions"})
-			return
+// CreateComment adds a comment to a post, unless the post is locked or its
+// topic is archived
+func CreateComment(comments store.CommentStore, posts store.PostStore, topics store.TopicStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var comment struct {
+			PostID int `json:"post_id"`
+			// ParentID makes the comment a reply to another comment on the same post
+			ParentID *int   `json:"parent_id"`
+			Content  string `json:"content" binding:"required"`
 		}
 
-		flatComment := FlatComment{
-			ID:           comment.ID,
-			PostID:       comment.PostID,
-			Content:      comment.Content,
-			CreatedBy:    comment.CreatedBy,
-			CreatedAt:    comment.CreatedAt,
-			UpdatedAt:    comment.UpdatedAt,
-			Username:     comment.Users.Username,
-			LikeCount:    0,
-			DislikeCount: 0,
-			NetScore:     0,
-			UserReaction: nil,
+		if err := c.BindJSON(&comment); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input."})
+			return
 		}
 
 		user, _ := c.Get("user")
-		currentUser := user.(User)
+		currentUser := user.(store.User)
 		userID := currentUser.ID
 
-		for _, reaction := range reactions {
-			if reaction.Reaction == 1 {
-				flatComment.LikeCount++
-				flatComment.NetScore++
-			} else if reaction.Reaction == -1 {
-				flatComment.DislikeCount++
-				flatComment.NetScore--
+		post, err := posts.GetPost(c.Request.Context(), comment.PostID, userID)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+				return
 			}
 
-			if reaction.UserID == userID {
-				r := reaction.Reaction
-				flatComment.UserReaction = &r
-			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve post"})
+			return
 		}
 
-		c.JSON(http.StatusOK, flatComment)
-	}
-}
-
-func CreateComment(client *supabase.Client) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		var comment Comment
-
-		if err := c.BindJSON(&comment); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input."})
+		if !requireOpenTopic(c, topics, post.TopicID) {
 			return
 		}
 
-		user, _ := c.Get("user")
-		currentUser := user.(User)
-		userID := currentUser.ID
-
-		data := map[string]interface{}{
-			"post_id":    comment.PostID,
-			"content":    comment.Content,
-			"created_by": userID,
+		if post.Locked() {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Post is locked and no longer takes comments"})
+			return
 		}
 
-		_, _, err := client.From("comments").Insert(data, false, "", "", "").Execute()
+		_, err = comments.CreateComment(c.Request.Context(), comment.PostID, comment.ParentID, comment.Content, userID)
 
 		if err != nil {
+			if errors.Is(err, store.ErrInvalidParent) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Parent comment must belong to the same post"})
+				return
+			}
+
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create comment"})
 			return
 		}
@@ -230,9 +158,13 @@ func CreateComment(client *supabase.Client) gin.HandlerFunc {
 	}
 }
 
-func UpdateComment(client *supabase.Client) gin.HandlerFunc {
+func UpdateComment(comments store.CommentStore, topics store.TopicStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		id := c.Param("id")
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid comment id"})
+			return
+		}
 
 		var input struct {
 			Content string `json:"content" binding:"required"`
@@ -244,16 +176,13 @@ func UpdateComment(client *supabase.Client) gin.HandlerFunc {
 		}
 
 		user, _ := c.Get("user")
-		currentUser := user.(User)
+		currentUser := user.(store.User)
 		userID := currentUser.ID
 
-		var result struct {
-			CreatedBy int `json:"created_by"`
-		}
-		_, err := client.From("comments").Select("created_by", "", false).Eq("id", id).Single().ExecuteTo(&result)
+		result, err := comments.GetComment(c.Request.Context(), id, userID)
 
 		if err != nil {
-			if strings.Contains(err.Error(), "PGRST116") || strings.Contains(err.Error(), "0 rows") {
+			if errors.Is(err, store.ErrNotFound) {
 				c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
 				return
 			}
@@ -262,16 +191,15 @@ func UpdateComment(client *supabase.Client) gin.HandlerFunc {
 			return
 		}
 
-		if result.CreatedBy != userID {
-			c.JSON(http.StatusForbidden, gin.H{"error": "You can only edit comments created by you"})
+		if !canModify(c, result.CreatedBy, result.TopicID, "You can only edit comments created by you") {
 			return
 		}
 
-		data := map[string]interface{}{
-			"content":    input.Content,
-			"updated_at": time.Now(),
+		if !requireOpenTopic(c, topics, result.TopicID) {
+			return
 		}
-		_, _, err = client.From("comments").Update(data, "", "").Eq("id", id).Execute()
+
+		err = comments.UpdateComment(c.Request.Context(), id, input.Content, userID)
 
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to edit comment"})
@@ -282,21 +210,22 @@ func UpdateComment(client *supabase.Client) gin.HandlerFunc {
 	}
 }
 
-func DeleteComment(client *supabase.Client) gin.HandlerFunc {
+func DeleteComment(comments store.CommentStore, topics store.TopicStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		id := c.Param("id")
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid comment id"})
+			return
+		}
 
 		user, _ := c.Get("user")
-		currentUser := user.(User)
+		currentUser := user.(store.User)
 		userID := currentUser.ID
 
-		var result struct {
-			CreatedBy int `json:"created_by"`
-		}
-		_, err := client.From("comments").Select("created_by", "", false).Eq("id", id).Single().ExecuteTo(&result)
+		result, err := comments.GetComment(c.Request.Context(), id, userID)
 
 		if err != nil {
-			if strings.Contains(err.Error(), "PGRST116") || strings.Contains(err.Error(), "0 rows") {
+			if errors.Is(err, store.ErrNotFound) {
 				c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
 				return
 			}
@@ -305,12 +234,15 @@ func DeleteComment(client *supabase.Client) gin.HandlerFunc {
 			return
 		}
 
-		if result.CreatedBy != userID {
-			c.JSON(http.StatusForbidden, gin.H{"error": "You can only delete comments created by you"})
+		if !canModify(c, result.CreatedBy, result.TopicID, "You can only delete comments created by you") {
+			return
+		}
+
+		if !requireOpenTopic(c, topics, result.TopicID) {
 			return
 		}
 
-		_, _, err = client.From("comments").Delete("", "").Eq("id", id).Execute()
+		err = comments.DeleteComment(c.Request.Context(), id)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete comment"})
 			return
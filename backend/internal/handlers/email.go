@@ -0,0 +1,340 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+	"web-forum/internal/auth"
+	"web-forum/internal/lockout"
+	"web-forum/internal/mailer"
+	"web-forum/internal/passwords"
+	"web-forum/internal/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// verifyEmailTTL is how long a link to verify an email address works
+	verifyEmailTTL = 24 * time.Hour
+	// resetPasswordTTL is how long a link to reset a password works
+	resetPasswordTTL = time.Hour
+	// mailTimeout bounds sending an email after the request has been answered
+	mailTimeout = 30 * time.Second
+)
+
+// appURL is where the frontend is served. Emailed links point there.
+func appURL() string {
+	u := os.Getenv("APP_URL")
+	if u == "" {
+		u = "http://localhost:5173"
+	}
+	return strings.TrimSuffix(u, "/")
+}
+
+// normaliseEmail trims and lowercases an address and checks it is a bare
+// address, without a display name. An empty address is valid and means none.
+func normaliseEmail(email string) (string, bool) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" {
+		return "", true
+	}
+
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email || len(email) > 254 {
+		return "", false
+	}
+	return email, true
+}
+
+// sendEmailLink emails user a single-use link to path on the frontend for
+// purpose. Earlier links for the same purpose stop working.
+func sendEmailLink(ctx context.Context, tokens store.EmailTokenStore, m mailer.Mailer, user store.User, purpose store.EmailPurpose) error {
+	token, hash, err := auth.NewEmailToken()
+	if err != nil {
+		return err
+	}
+
+	ttl, path := verifyEmailTTL, "/verify-email"
+	if purpose == store.EmailReset {
+		ttl, path = resetPasswordTTL, "/reset-password"
+	}
+
+	err = tokens.CreateEmailToken(ctx, hash, store.EmailToken{
+		UserID:    user.ID,
+		Purpose:   purpose,
+		Email:     user.Email,
+		ExpiresAt: time.Now().Add(ttl),
+	})
+	if err != nil {
+		return err
+	}
+
+	link := appURL() + path + "?token=" + url.QueryEscape(token)
+
+	msg := mailer.Message{To: user.Email}
+	if purpose == store.EmailReset {
+		msg.Subject = "Reset your Web Forum password"
+		msg.Body = fmt.Sprintf("Hi %s,\n\n"+
+			"Someone asked to reset your Web Forum password. To choose a new one, open this link within an hour:\n\n"+
+			"%s\n\n"+
+			"If it was not you, you can ignore this email. Your password has not changed.\n",
+			user.Username, link)
+	} else {
+		msg.Subject = "Verify your email address for Web Forum"
+		msg.Body = fmt.Sprintf("Hi %s,\n\n"+
+			"Confirm this is your email address by opening this link within 24 hours:\n\n"+
+			"%s\n\n"+
+			"If you did not add it to a Web Forum account, you can ignore this email.\n",
+			user.Username, link)
+	}
+
+	return m.Send(ctx, msg)
+}
+
+// ChangeEmail sets or, given an empty email, removes the user's email address
+// and sends a link to verify the new one. It takes the password, or the
+// username of users without one, since the address is how the account is
+// recovered.
+func ChangeEmail(users store.UserStore, tokens store.EmailTokenStore, m mailer.Mailer, guard *lockout.Guard, audits store.AuditStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, _ := c.Get("user")
+		currentUser := user.(store.User)
+
+		var input struct {
+			Email    string `json:"email"`
+			Password string `json:"password"`
+			Username string `json:"username"`
+		}
+
+		if err := c.BindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+
+		email, ok := normaliseEmail(input.Email)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email address"})
+			return
+		}
+
+		if !confirmPassword(c, users, guard, audits, currentUser, input.Password, input.Username) {
+			return
+		}
+
+		if email == currentUser.Email {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "That is already your email address"})
+			return
+		}
+
+		err := users.SetEmail(c.Request.Context(), currentUser.ID, email)
+		if err != nil {
+			if errors.Is(err, store.ErrConflict) {
+				c.JSON(http.StatusConflict, gin.H{"error": "Email address is already in use"})
+				return
+			}
+
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change email address"})
+			return
+		}
+
+		if email == "" {
+			c.JSON(http.StatusOK, gin.H{"message": "Email address removed"})
+			return
+		}
+
+		currentUser.Email = email
+		if err := sendEmailLink(c.Request.Context(), tokens, m, currentUser, store.EmailVerify); err != nil {
+			log.Printf("Error sending verification email: %v", err)
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Email address changed, but the verification email could not be sent. Try resending it"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Email address changed. Check your inbox to verify it"})
+	}
+}
+
+// ResendVerification sends a new link to verify the user's email address
+func ResendVerification(tokens store.EmailTokenStore, m mailer.Mailer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, _ := c.Get("user")
+		currentUser := user.(store.User)
+
+		if currentUser.Email == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Add an email address first"})
+			return
+		}
+		if currentUser.EmailVerified() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Email address is already verified"})
+			return
+		}
+
+		if err := sendEmailLink(c.Request.Context(), tokens, m, currentUser, store.EmailVerify); err != nil {
+			log.Printf("Error sending verification email: %v", err)
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to send verification email"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Verification email sent"})
+	}
+}
+
+// VerifyEmail spends a link sent by ChangeEmail. It needs no login, the token
+// is proof enough, so the link works in whichever browser opens it.
+func VerifyEmail(users store.UserStore, tokens store.EmailTokenStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input struct {
+			Token string `json:"token" binding:"required"`
+		}
+
+		if err := c.BindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+
+		token, err := tokens.UseEmailToken(c.Request.Context(), auth.HashToken(input.Token), store.EmailVerify)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired link"})
+				return
+			}
+
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email address"})
+			return
+		}
+
+		err = users.MarkEmailVerified(c.Request.Context(), token.UserID, token.Email)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "This link is for an email address the account no longer uses"})
+				return
+			}
+
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email address"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Email address verified"})
+	}
+}
+
+// ForgotPassword emails a password reset link to a verified address. It
+// answers the same whether or not the address belongs to anyone, and sends
+// after answering, so neither the response nor its timing tells.
+func ForgotPassword(users store.UserStore, tokens store.EmailTokenStore, m mailer.Mailer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input struct {
+			Email string `json:"email" binding:"required"`
+		}
+
+		if err := c.BindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+
+		email, ok := normaliseEmail(input.Email)
+		if !ok || email == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email address"})
+			return
+		}
+
+		user, err := users.GetUserByEmail(c.Request.Context(), email)
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+
+		// Unverified addresses could be anyone's, so they cannot take over the account
+		if err == nil && user.EmailVerified() {
+			ctx, cancel := context.WithTimeout(context.WithoutCancel(c.Request.Context()), mailTimeout)
+			go func() {
+				defer cancel()
+				if err := sendEmailLink(ctx, tokens, m, user, store.EmailReset); err != nil {
+					log.Printf("Error sending password reset email: %v", err)
+				}
+			}()
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "If that email address belongs to an account, a link to reset its password is on its way"})
+	}
+}
+
+// ResetForgottenPassword sets a new password, following rules, with a link
+// sent by ForgotPassword and logs every session out
+func ResetForgottenPassword(users store.UserStore, sessions store.SessionStore, tokens store.EmailTokenStore, rules *passwords.Policy, guard *lockout.Guard, audits store.AuditStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input struct {
+			Token       string `json:"token" binding:"required"`
+			NewPassword string `json:"new_password" binding:"required"`
+		}
+
+		if err := c.BindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+
+		// Check what can be checked before spending the link, so a rejected
+		// password can be retried with it
+		if !checkPassword(c, rules, input.NewPassword, "") {
+			return
+		}
+
+		token, err := tokens.UseEmailToken(c.Request.Context(), auth.HashToken(input.Token), store.EmailReset)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired link"})
+				return
+			}
+
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+			return
+		}
+
+		user, err := users.GetUserByID(c.Request.Context(), token.UserID)
+		// A link sent to an address the account has since dropped is no good
+		if errors.Is(err, store.ErrNotFound) || err == nil && user.Email != token.Email {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired link"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+
+		// Only the username rule is left to check. The link is spent by now,
+		// but a password with the username in it is rare enough to ask for a
+		// new link.
+		if !checkPassword(c, rules, input.NewPassword, user.Username) {
+			return
+		}
+
+		hashedPassword, err := hashPassword(input.NewPassword)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process password"})
+			return
+		}
+
+		if err := users.UpdatePassword(c.Request.Context(), user.ID, hashedPassword); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+			return
+		}
+
+		// Whoever knew the old password is logged out, and the owner may log in at once
+		if err := sessions.RevokeUserSessions(c.Request.Context(), user.ID, 0); err != nil {
+			log.Printf("Error revoking sessions after password reset: %v", err)
+		}
+		if err := guard.Succeed(c.Request.Context(), user.Username); err != nil {
+			log.Printf("Error clearing failed login attempts: %v", err)
+		}
+
+		audit(c, audits, store.AuditEntry{Action: store.AuditPasswordReset, UserID: &user.ID})
+
+		c.JSON(http.StatusOK, gin.H{"message": "Password reset. Log in with your new password"})
+	}
+}
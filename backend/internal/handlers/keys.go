@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"net/http"
+	"web-forum/internal/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JWKS publishes the public keys access tokens are signed with, so other
+// services can verify them. HS256 secrets are never included.
+func JWKS(c *gin.Context) {
+	keys, err := auth.Keys()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load keys"})
+		return
+	}
+
+	// Verifiers may cache the keys for a while, rotation keeps the old key around anyway
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, gin.H{"keys": keys.PublicKeys()})
+}
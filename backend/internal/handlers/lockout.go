@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+	"web-forum/internal/lockout"
+	"web-forum/internal/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// allowAttempt reserves an attempt at a password for username from this
+// address, which counts as failed until releaseAttempt takes it back. While
+// failed attempts make it wait, it responds with 429 and Retry-After and
+// returns false.
+func allowAttempt(c *gin.Context, guard *lockout.Guard, username string) bool {
+	wait, err := guard.Attempt(c.Request.Context(), username, c.ClientIP())
+	if err != nil {
+		log.Printf("Error checking failed login attempts: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Authentication error"})
+		return false
+	}
+
+	if wait > 0 {
+		seconds := int(math.Ceil(wait.Seconds()))
+		c.Header("Retry-After", strconv.Itoa(seconds))
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error":       "Too many failed attempts. Try again later",
+			"retry_after": seconds,
+		})
+		return false
+	}
+	return true
+}
+
+// releaseAttempt takes back the attempt allowAttempt reserved for username,
+// once it turned out right or could not be checked
+func releaseAttempt(c *gin.Context, guard *lockout.Guard, username string) {
+	if err := guard.Release(c.Request.Context(), username, c.ClientIP()); err != nil {
+		log.Printf("Error releasing login attempt: %v", err)
+	}
+}
+
+// recordFailure counts a wrong password for username, which belongs to the
+// user with userID or to nobody if it is 0, and audits any lockout it triggers
+func recordFailure(c *gin.Context, guard *lockout.Guard, audits store.AuditStore, username string, userID int) {
+	lockouts, err := guard.Fail(c.Request.Context(), username, c.ClientIP())
+	if err != nil {
+		log.Printf("Error recording failed login attempt: %v", err)
+	}
+
+	for _, l := range lockouts {
+		entry := store.AuditEntry{
+			Action:  store.AuditLoginLockout,
+			Subject: l.Key,
+			Detail:  "Locked out until " + l.Until.UTC().Format(time.RFC3339) + " after repeated failed logins",
+		}
+		if userID != 0 && l.Key == lockout.UsernameKey(username) {
+			entry.UserID = &userID
+		}
+
+		log.Printf("Locked out %s until %s", l.Key, l.Until.UTC().Format(time.RFC3339))
+		audit(c, audits, entry)
+	}
+}
@@ -0,0 +1,17 @@
+package handlers
+
+import (
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	os.Setenv("JWT_SECRET", "test-secret")
+	os.Unsetenv("JWT_KEYS")
+	os.Unsetenv("APP_URL")
+
+	os.Exit(m.Run())
+}
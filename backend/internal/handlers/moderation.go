@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"web-forum/internal/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetUserRole changes a user's role. Admins only, and not their own role so
+// the last admin cannot lock everyone out by accident.
+func SetUserRole(users store.UserStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireAdmin(c) {
+			return
+		}
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user id"})
+			return
+		}
+
+		var input struct {
+			Role string `json:"role" binding:"required"`
+		}
+
+		if err := c.BindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+
+		role, err := store.ParseRole(input.Role)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "role must be one of member, moderator or admin"})
+			return
+		}
+
+		if id == permissionsOf(c).UserID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot change your own role"})
+			return
+		}
+
+		err = users.SetRole(c.Request.Context(), id, role)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+				return
+			}
+
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change role"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Role changed successfully"})
+	}
+}
+
+func GetModerators(moderators store.ModeratorStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		topicID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid topic id"})
+			return
+		}
+
+		users, err := moderators.ListModerators(c.Request.Context(), topicID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve moderators"})
+			return
+		}
+
+		c.JSON(http.StatusOK, users)
+	}
+}
+
+// AddModerator assigns a user with the moderator role to a topic. Admins only.
+func AddModerator(users store.UserStore, moderators store.ModeratorStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireAdmin(c) {
+			return
+		}
+
+		topicID, userID, ok := moderatorParams(c)
+		if !ok {
+			return
+		}
+
+		user, err := users.GetUserByID(c.Request.Context(), userID)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+				return
+			}
+
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add moderator"})
+			return
+		}
+
+		if user.Role != store.RoleModerator {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "User must have the moderator role"})
+			return
+		}
+
+		err = moderators.AddModerator(c.Request.Context(), topicID, userID)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Topic not found"})
+				return
+			}
+
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add moderator"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Moderator added successfully"})
+	}
+}
+
+// RemoveModerator unassigns a moderator from a topic. Admins only.
+func RemoveModerator(moderators store.ModeratorStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireAdmin(c) {
+			return
+		}
+
+		topicID, userID, ok := moderatorParams(c)
+		if !ok {
+			return
+		}
+
+		err := moderators.RemoveModerator(c.Request.Context(), topicID, userID)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "User does not moderate this topic"})
+				return
+			}
+
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove moderator"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Moderator removed successfully"})
+	}
+}
+
+// moderatorParams reads the topic and user ids of a moderator route. On bad
+// input it responds with 400 and returns false.
+func moderatorParams(c *gin.Context) (topicID, userID int, ok bool) {
+	topicID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid topic id"})
+		return 0, 0, false
+	}
+
+	userID, err = strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user id"})
+		return 0, 0, false
+	}
+
+	return topicID, userID, true
+}
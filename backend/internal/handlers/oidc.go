@@ -0,0 +1,290 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+	"web-forum/internal/auth"
+	"web-forum/internal/oidc"
+	"web-forum/internal/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// oidcFlowCookie holds the state, nonce and PKCE verifier of a single
+	// sign-on between leaving for the provider and coming back
+	oidcFlowCookie = "OIDCFlow"
+	oidcFlowPath   = "/api/users/oidc"
+	// oidcFlowTTL is how long the user has to sign in at the provider
+	oidcFlowTTL = 10 * time.Minute
+
+	// challengeCookie carries the two-factor challenge of a single sign-on to
+	// the second login step, which is the only place it is sent. A redirect
+	// query would leave it in browser history, logs and Referer headers.
+	challengeCookie     = "TwoFactorChallenge"
+	challengeCookiePath = "/api/users/login/2fa"
+)
+
+// GetSingleSignOn tells the login page whether to offer single sign-on
+func GetSingleSignOn(provider *oidc.Provider) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if provider == nil {
+			c.JSON(http.StatusOK, gin.H{"enabled": false})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"enabled": true, "name": provider.Name()})
+	}
+}
+
+// StartSingleSignOn sends the browser to the provider to sign in. It is
+// navigated to, not fetched, so errors go back to the login page.
+func StartSingleSignOn(provider *oidc.Provider) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if provider == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Single sign-on is not configured"})
+			return
+		}
+
+		flow, err := oidc.NewFlow()
+		if err != nil {
+			ssoFailed(c, "Authentication error")
+			return
+		}
+
+		authURL, err := provider.AuthCodeURL(c.Request.Context(), flow)
+		if err != nil {
+			log.Printf("Error starting single sign-on: %v", err)
+			ssoFailed(c, "Could not reach the identity provider. Try again later")
+			return
+		}
+
+		setCookie(c, oidcFlowCookie, flow.String(), oidcFlowPath, int(oidcFlowTTL.Seconds()))
+		c.Redirect(http.StatusFound, authURL)
+	}
+}
+
+// FinishSingleSignOn is where the provider sends the browser back. It checks
+// the sign-in, finds or creates the user and starts their session the way
+// Login does, then sends the browser on to the frontend.
+func FinishSingleSignOn(provider *oidc.Provider, users store.UserStore, sessions store.SessionStore, twoFactors store.TwoFactorStore, identities store.IdentityStore, audits store.AuditStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if provider == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Single sign-on is not configured"})
+			return
+		}
+
+		// The flow is single-use whatever happens next
+		cookie, _ := c.Cookie(oidcFlowCookie)
+		setCookie(c, oidcFlowCookie, "", oidcFlowPath, -1)
+
+		flow, err := oidc.ParseFlow(cookie)
+		if err != nil || !flow.CheckState(c.Query("state")) {
+			ssoFailed(c, "Sign-in expired. Please try again")
+			return
+		}
+		if c.Query("error") != "" {
+			ssoFailed(c, "The identity provider did not sign you in")
+			return
+		}
+		if c.Query("code") == "" {
+			ssoFailed(c, "Sign-in expired. Please try again")
+			return
+		}
+
+		claims, err := provider.Exchange(c.Request.Context(), c.Query("code"), flow)
+		if err != nil {
+			log.Printf("Error finishing single sign-on: %v", err)
+			ssoFailed(c, "Could not verify the sign-in with the identity provider")
+			return
+		}
+
+		user, err := ssoUser(c, users, identities, audits, claims)
+		if errors.Is(err, errAccountDeleted) {
+			ssoFailed(c, "This account was deleted")
+			return
+		}
+		if err != nil {
+			log.Printf("Error finding user for single sign-on: %v", err)
+			ssoFailed(c, "Failed to sign in")
+			return
+		}
+
+		// The provider vouches for the password, not for the forum's second factor
+		twoFactor, err := twoFactors.GetTOTP(c.Request.Context(), user.ID)
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			ssoFailed(c, "Failed to sign in")
+			return
+		}
+		if twoFactor.Enabled() {
+			challengeToken, err := auth.IssueChallengeToken(user.ID)
+			if err != nil {
+				ssoFailed(c, "Authentication error")
+				return
+			}
+
+			setCookie(c, challengeCookie, challengeToken, challengeCookiePath, int(auth.ChallengeTTL.Seconds()))
+			c.Redirect(http.StatusFound, appURL()+"/login?two_factor=1")
+			return
+		}
+
+		if !startSession(c, sessions, user) {
+			return
+		}
+
+		// The login page finds the session and carries on to the forum
+		c.Redirect(http.StatusFound, appURL()+"/login")
+	}
+}
+
+// ssoFailed sends the browser back to the login page with an error to show
+func ssoFailed(c *gin.Context, message string) {
+	c.Redirect(http.StatusFound, appURL()+"/login?sso_error="+url.QueryEscape(message))
+}
+
+// errAccountDeleted is returned for a provider account linked to a deleted
+// user, which must not sign in again
+var errAccountDeleted = errors.New("account deleted")
+
+// ssoUser finds the user a provider account is linked to. An account seen for
+// the first time is linked to the user with the same verified email address,
+// if both sides verified it, and to a new user otherwise.
+func ssoUser(c *gin.Context, users store.UserStore, identities store.IdentityStore, audits store.AuditStore, claims oidc.Claims) (store.User, error) {
+	ctx := c.Request.Context()
+
+	identity, err := identities.GetIdentity(ctx, claims.Issuer, claims.Subject)
+	if err == nil {
+		return linkedUser(ctx, users, identity)
+	}
+	if !errors.Is(err, store.ErrNotFound) {
+		return store.User{}, err
+	}
+
+	email, ok := normaliseEmail(claims.Email)
+	if !ok || !claims.EmailVerified {
+		email = ""
+	}
+
+	if email != "" {
+		user, err := users.GetUserByEmail(ctx, email)
+		if err == nil && user.EmailVerified() {
+			err = identities.LinkIdentity(ctx, store.Identity{Issuer: claims.Issuer, Subject: claims.Subject, UserID: user.ID})
+			if err != nil {
+				return store.User{}, err
+			}
+
+			audit(c, audits, store.AuditEntry{
+				Action:  store.AuditIdentityLinked,
+				UserID:  &user.ID,
+				Subject: claims.Issuer,
+				Detail:  "Linked by verified email address",
+			})
+			return user, nil
+		}
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			return store.User{}, err
+		}
+	}
+
+	user, err := createSSOUser(ctx, users, claims)
+	if err != nil {
+		return store.User{}, err
+	}
+
+	err = identities.LinkIdentity(ctx, store.Identity{Issuer: claims.Issuer, Subject: claims.Subject, UserID: user.ID})
+	if errors.Is(err, store.ErrConflict) {
+		// Another callback for the same account won the race. The user made
+		// here stays behind, unused, which is harmless.
+		identity, err := identities.GetIdentity(ctx, claims.Issuer, claims.Subject)
+		if err != nil {
+			return store.User{}, err
+		}
+		return linkedUser(ctx, users, identity)
+	}
+	if err != nil {
+		return store.User{}, err
+	}
+
+	// The address is only a convenience, so a clash with an unverified one
+	// another user typed in is not worth failing the sign-in over
+	if email != "" {
+		err = users.SetEmail(ctx, user.ID, email)
+		if err == nil {
+			err = users.MarkEmailVerified(ctx, user.ID, email)
+		}
+		if err != nil && !errors.Is(err, store.ErrConflict) {
+			log.Printf("Error saving email address from single sign-on: %v", err)
+		}
+	}
+
+	return users.GetUserByID(ctx, user.ID)
+}
+
+// linkedUser returns the user identity is linked to. Deleting a user unlinks
+// their identities, but a sign-in racing the deletion can still find one.
+func linkedUser(ctx context.Context, users store.UserStore, identity store.Identity) (store.User, error) {
+	user, err := users.GetUserByID(ctx, identity.UserID)
+	if err != nil {
+		return store.User{}, err
+	}
+	if user.Deleted() {
+		return store.User{}, errAccountDeleted
+	}
+	return user, nil
+}
+
+// createSSOUser creates a user named after the provider account, adding a
+// number if the name is taken. It has no password, and an empty hash matches
+// none, so it signs in through the provider until it sets one through a
+// password reset.
+func createSSOUser(ctx context.Context, users store.UserStore, claims oidc.Claims) (store.User, error) {
+	base := ssoUsername(claims)
+
+	for i := 1; i <= 20; i++ {
+		username := base
+		if i > 1 {
+			username = base + "-" + strconv.Itoa(i)
+		}
+
+		user, err := users.CreateUser(ctx, username, "")
+		if !errors.Is(err, store.ErrConflict) {
+			return user, err
+		}
+	}
+
+	// Twenty namesakes is unusual enough that a random suffix is fine
+	b := make([]byte, 3)
+	if _, err := rand.Read(b); err != nil {
+		return store.User{}, err
+	}
+	return users.CreateUser(ctx, base+"-"+hex.EncodeToString(b), "")
+}
+
+// ssoUsername picks a username from what the provider says about the user,
+// fitting the 3 to 50 characters sign up allows with room for a suffix, and
+// passing over names reserved for deleted accounts as sign up does
+func ssoUsername(claims oidc.Claims) string {
+	local, _, _ := strings.Cut(claims.Email, "@")
+
+	for _, candidate := range []string{claims.PreferredUsername, local, claims.Name} {
+		candidate = strings.Join(strings.Fields(candidate), " ")
+		if utf8.RuneCountInString(candidate) < 3 || reservedUsername(candidate) {
+			continue
+		}
+
+		if runes := []rune(candidate); len(runes) > 40 {
+			candidate = string(runes[:40])
+		}
+		return candidate
+	}
+	return "user"
+}
@@ -0,0 +1,390 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+	"web-forum/internal/auth"
+	"web-forum/internal/lockout"
+	"web-forum/internal/oidc"
+	"web-forum/internal/oidc/mockoidc"
+	"web-forum/internal/store"
+	"web-forum/internal/store/memory"
+	"web-forum/internal/totp"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ssoTest is a forum with single sign-on through a mock provider
+type ssoTest struct {
+	t      *testing.T
+	mock   *mockoidc.Provider
+	url    string
+	client *http.Client
+	db     *memory.Store
+	router *gin.Engine
+}
+
+// newSSOTest starts a mock provider whose issuer is its URL followed by
+// issuerSuffix, and a forum signing in with it
+func newSSOTest(t *testing.T, issuerSuffix string) *ssoTest {
+	t.Helper()
+
+	mock, err := mockoidc.New("", "forum", "", auth.AlgEdDSA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(mock)
+	t.Cleanup(server.Close)
+	mock.Issuer = server.URL + issuerSuffix
+
+	client := server.Client()
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	provider := oidc.New(oidc.Config{
+		Issuer:      mock.Issuer,
+		ClientID:    "forum",
+		RedirectURL: "http://forum.test/api/users/oidc/callback",
+	}, client)
+
+	db := memory.New()
+	guard := lockout.New(lockout.NewMemoryStore())
+
+	router := gin.New()
+	router.GET("/api/users/oidc/login", StartSingleSignOn(provider))
+	router.GET("/api/users/oidc/callback", FinishSingleSignOn(provider, db, db, db, db, db))
+	router.POST("/api/users/login/2fa", VerifyTwoFactor(db, db, db, guard, db))
+
+	return &ssoTest{t: t, mock: mock, url: server.URL, client: client, db: db, router: router}
+}
+
+func (s *ssoTest) serve(req *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	return rec
+}
+
+// account is who the mock provider is told to sign in
+type account struct {
+	subject, username, email string
+	emailVerified            bool
+}
+
+// start begins a sign-in at the forum and returns its flow cookie and the
+// authorization request it sends the browser to
+func (s *ssoTest) start() (*http.Cookie, url.Values) {
+	s.t.Helper()
+
+	rec := s.serve(httptest.NewRequest(http.MethodGet, "/api/users/oidc/login", nil))
+	if rec.Code != http.StatusFound {
+		s.t.Fatalf("start: status %d: %s", rec.Code, rec.Body)
+	}
+	authURL, err := url.Parse(rec.Header().Get("Location"))
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	flow := findCookie(rec, oidcFlowCookie)
+	if flow == nil {
+		s.t.Fatalf("start set no %s cookie", oidcFlowCookie)
+	}
+	return flow, authURL.Query()
+}
+
+// approve signs a in at the mock provider and returns the callback query it
+// sends the browser back with
+func (s *ssoTest) approve(authQuery url.Values, a account) url.Values {
+	s.t.Helper()
+
+	query := url.Values{}
+	for name, values := range authQuery {
+		query[name] = values
+	}
+	query.Set("sub", a.subject)
+	query.Set("preferred_username", a.username)
+	query.Set("email", a.email)
+	if a.emailVerified {
+		query.Set("email_verified", "true")
+	}
+
+	resp, err := s.client.Get(s.url + "/authorize/approve?" + query.Encode())
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		s.t.Fatalf("approve: status %d", resp.StatusCode)
+	}
+
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	return callback.Query()
+}
+
+// callback finishes a sign-in at the forum
+func (s *ssoTest) callback(flow *http.Cookie, query url.Values) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/api/users/oidc/callback?"+query.Encode(), nil)
+	req.AddCookie(flow)
+	return s.serve(req)
+}
+
+// signIn goes through the whole flow as a
+func (s *ssoTest) signIn(a account) *httptest.ResponseRecorder {
+	s.t.Helper()
+
+	flow, authQuery := s.start()
+	return s.callback(flow, s.approve(authQuery, a))
+}
+
+// signedInAs checks a callback response started a session and returns whose
+func (s *ssoTest) signedInAs(rec *httptest.ResponseRecorder) store.User {
+	s.t.Helper()
+
+	if location := rec.Header().Get("Location"); rec.Code != http.StatusFound || location != appURL()+"/login" {
+		s.t.Fatalf("callback: status %d to %q, want a redirect to the login page", rec.Code, location)
+	}
+
+	access := findCookie(rec, accessCookie)
+	if access == nil || access.Value == "" {
+		s.t.Fatalf("callback set no %s cookie", accessCookie)
+	}
+	claims, err := auth.ParseAccessToken(access.Value)
+	if err != nil {
+		s.t.Fatalf("ParseAccessToken: %v", err)
+	}
+
+	user, err := s.db.GetUserByID(context.Background(), claims.UserID)
+	if err != nil {
+		s.t.Fatalf("GetUserByID: %v", err)
+	}
+	return user
+}
+
+// failed checks a callback response sent the browser back with an error and
+// started no session
+func failed(t *testing.T, rec *httptest.ResponseRecorder) {
+	t.Helper()
+
+	location, err := url.Parse(rec.Header().Get("Location"))
+	if rec.Code != http.StatusFound || err != nil || location.Query().Get("sso_error") == "" {
+		t.Fatalf("callback: status %d to %q, want a redirect with sso_error", rec.Code, rec.Header().Get("Location"))
+	}
+	if access := findCookie(rec, accessCookie); access != nil && access.Value != "" {
+		t.Errorf("callback set a session cookie")
+	}
+}
+
+func findCookie(rec *httptest.ResponseRecorder, name string) *http.Cookie {
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.Name == name {
+			return cookie
+		}
+	}
+	return nil
+}
+
+var alice = account{subject: "alice-sub", username: "alice", email: "alice@example.com", emailVerified: true}
+
+func TestSingleSignOnNewUser(t *testing.T) {
+	// Providers differ on whether their issuer ends in a slash, and ID tokens
+	// have to name it exactly as discovery does
+	for _, suffix := range []string{"", "/"} {
+		t.Run("issuer "+suffix, func(t *testing.T) {
+			s := newSSOTest(t, suffix)
+
+			user := s.signedInAs(s.signIn(alice))
+			if user.Username != "alice" || user.Password != "" {
+				t.Errorf("signed in as %q with password %q, want a new alice without one", user.Username, user.Password)
+			}
+			if user.Email != alice.email || !user.EmailVerified() {
+				t.Errorf("email %q, verified %v, want the provider's verified address", user.Email, user.EmailVerified())
+			}
+
+			// The provider account is linked now, so signing in again finds the same user
+			if again := s.signedInAs(s.signIn(alice)); again.ID != user.ID {
+				t.Errorf("second sign-in as user %d, want %d", again.ID, user.ID)
+			}
+		})
+	}
+}
+
+func TestSingleSignOnLinksVerifiedEmail(t *testing.T) {
+	s := newSSOTest(t, "")
+	ctx := context.Background()
+
+	bob, err := s.db.CreateUser(ctx, "bob", "hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.db.SetEmail(ctx, bob.ID, "bob@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.db.MarkEmailVerified(ctx, bob.ID, "bob@example.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	// An address the provider did not verify proves nothing
+	unverified := account{subject: "bob-1", username: "bobby", email: "bob@example.com"}
+	if user := s.signedInAs(s.signIn(unverified)); user.ID == bob.ID {
+		t.Errorf("unverified email linked to bob")
+	}
+
+	verified := account{subject: "bob-2", username: "bobby", email: "bob@example.com", emailVerified: true}
+	if user := s.signedInAs(s.signIn(verified)); user.ID != bob.ID {
+		t.Errorf("verified email signed in as %q, want bob", user.Username)
+	}
+	if identity, err := s.db.GetIdentity(ctx, s.mock.Issuer, "bob-2"); err != nil || identity.UserID != bob.ID {
+		t.Errorf("GetIdentity = %+v, %v, want one linked to bob", identity, err)
+	}
+}
+
+func TestSingleSignOnReservedUsername(t *testing.T) {
+	s := newSSOTest(t, "")
+
+	a := account{subject: "sneaky", username: store.DeletedUsername(7), email: "sneaky@example.com"}
+	user := s.signedInAs(s.signIn(a))
+	if user.Username != "sneaky" {
+		t.Errorf("signed in as %q, want the name from the email address", user.Username)
+	}
+}
+
+func TestSingleSignOnDeletedUser(t *testing.T) {
+	s := newSSOTest(t, "")
+	ctx := context.Background()
+
+	user := s.signedInAs(s.signIn(alice))
+	if err := s.db.DeleteUser(ctx, user.ID, store.DeleteAnonymise); err != nil {
+		t.Fatal(err)
+	}
+
+	// Deleting unlinks the identity, but one linked again, as a sign-in that
+	// raced the deletion could, must not sign in either
+	err := s.db.LinkIdentity(ctx, store.Identity{Issuer: s.mock.Issuer, Subject: alice.subject, UserID: user.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	failed(t, s.signIn(alice))
+}
+
+func TestSingleSignOnRejectsTampering(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(flow *http.Cookie, callback url.Values)
+	}{
+		{"state", func(flow *http.Cookie, callback url.Values) {
+			callback.Set("state", "forged")
+		}},
+		{"PKCE verifier", func(flow *http.Cookie, callback url.Values) {
+			f, _ := oidc.ParseFlow(flow.Value)
+			f.Verifier = strings.Repeat("v", 43)
+			flow.Value = f.String()
+		}},
+		{"nonce", func(flow *http.Cookie, callback url.Values) {
+			f, _ := oidc.ParseFlow(flow.Value)
+			f.Nonce = "forged"
+			flow.Value = f.String()
+		}},
+		{"missing code", func(flow *http.Cookie, callback url.Values) {
+			callback.Del("code")
+		}},
+		{"no flow cookie", func(flow *http.Cookie, callback url.Values) {
+			flow.Value = ""
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newSSOTest(t, "")
+
+			flow, authQuery := s.start()
+			callback := s.approve(authQuery, alice)
+			tt.tamper(flow, callback)
+
+			failed(t, s.callback(flow, callback))
+		})
+	}
+}
+
+func TestSingleSignOnCodeIsSingleUse(t *testing.T) {
+	s := newSSOTest(t, "")
+
+	flow, authQuery := s.start()
+	callback := s.approve(authQuery, alice)
+	s.signedInAs(s.callback(flow, callback))
+
+	// The flow cookie was cleared, but even with it the code is spent
+	failed(t, s.callback(flow, callback))
+}
+
+func TestSingleSignOnWrongIssuer(t *testing.T) {
+	s := newSSOTest(t, "")
+
+	// Discovery happens on start, so the ID token issued after it names
+	// another issuer than discovery did
+	flow, authQuery := s.start()
+	callback := s.approve(authQuery, alice)
+	s.mock.Issuer = "https://attacker.example"
+
+	failed(t, s.callback(flow, callback))
+}
+
+func TestSingleSignOnTwoFactor(t *testing.T) {
+	s := newSSOTest(t, "")
+	ctx := context.Background()
+
+	user := s.signedInAs(s.signIn(alice))
+
+	secret, err := totp.NewSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.db.StartTOTP(ctx, user.ID, secret); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.db.EnableTOTP(ctx, user.ID, 0, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// The provider's sign-in is only the first step now
+	rec := s.signIn(alice)
+	if location := rec.Header().Get("Location"); rec.Code != http.StatusFound || location != appURL()+"/login?two_factor=1" {
+		t.Fatalf("callback: status %d to %q, want the code prompt", rec.Code, location)
+	}
+	if strings.Contains(rec.Header().Get("Location"), "challenge") {
+		t.Errorf("challenge token in the redirect URL")
+	}
+	if access := findCookie(rec, accessCookie); access != nil && access.Value != "" {
+		t.Errorf("session started before the second factor")
+	}
+	challenge := findCookie(rec, challengeCookie)
+	if challenge == nil || challenge.Value == "" || challenge.Path != challengeCookiePath || !challenge.HttpOnly {
+		t.Fatalf("challenge cookie = %+v, want an HttpOnly one for %s", challenge, challengeCookiePath)
+	}
+
+	code, err := totp.Code(secret, totp.Step(time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/api/users/login/2fa", strings.NewReader(`{"code":"`+code+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.AddCookie(challenge)
+
+	rec = s.serve(req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("login/2fa: status %d: %s", rec.Code, rec.Body)
+	}
+	if access := findCookie(rec, accessCookie); access == nil || access.Value == "" {
+		t.Errorf("login/2fa started no session")
+	}
+	if cleared := findCookie(rec, challengeCookie); cleared == nil || cleared.MaxAge >= 0 {
+		t.Errorf("login/2fa left the challenge cookie")
+	}
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"web-forum/internal/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// listOptions reads the sort, limit and cursor query parameters. On bad input
+// it responds with 400 and returns false.
+func listOptions(c *gin.Context) (store.ListOptions, bool) {
+	opts := store.ListOptions{}
+	var ok bool
+
+	sort, err := store.ParseSort(c.Query("sort"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sort must be one of newest, oldest, likes, popular or hot"})
+		return opts, false
+	}
+	opts.Sort = sort
+
+	if opts.Limit, ok = pageLimit(c); !ok {
+		return opts, false
+	}
+	if opts.After, ok = pageCursor(c, opts.Sort); !ok {
+		return opts, false
+	}
+
+	return opts, true
+}
+
+// pageLimit reads the limit query parameter, responding with 400 and returning
+// false when it is out of range
+func pageLimit(c *gin.Context) (int, bool) {
+	limitStr := c.Query("limit")
+	if limitStr == "" {
+		return store.DefaultPageSize, true
+	}
+
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit < 1 || limit > store.MaxPageSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and " + strconv.Itoa(store.MaxPageSize)})
+		return 0, false
+	}
+	return limit, true
+}
+
+// pageCursor reads the cursor query parameter, which must have been handed
+// out for the given order. It responds with 400 and returns false otherwise.
+func pageCursor(c *gin.Context, sort store.Sort) (*store.Cursor, bool) {
+	cursorStr := c.Query("cursor")
+	if cursorStr == "" {
+		return nil, true
+	}
+
+	cursor, err := store.DecodeCursor(cursorStr)
+	if err != nil || cursor.Sort != sort {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+		return nil, false
+	}
+	return &cursor, true
+}
+
+// nextCursor is the value of next_cursor in list responses, null on the last page
+func nextCursor(next *store.Cursor) *string {
+	if next == nil {
+		return nil
+	}
+
+	encoded := next.Encode()
+	return &encoded
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"web-forum/internal/passwords"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetPasswordPolicy describes the rules new passwords must follow, for the
+// sign up and password forms to show up front
+func GetPasswordPolicy(rules *passwords.Policy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"policy":         rules,
+			"breached_check": rules.Breached != nil,
+		})
+	}
+}
+
+// checkPassword checks a new password for the user called username against
+// the policy. If it breaks any rule, it responds with 400 listing them all
+// and returns false.
+func checkPassword(c *gin.Context, rules *passwords.Policy, password, username string) bool {
+	err := rules.Check(c.Request.Context(), password, username)
+	if err == nil {
+		return true
+	}
+
+	var policyErr *passwords.Error
+	if errors.As(err, &policyErr) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":      "Password does not meet the requirements",
+			"violations": policyErr.Violations,
+		})
+		return false
+	}
+
+	log.Printf("Error checking password: %v", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process password"})
+	return false
+}
@@ -1,212 +1,96 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
-	"strings"
-	"time"
+	"web-forum/internal/store"
 
 	"github.com/gin-gonic/gin"
-	"github.com/supabase-community/supabase-go"
 )
 
-type Post struct {
-	ID        int    `json:"id"`
-	TopicID   int    `json:"topic_id"`
-	Title     string `json:"title" binding:"required"`
-	Content   string `json:"content" binding:"required"`
-	CreatedBy int    `json:"created_by"`
-	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
-	Users     struct {
-		Username string `json:"username"`
-	} `json:"users"`
-}
-
-type FlatPost struct {
-	ID           int    `json:"id"`
-	TopicID      int    `json:"topic_id"`
-	Title        string `json:"title" binding:"required"`
-	Content      string `json:"content" binding:"required"`
-	CreatedBy    int    `json:"created_by"`
-	CreatedAt    string `json:"created_at"`
-	UpdatedAt    string `json:"updated_at"`
-	Username     string `json:"username"`
-	LikeCount    int    `json:"like_count"`
-	DislikeCount int    `json:"dislike_count"`
-	NetScore     int    `json:"net_score"`
-	UserReaction *int   `json:"user_reaction"`
-}
-
-func GetPosts(client *supabase.Client) gin.HandlerFunc {
+func GetPosts(posts store.PostStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		topicID := c.Query("topic_id")
-		var posts []Post
+		topicIDStr := c.Query("topic_id")
 
-		if topicID == "" {
+		if topicIDStr == "" {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "topic_id is required"})
 			return
 		}
 
-		_, err := client.From("posts").Select(`id, topic_id, title, content, created_by, created_at, updated_at, users(username)`, "", false).Eq("topic_id", topicID).ExecuteTo(&posts)
+		topicID, err := strconv.Atoi(topicIDStr)
 		if err != nil {
-			log.Printf("Error fetching posts: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve posts"})
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid topic id"})
 			return
 		}
 
-		postIDs := make([]string, 0, len(posts))
-		for _, post := range posts {
-			postIDs = append(postIDs, strconv.Itoa(post.ID))
-		}
-
-		type PostReactionRow struct {
-			PostID   int `json:"post_id"`
-			UserID   int `json:"user_id"`
-			Reaction int `json:"reaction"`
-		}
-
-		var reactions []PostReactionRow
-
-		if len(postIDs) > 0 {
-			_, err = client.From("post_reactions").Select("post_id, user_id, reaction", "", false).In("post_id", postIDs).ExecuteTo(&reactions)
-
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reactions"})
-				return
-			}
-		}
-
-		flatPosts := make([]FlatPost, len(posts))
-		for i, post := range posts {
-			flatPosts[i] = FlatPost{
-				ID:           post.ID,
-				TopicID:      post.TopicID,
-				Title:        post.Title,
-				Content:      post.Content,
-				CreatedBy:    post.CreatedBy,
-				CreatedAt:    post.CreatedAt,
-				UpdatedAt:    post.UpdatedAt,
-				Username:     post.Users.Username,
-				LikeCount:    0,
-				DislikeCount: 0,
-				NetScore:     0,
-				UserReaction: nil,
-			}
-		}
-
-		postMap := make(map[int]*FlatPost)
-		// Faster to search for specific post
-		for i := range flatPosts {
-			postMap[flatPosts[i].ID] = &flatPosts[i]
+		opts, ok := listOptions(c)
+		if !ok {
+			return
 		}
 
 		user, _ := c.Get("user")
-		currentUser := user.(User)
+		currentUser := user.(store.User)
 		userID := currentUser.ID
 
-		for _, reaction := range reactions {
-			post := postMap[reaction.PostID]
-			if post == nil {
-				continue
-			}
-
-			if reaction.Reaction == 1 {
-				post.LikeCount++
-				post.NetScore++
-			} else if reaction.Reaction == -1 {
-				post.DislikeCount++
-				post.NetScore--
-			}
-
-			if reaction.UserID == userID {
-				r := reaction.Reaction
-				post.UserReaction = &r
-			}
+		page, err := posts.ListPosts(c.Request.Context(), topicID, userID, opts)
+		if err != nil {
+			log.Printf("Error fetching posts: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve posts"})
+			return
 		}
 
-		c.JSON(http.StatusOK, flatPosts)
+		c.JSON(http.StatusOK, gin.H{
+			"posts":       page.Items,
+			"next_cursor": nextCursor(page.Next),
+		})
 	}
 }
 
-func GetPost(client *supabase.Client) gin.HandlerFunc {
+func GetPost(posts store.PostStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		postID := c.Param("id")
+		postIDStr := c.Param("id")
 
-		if postID == "" {
+		if postIDStr == "" {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "post_id is required"})
 			return
 		}
-		var post Post
-		_, err := client.From("posts").Select(`id, topic_id, title, content, created_by, created_at, updated_at, users(username)`, "", false).Eq("id", postID).Single().ExecuteTo(&post)
 
+		postID, err := strconv.Atoi(postIDStr)
 		if err != nil {
-			if strings.Contains(err.Error(), "PGRST116") || strings.Contains(err.Error(), "0 rows") {
-				c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
-				return
-			}
-
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve post"})
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post id"})
 			return
 		}
 
-		type PostReactionRow struct {
-			PostID   int `json:"post_id"`
-			UserID   int `json:"user_id"`
-			Reaction int `json:"reaction"`
-		}
-
-		var reactions []PostReactionRow
-
-		_, err = client.From("post_reactions").Select("post_id, user_id, reaction", "", false).Eq("post_id", postID).ExecuteTo(&reactions)
-
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reactions"})
-			return
-		}
-
-		flatPost := FlatPost{
-			ID:           post.ID,
-			TopicID:      post.TopicID,
-			Title:        post.Title,
-			Content:      post.Content,
-			CreatedBy:    post.CreatedBy,
-			CreatedAt:    post.CreatedAt,
-			UpdatedAt:    post.UpdatedAt,
-			Username:     post.Users.Username,
-			LikeCount:    0,
-			DislikeCount: 0,
-			NetScore:     0,
-			UserReaction: nil,
-		}
-
 		user, _ := c.Get("user")
-		currentUser := user.(User)
+		currentUser := user.(store.User)
 		userID := currentUser.ID
 
-		for _, reaction := range reactions {
-			if reaction.Reaction == 1 {
-				flatPost.LikeCount++
-				flatPost.NetScore++
-			} else if reaction.Reaction == -1 {
-				flatPost.DislikeCount++
-				flatPost.NetScore--
+		post, err := posts.GetPost(c.Request.Context(), postID, userID)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+				return
 			}
 
-			if reaction.UserID == userID {
-				r := reaction.Reaction
-				flatPost.UserReaction = &r
-			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve post"})
+			return
 		}
 
-		c.JSON(http.StatusOK, flatPost)
+		c.JSON(http.StatusOK, post)
 	}
 }
 
-func CreatePost(client *supabase.Client) gin.HandlerFunc {
+// CreatePost adds a post to a topic that is not archived
+func CreatePost(posts store.PostStore, topics store.TopicStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var post Post
+		var post struct {
+			TopicID int    `json:"topic_id"`
+			Title   string `json:"title" binding:"required"`
+			Content string `json:"content" binding:"required"`
+		}
 
 		if err := c.BindJSON(&post); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Input"})
@@ -214,17 +98,14 @@ func CreatePost(client *supabase.Client) gin.HandlerFunc {
 		}
 
 		user, _ := c.Get("user")
-		currentUser := user.(User)
+		currentUser := user.(store.User)
 		userID := currentUser.ID
 
-		data := map[string]interface{}{
-			"topic_id":   post.TopicID,
-			"title":      post.Title,
-			"content":    post.Content,
-			"created_by": userID,
+		if !requireOpenTopic(c, topics, post.TopicID) {
+			return
 		}
 
-		_, _, err := client.From("posts").Insert(data, false, "", "", "").Execute()
+		_, err := posts.CreatePost(c.Request.Context(), post.TopicID, post.Title, post.Content, userID)
 
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create post"})
@@ -235,9 +116,13 @@ func CreatePost(client *supabase.Client) gin.HandlerFunc {
 	}
 }
 
-func UpdatePost(client *supabase.Client) gin.HandlerFunc {
+func UpdatePost(posts store.PostStore, topics store.TopicStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		id := c.Param("id")
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post id"})
+			return
+		}
 
 		var input struct {
 			Title   string `json:"title" binding:"required"`
@@ -250,16 +135,13 @@ func UpdatePost(client *supabase.Client) gin.HandlerFunc {
 		}
 
 		user, _ := c.Get("user")
-		currentUser := user.(User)
+		currentUser := user.(store.User)
 		userID := currentUser.ID
 
-		var result struct {
-			CreatedBy int `json:"created_by"`
-		}
-		_, err := client.From("posts").Select("created_by", "", false).Eq("id", id).Single().ExecuteTo(&result)
+		result, err := posts.GetPost(c.Request.Context(), id, userID)
 
 		if err != nil {
-			if strings.Contains(err.Error(), "PGRST116") || strings.Contains(err.Error(), "0 rows") {
+			if errors.Is(err, store.ErrNotFound) {
 				c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
 				return
 			}
@@ -268,18 +150,15 @@ func UpdatePost(client *supabase.Client) gin.HandlerFunc {
 			return
 		}
 
-		if result.CreatedBy != userID {
-			c.JSON(http.StatusForbidden, gin.H{"error": "You can only edit posts created by you"})
+		if !canModify(c, result.CreatedBy, result.TopicID, "You can only edit posts created by you") {
 			return
 		}
 
-		data := map[string]interface{}{
-			"title":      input.Title,
-			"content":    input.Content,
-			"updated_at": time.Now(),
+		if !requireOpenTopic(c, topics, result.TopicID) {
+			return
 		}
 
-		_, _, err = client.From("posts").Update(data, "", "").Eq("id", id).Execute()
+		err = posts.UpdatePost(c.Request.Context(), id, input.Title, input.Content, userID)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to edit post"})
 			return
@@ -289,22 +168,22 @@ func UpdatePost(client *supabase.Client) gin.HandlerFunc {
 	}
 }
 
-func DeletePost(client *supabase.Client) gin.HandlerFunc {
+func DeletePost(posts store.PostStore, topics store.TopicStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		id := c.Param("id")
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post id"})
+			return
+		}
 
 		user, _ := c.Get("user")
-		currentUser := user.(User)
+		currentUser := user.(store.User)
 		userID := currentUser.ID
 
-		var result struct {
-			CreatedBy int `json:"created_by"`
-		}
-
-		_, err := client.From("posts").Select("created_by", "", false).Eq("id", id).Single().ExecuteTo(&result)
+		result, err := posts.GetPost(c.Request.Context(), id, userID)
 
 		if err != nil {
-			if strings.Contains(err.Error(), "PGRST116") || strings.Contains(err.Error(), "0 rows") {
+			if errors.Is(err, store.ErrNotFound) {
 				c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
 				return
 			}
@@ -313,12 +192,15 @@ func DeletePost(client *supabase.Client) gin.HandlerFunc {
 			return
 		}
 
-		if result.CreatedBy != userID {
-			c.JSON(http.StatusForbidden, gin.H{"error": "You can only delete posts created by you"})
+		if !canModify(c, result.CreatedBy, result.TopicID, "You can only delete posts created by you") {
+			return
+		}
+
+		if !requireOpenTopic(c, topics, result.TopicID) {
 			return
 		}
 
-		_, _, err = client.From("posts").Delete("", "").Eq("id", id).Execute()
+		err = posts.DeletePost(c.Request.Context(), id)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete post"})
 			return
@@ -327,3 +209,79 @@ func DeletePost(client *supabase.Client) gin.HandlerFunc {
 		c.JSON(http.StatusOK, gin.H{"message": "Post deleted successfully"})
 	}
 }
+
+// PinPost pins a post to the top of its topic or unpins it
+func PinPost(posts store.PostStore, topics store.TopicStore) gin.HandlerFunc {
+	return setPostState(posts, topics, "pinned", posts.SetPostPinned)
+}
+
+// LockPost locks a post against new comments or unlocks it
+func LockPost(posts store.PostStore, topics store.TopicStore) gin.HandlerFunc {
+	return setPostState(posts, topics, "locked", posts.SetPostLocked)
+}
+
+// setPostState turns one of a post's moderation states on or off, as given by
+// the boolean field of the request body. Only the topic's moderators and
+// admins can, and only while the topic is open.
+func setPostState(posts store.PostStore, topics store.TopicStore, field string, set func(ctx context.Context, id int, on bool) error) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post id"})
+			return
+		}
+
+		var input map[string]bool
+		if err := c.BindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+		on, ok := input[field]
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": field + " is required"})
+			return
+		}
+
+		user, _ := c.Get("user")
+		currentUser := user.(store.User)
+		userID := currentUser.ID
+
+		result, err := posts.GetPost(c.Request.Context(), id, userID)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+				return
+			}
+
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve post"})
+			return
+		}
+
+		if !requireModerator(c, result.TopicID) {
+			return
+		}
+
+		if !requireOpenTopic(c, topics, result.TopicID) {
+			return
+		}
+
+		if err := set(c.Request.Context(), id, on); err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+				return
+			}
+
+			log.Printf("Error setting post %s: %v", field, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update post"})
+			return
+		}
+
+		result, err = posts.GetPost(c.Request.Context(), id, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve post"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Post updated successfully", "post": result})
+	}
+}
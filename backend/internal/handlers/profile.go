@@ -0,0 +1,274 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+	"web-forum/internal/lockout"
+	"web-forum/internal/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	maxDisplayName = 50
+	maxBio         = 1000
+	maxAvatarURL   = 2048
+)
+
+// GetProfile shows a user's public profile with how much they have posted and
+// the reputation others gave them. Deleted accounts have none.
+func GetProfile(profiles store.ProfileStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user id"})
+			return
+		}
+
+		profile, err := profiles.GetProfile(c.Request.Context(), id)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+				return
+			}
+
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve profile"})
+			return
+		}
+
+		c.JSON(http.StatusOK, profile)
+	}
+}
+
+// UpdateProfile changes the display name, bio and avatar of a user, leaving
+// out fields that are not given. Users edit their own, admins anyone's.
+func UpdateProfile(profiles store.ProfileStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := ownAccount(c)
+		if !ok {
+			return
+		}
+
+		var input struct {
+			DisplayName *string `json:"display_name"`
+			Bio         *string `json:"bio"`
+			AvatarURL   *string `json:"avatar_url"`
+		}
+
+		if err := c.BindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+
+		update := store.ProfileUpdate{DisplayName: input.DisplayName, Bio: input.Bio, AvatarURL: input.AvatarURL}
+		if update.DisplayName != nil {
+			displayName := strings.Join(strings.Fields(*update.DisplayName), " ")
+			if utf8.RuneCountInString(displayName) > maxDisplayName {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Display name can be at most 50 characters"})
+				return
+			}
+			update.DisplayName = &displayName
+		}
+		if update.Bio != nil {
+			bio := strings.TrimSpace(*update.Bio)
+			if utf8.RuneCountInString(bio) > maxBio {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Bio can be at most 1000 characters"})
+				return
+			}
+			update.Bio = &bio
+		}
+		if update.AvatarURL != nil {
+			avatarURL := strings.TrimSpace(*update.AvatarURL)
+			if !validAvatarURL(avatarURL) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Avatar must be an http or https URL"})
+				return
+			}
+			update.AvatarURL = &avatarURL
+		}
+
+		user, err := profiles.UpdateProfile(c.Request.Context(), id, update)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+				return
+			}
+
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Profile updated successfully", "user": user})
+	}
+}
+
+// validAvatarURL accepts no avatar or an absolute http(s) URL. Other schemes,
+// javascript: and data: among them, have no business in an img tag.
+func validAvatarURL(avatarURL string) bool {
+	if avatarURL == "" {
+		return true
+	}
+	if len(avatarURL) > maxAvatarURL {
+		return false
+	}
+
+	u, err := url.Parse(avatarURL)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// ChangeUsername renames a user. Users rename themselves, admins anyone. The
+// old name is free for others to take at once.
+func ChangeUsername(profiles store.ProfileStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := ownAccount(c)
+		if !ok {
+			return
+		}
+
+		var input struct {
+			Username string `json:"username" binding:"required,min=3,max=50"`
+		}
+
+		if err := c.BindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input. Usernames need 3 to 50 characters"})
+			return
+		}
+
+		if reservedUsername(input.Username) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "That username is reserved"})
+			return
+		}
+
+		err := profiles.SetUsername(c.Request.Context(), id, input.Username)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+				return
+			}
+			if errors.Is(err, store.ErrConflict) {
+				c.JSON(http.StatusConflict, gin.H{"error": "Username already exists"})
+				return
+			}
+
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change username"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Username changed successfully"})
+	}
+}
+
+// reservedUsername reports whether username looks like a deleted account's,
+// which nobody may take
+func reservedUsername(username string) bool {
+	return strings.HasPrefix(strings.ToLower(username), store.DeletedUsernamePrefix)
+}
+
+// DeleteAccount deletes a user, signing them out everywhere. The policy says
+// whether their posts, comments and reactions stay, anonymised, or go too.
+//
+// Users deleting themselves confirm with their password, or with their
+// username if they only sign in through single sign-on. Admins may delete
+// other users, though not other admins, and not themselves: another admin has
+// to demote them first, so the forum always keeps one.
+func DeleteAccount(users store.UserStore, profiles store.ProfileStore, guard *lockout.Guard, audits store.AuditStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, _ := c.Get("user")
+		currentUser := user.(store.User)
+
+		id, ok := ownAccount(c)
+		if !ok {
+			return
+		}
+
+		var input struct {
+			Policy   string `json:"policy" binding:"required"`
+			Password string `json:"password"`
+			Username string `json:"username"`
+		}
+
+		if err := c.BindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+
+		policy, err := store.ParseDeletionPolicy(input.Policy)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "policy must be one of anonymise or delete"})
+			return
+		}
+
+		if id == currentUser.ID {
+			if currentUser.Role == store.RoleAdmin {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Admins cannot delete their own account. Ask another admin to change your role first"})
+				return
+			}
+
+			if !confirmPassword(c, users, guard, audits, currentUser, input.Password, input.Username) {
+				return
+			}
+		} else {
+			target, err := users.GetUserByID(c.Request.Context(), id)
+			if err != nil {
+				if errors.Is(err, store.ErrNotFound) {
+					c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+					return
+				}
+
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+				return
+			}
+
+			if target.Role == store.RoleAdmin {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Admins cannot be deleted. Change their role first"})
+				return
+			}
+		}
+
+		err = profiles.DeleteUser(c.Request.Context(), id, policy)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+				return
+			}
+
+			log.Printf("Error deleting user %d: %v", id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete account"})
+			return
+		}
+
+		detail := "Policy " + string(policy)
+		if id != currentUser.ID {
+			detail += ", by admin " + currentUser.Username
+		}
+		audit(c, audits, store.AuditEntry{Action: store.AuditAccountDeleted, UserID: &id, Detail: detail})
+
+		if id == currentUser.ID {
+			clearSessionCookies(c)
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Account deleted successfully"})
+	}
+}
+
+// ownAccount reads the user id of an account route and checks the signed-in
+// user may manage that account: their own, or anyone's for admins. If not, it
+// responds and returns false.
+func ownAccount(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user id"})
+		return 0, false
+	}
+
+	permissions := permissionsOf(c)
+	if id != permissions.UserID && !permissions.IsAdmin() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only change your own account"})
+		return 0, false
+	}
+	return id, true
+}
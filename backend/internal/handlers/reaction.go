@@ -1,11 +1,12 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"web-forum/internal/store"
 
 	"github.com/gin-gonic/gin"
-	"github.com/supabase-community/supabase-go"
 )
 
 type PostReaction struct {
@@ -16,7 +17,13 @@ type CommentReaction struct {
 	Reaction int `json:"reaction"`
 }
 
-func CreatePostReaction(client *supabase.Client) gin.HandlerFunc {
+var reactionMessages = map[store.ReactionChange]string{
+	store.ReactionCreated: "Reaction created",
+	store.ReactionUpdated: "Reaction updated",
+	store.ReactionDeleted: "Reaction deleted",
+}
+
+func CreatePostReaction(reactions store.ReactionStore, posts store.PostStore, topics store.TopicStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		postIDStr := c.Param("id")
 		postID, err := strconv.Atoi(postIDStr)
@@ -26,9 +33,8 @@ func CreatePostReaction(client *supabase.Client) gin.HandlerFunc {
 		}
 
 		user, _ := c.Get("user")
-		currentUser := user.(User)
+		currentUser := user.(store.User)
 		userID := currentUser.ID
-		strUserId := strconv.Itoa(userID)
 
 		var input PostReaction
 		if err := c.BindJSON(&input); err != nil || (input.Reaction != 1 && input.Reaction != -1) {
@@ -36,63 +42,33 @@ func CreatePostReaction(client *supabase.Client) gin.HandlerFunc {
 			return
 		}
 
-		reaction := input.Reaction
-		var existing []PostReaction
-		_, err = client.From("post_reactions").Select("reaction", "", false).Eq("user_id", strUserId).Eq("post_id", postIDStr).ExecuteTo(&existing)
-
+		post, err := posts.GetPost(c.Request.Context(), postID, userID)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reaction"})
-			return
-		}
-
-		if len(existing) == 0 {
-			// Insert reaction
-			data := map[string]interface{}{
-				"post_id":  postID,
-				"user_id":  userID,
-				"reaction": reaction,
-			}
-			_, _, err = client.From("post_reactions").Insert(data, false, "", "", "").Execute()
-
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create reaction"})
+			if errors.Is(err, store.ErrNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
 				return
 			}
 
-			c.JSON(http.StatusOK, gin.H{"message": "Reaction created"})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update reaction"})
 			return
 		}
 
-		currentReaction := existing[0].Reaction
-		if reaction == currentReaction {
-			// Means double click
-			// Delete
-			_, _, err = client.From("post_reactions").Delete("", "").Eq("post_id", postIDStr).Eq("user_id", strUserId).Execute()
-
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete reaction"})
-				return
-			}
-
-			c.JSON(http.StatusOK, gin.H{"message": "Reaction deleted"})
+		// Posts in archived topics are read-only, reactions included
+		if !requireOpenTopic(c, topics, post.TopicID) {
 			return
 		}
-		// Like -> Dislike or opposite
-		// Update
-		data := map[string]interface{}{
-			"reaction": reaction,
-		}
-		_, _, err = client.From("post_reactions").Update(data, "", "").Eq("post_id", postIDStr).Eq("user_id", strUserId).Execute()
+
+		change, err := reactions.TogglePostReaction(c.Request.Context(), postID, userID, input.Reaction)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update reaction"})
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{"message": "Reaction updated"})
+		c.JSON(http.StatusOK, gin.H{"message": reactionMessages[change]})
 	}
 }
 
-func CreateCommentReaction(client *supabase.Client) gin.HandlerFunc {
+func CreateCommentReaction(reactions store.ReactionStore, comments store.CommentStore, topics store.TopicStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		commentIDStr := c.Param("id")
 		commentID, err := strconv.Atoi(commentIDStr)
@@ -102,9 +78,8 @@ func CreateCommentReaction(client *supabase.Client) gin.HandlerFunc {
 		}
 
 		user, _ := c.Get("user")
-		currentUser := user.(User)
+		currentUser := user.(store.User)
 		userID := currentUser.ID
-		strUserId := strconv.Itoa(userID)
 
 		var input CommentReaction
 		if err := c.BindJSON(&input); err != nil || (input.Reaction != 1 && input.Reaction != -1) {
@@ -112,58 +87,27 @@ func CreateCommentReaction(client *supabase.Client) gin.HandlerFunc {
 			return
 		}
 
-		reaction := input.Reaction
-		var existing []CommentReaction
-		_, err = client.From("comment_reactions").Select("reaction", "", false).Eq("user_id", strUserId).Eq("comment_id", commentIDStr).ExecuteTo(&existing)
-
+		comment, err := comments.GetComment(c.Request.Context(), commentID, userID)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reaction"})
-			return
-		}
-
-		if len(existing) == 0 {
-			// Insert reaction
-			data := map[string]interface{}{
-				"comment_id": commentID,
-				"user_id":    userID,
-				"reaction":   reaction,
-			}
-			_, _, err = client.From("comment_reactions").Insert(data, false, "", "", "").Execute()
-
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create reaction"})
+			if errors.Is(err, store.ErrNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
 				return
 			}
 
-			c.JSON(http.StatusOK, gin.H{"message": "Reaction created"})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update reaction"})
 			return
 		}
 
-		currentReaction := existing[0].Reaction
-		if reaction == currentReaction {
-			// Means double click
-			// Delete
-			_, _, err = client.From("comment_reactions").Delete("", "").Eq("comment_id", commentIDStr).Eq("user_id", strUserId).Execute()
-
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete reaction"})
-				return
-			}
-
-			c.JSON(http.StatusOK, gin.H{"message": "Reaction deleted"})
+		if !requireOpenTopic(c, topics, comment.TopicID) {
 			return
 		}
-		// Like -> Dislike or opposite
-		// Update
-		data := map[string]interface{}{
-			"reaction": reaction,
-		}
-		_, _, err = client.From("comment_reactions").Update(data, "", "").Eq("comment_id", commentIDStr).Eq("user_id", strUserId).Execute()
+
+		change, err := reactions.ToggleCommentReaction(c.Request.Context(), commentID, userID, input.Reaction)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update reaction"})
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{"message": "Reaction updated"})
+		c.JSON(http.StatusOK, gin.H{"message": reactionMessages[change]})
 	}
 }
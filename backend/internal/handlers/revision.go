@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"web-forum/internal/diff"
+	"web-forum/internal/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetPostRevisions lists every version of a post, oldest first
+func GetPostRevisions(posts store.PostStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		revisions, ok := revisionsOf(c, posts.ListPostRevisions, "Invalid post id", "Post not found")
+		if !ok {
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"revisions": revisions})
+	}
+}
+
+// GetPostRevisionDiff compares the title and content of two versions of a post
+func GetPostRevisionDiff(posts store.PostStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		revisions, ok := revisionsOf(c, posts.ListPostRevisions, "Invalid post id", "Post not found")
+		if !ok {
+			return
+		}
+
+		from, to, ok := revisionPair(c, revisions)
+		if !ok {
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"from":    from,
+			"to":      to,
+			"title":   diff.Text(from.Title, to.Title),
+			"content": diff.Text(from.Content, to.Content),
+		})
+	}
+}
+
+// GetCommentRevisions lists every version of a comment, oldest first
+func GetCommentRevisions(comments store.CommentStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		revisions, ok := revisionsOf(c, comments.ListCommentRevisions, "Invalid comment id", "Comment not found")
+		if !ok {
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"revisions": revisions})
+	}
+}
+
+// GetCommentRevisionDiff compares the content of two versions of a comment
+func GetCommentRevisionDiff(comments store.CommentStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		revisions, ok := revisionsOf(c, comments.ListCommentRevisions, "Invalid comment id", "Comment not found")
+		if !ok {
+			return
+		}
+
+		from, to, ok := revisionPair(c, revisions)
+		if !ok {
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"from":    from,
+			"to":      to,
+			"content": diff.Text(from.Content, to.Content),
+		})
+	}
+}
+
+// revisionsOf lists the revisions of the post or comment in the id parameter.
+// If the id is invalid or names nothing, it responds with 400 or 404 and the
+// given message and returns false.
+func revisionsOf(c *gin.Context, list func(ctx context.Context, id int) ([]store.Revision, error), invalid, notFound string) ([]store.Revision, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": invalid})
+		return nil, false
+	}
+
+	revisions, err := list(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": notFound})
+			return nil, false
+		}
+
+		log.Printf("Error fetching revisions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve revisions"})
+		return nil, false
+	}
+	return revisions, true
+}
+
+// revisionPair picks the revisions numbered by the from and to query
+// parameters. to defaults to the current revision and from to the one before
+// to, or to itself if that is the original. If either is not a revision, it
+// responds with 400 or 404 and returns false.
+func revisionPair(c *gin.Context, revisions []store.Revision) (from, to store.Revision, ok bool) {
+	toNumber := revisions[len(revisions)-1].Number
+	if toStr := c.Query("to"); toStr != "" {
+		n, err := strconv.Atoi(toStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be a revision number"})
+			return store.Revision{}, store.Revision{}, false
+		}
+		toNumber = n
+	}
+
+	fromNumber := max(toNumber-1, 1)
+	if fromStr := c.Query("from"); fromStr != "" {
+		n, err := strconv.Atoi(fromStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be a revision number"})
+			return store.Revision{}, store.Revision{}, false
+		}
+		fromNumber = n
+	}
+
+	from, fromFound := revisionNumbered(revisions, fromNumber)
+	to, toFound := revisionNumbered(revisions, toNumber)
+	if !fromFound || !toFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Revision not found"})
+		return store.Revision{}, store.Revision{}, false
+	}
+	return from, to, true
+}
+
+func revisionNumbered(revisions []store.Revision, number int) (store.Revision, bool) {
+	for _, revision := range revisions {
+		if revision.Number == number {
+			return revision, true
+		}
+	}
+	return store.Revision{}, false
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"web-forum/internal/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxSearchLength = 200
+
+// Search runs a full-text search over topics, posts and comments. Besides q
+// it takes type (a comma separated list of topic, post and comment),
+// topic_id, author (a username), from and to (dates or RFC 3339 times),
+// limit and cursor.
+func Search(searches store.SearchStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		query := store.SearchQuery{Text: strings.TrimSpace(c.Query("q"))}
+
+		if query.Text == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "q is required"})
+			return
+		}
+		if len(query.Text) > maxSearchLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "q must be at most " + strconv.Itoa(maxSearchLength) + " characters"})
+			return
+		}
+
+		if typesStr := c.Query("type"); typesStr != "" {
+			for _, typeStr := range strings.Split(typesStr, ",") {
+				searchType, err := store.ParseSearchType(strings.TrimSpace(typeStr))
+				if err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "type must be a list of topic, post or comment"})
+					return
+				}
+				query.Types = append(query.Types, searchType)
+			}
+		}
+
+		if topicIDStr := c.Query("topic_id"); topicIDStr != "" {
+			topicID, err := strconv.Atoi(topicIDStr)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid topic id"})
+				return
+			}
+			query.TopicID = topicID
+		}
+
+		query.Author = c.Query("author")
+
+		var ok bool
+		if query.From, ok = searchTime(c, "from", false); !ok {
+			return
+		}
+		if query.To, ok = searchTime(c, "to", true); !ok {
+			return
+		}
+		if query.Limit, ok = pageLimit(c); !ok {
+			return
+		}
+		if query.After, ok = pageCursor(c, store.SortRelevance); !ok {
+			return
+		}
+
+		page, err := searches.Search(c.Request.Context(), query)
+		if err != nil {
+			log.Printf("Error searching: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"results":     page.Items,
+			"next_cursor": nextCursor(page.Next),
+		})
+	}
+}
+
+// searchTime reads a date range bound. A bare date as the upper bound covers
+// that whole day. On bad input it responds with 400 and returns false.
+func searchTime(c *gin.Context, param string, endOfDay bool) (time.Time, bool) {
+	value := c.Query(param)
+	if value == "" {
+		return time.Time{}, true
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, true
+	}
+
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": param + " must be a date (YYYY-MM-DD) or an RFC 3339 time"})
+		return time.Time{}, false
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return t, true
+}
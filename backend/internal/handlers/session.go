@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+	"web-forum/internal/auth"
+	"web-forum/internal/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	accessCookie  = "Authorisation"
+	refreshCookie = "Refresh"
+	// The refresh token is only ever needed by the refresh and logout routes
+	refreshCookiePath = "/api/users"
+)
+
+// setCookie sets an HttpOnly cookie, scoped to the forum's domain in production
+func setCookie(c *gin.Context, name, value, path string, maxAge int) {
+	isProduction := os.Getenv("ENVIRONMENT") == "production"
+	if isProduction {
+		c.SetSameSite(http.SameSiteNoneMode)
+		c.SetCookie(name, value, maxAge, path, ".forum.sahishnu.dev", true, true)
+	} else {
+		c.SetSameSite(http.SameSiteLaxMode)
+		c.SetCookie(name, value, maxAge, path, "", false, true)
+	}
+}
+
+// setSessionCookies hands the client a new access token and refresh token for a session
+func setSessionCookies(c *gin.Context, session store.Session, refreshToken string) bool {
+	accessToken, err := auth.IssueAccessToken(session.UserID, session.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Authentication error"})
+		return false
+	}
+
+	setCookie(c, accessCookie, accessToken, "/", int(auth.AccessTokenTTL.Seconds()))
+	setCookie(c, refreshCookie, refreshToken, refreshCookiePath, int(time.Until(session.ExpiresAt).Seconds()))
+	return true
+}
+
+func clearSessionCookies(c *gin.Context) {
+	setCookie(c, accessCookie, "", "/", -1)
+	setCookie(c, refreshCookie, "", refreshCookiePath, -1)
+}
+
+// startSession logs a user in: it creates a session and sets its cookies. On
+// failure it responds with 500 and returns false.
+func startSession(c *gin.Context, sessions store.SessionStore, user store.User) bool {
+	refreshToken, hash, err := auth.NewRefreshToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Authentication error"})
+		return false
+	}
+
+	session, err := sessions.CreateSession(c.Request.Context(), user.ID, hash,
+		c.Request.UserAgent(), c.ClientIP(), time.Now().Add(auth.SessionTTL))
+	if err != nil {
+		log.Printf("Error creating session: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Authentication error"})
+		return false
+	}
+
+	return setSessionCookies(c, session, refreshToken)
+}
+
+// currentSessionID returns the session RequireAuthentication found the request's token belongs to
+func currentSessionID(c *gin.Context) int {
+	sessionID, _ := c.Get("session_id")
+	return sessionID.(int)
+}
+
+// Refresh exchanges the refresh token cookie for a new access token and a new
+// refresh token. Presenting a refresh token twice revokes its session.
+func Refresh(sessions store.SessionStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		oldToken, err := c.Cookie(refreshCookie)
+		if err != nil || oldToken == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Not logged in"})
+			return
+		}
+
+		newToken, newHash, err := auth.NewRefreshToken()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Authentication error"})
+			return
+		}
+
+		session, err := sessions.RotateRefreshToken(c.Request.Context(), auth.HashToken(oldToken), newHash)
+		if err != nil {
+			if errors.Is(err, store.ErrTokenReused) {
+				log.Printf("Refresh token reused, revoked its session")
+			}
+			if errors.Is(err, store.ErrNotFound) || errors.Is(err, store.ErrTokenReused) {
+				clearSessionCookies(c)
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Session expired. Please log in again"})
+				return
+			}
+
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh session"})
+			return
+		}
+
+		if !setSessionCookies(c, session, newToken) {
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Session refreshed"})
+	}
+}
+
+func GetSessions(sessions store.SessionStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, _ := c.Get("user")
+		currentUser := user.(store.User)
+
+		result, err := sessions.ListSessions(c.Request.Context(), currentUser.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve sessions"})
+			return
+		}
+
+		currentID := currentSessionID(c)
+		for i := range result {
+			result[i].Current = result[i].ID == currentID
+		}
+
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+// RevokeSession logs one of the user's sessions out
+func RevokeSession(sessions store.SessionStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session id"})
+			return
+		}
+
+		user, _ := c.Get("user")
+		currentUser := user.(store.User)
+
+		// Someone else's session is reported as missing, not forbidden, so
+		// session ids cannot be probed
+		session, err := sessions.GetSession(c.Request.Context(), id)
+		if errors.Is(err, store.ErrNotFound) || err == nil && session.UserID != currentUser.ID {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+			return
+		}
+
+		err = sessions.RevokeSession(c.Request.Context(), id)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+				return
+			}
+
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+			return
+		}
+
+		if id == currentSessionID(c) {
+			clearSessionCookies(c)
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Session revoked successfully"})
+	}
+}
+
+// RevokeAllSessions logs the user out everywhere, including this browser
+func RevokeAllSessions(sessions store.SessionStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, _ := c.Get("user")
+		currentUser := user.(store.User)
+
+		err := sessions.RevokeUserSessions(c.Request.Context(), currentUser.ID, 0)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke sessions"})
+			return
+		}
+
+		clearSessionCookies(c)
+		c.JSON(http.StatusOK, gin.H{"message": "Logged out everywhere"})
+	}
+}
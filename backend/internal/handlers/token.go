@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"web-forum/internal/auth"
+	"web-forum/internal/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	maxTokenNameLength = 100
+	// Personal access tokens last 30 days unless asked otherwise, at most a year
+	defaultTokenDays = 30
+	maxTokenDays     = 365
+)
+
+func GetPersonalTokens(tokens store.PersonalTokenStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, _ := c.Get("user")
+		currentUser := user.(store.User)
+
+		result, err := tokens.ListPersonalTokens(c.Request.Context(), currentUser.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve tokens"})
+			return
+		}
+
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+// CreatePersonalToken creates a personal access token for scripts and bots.
+// The response is the only time the token itself is shown.
+func CreatePersonalToken(tokens store.PersonalTokenStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input struct {
+			Name          string `json:"name" binding:"required"`
+			Scope         string `json:"scope" binding:"required"`
+			ExpiresInDays *int   `json:"expires_in_days"`
+		}
+
+		if err := c.BindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+
+		name := strings.TrimSpace(input.Name)
+		if name == "" || len(name) > maxTokenNameLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "name must be 1 to " + strconv.Itoa(maxTokenNameLength) + " characters"})
+			return
+		}
+
+		scope, err := store.ParseTokenScope(input.Scope)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "scope must be read or write"})
+			return
+		}
+
+		days := defaultTokenDays
+		if input.ExpiresInDays != nil {
+			days = *input.ExpiresInDays
+		}
+		if days < 1 || days > maxTokenDays {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "expires_in_days must be between 1 and " + strconv.Itoa(maxTokenDays)})
+			return
+		}
+
+		secret, hash, err := auth.NewPersonalToken()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create token"})
+			return
+		}
+
+		user, _ := c.Get("user")
+		currentUser := user.(store.User)
+
+		token, err := tokens.CreatePersonalToken(c.Request.Context(), currentUser.ID, name, scope, hash,
+			time.Now().AddDate(0, 0, days))
+		if err != nil {
+			log.Printf("Error creating personal access token: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create token"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{
+			"message":        "Token created successfully. Copy it now, it will not be shown again",
+			"token":          secret,
+			"personal_token": token,
+		})
+	}
+}
+
+// RevokePersonalToken revokes one of the user's personal access tokens
+func RevokePersonalToken(tokens store.PersonalTokenStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid token id"})
+			return
+		}
+
+		user, _ := c.Get("user")
+		currentUser := user.(store.User)
+
+		// Like sessions, someone else's token is reported as missing
+		token, err := tokens.GetPersonalToken(c.Request.Context(), id)
+		if errors.Is(err, store.ErrNotFound) || err == nil && token.UserID != currentUser.ID {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Token not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke token"})
+			return
+		}
+
+		err = tokens.RevokePersonalToken(c.Request.Context(), id)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Token not found"})
+				return
+			}
+
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke token"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Token revoked successfully"})
+	}
+}
@@ -1,64 +1,266 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
+	"strconv"
 	"strings"
-	"time"
+	"unicode/utf8"
+	"web-forum/internal/store"
 
 	"github.com/gin-gonic/gin"
-	"github.com/supabase-community/supabase-go"
 )
 
-type Topic struct {
-	ID int				`json:"id"`
-	Title string		`json:"title" binding:"required"`
-	CreatedBy int		`json:"created_by"`
-	CreatedAt time.Time	`json:"created_at"`
+const maxTopicDescription = 1000
+
+// optionalInt is a JSON number that may also be null or left out, which
+// Set tells apart
+type optionalInt struct {
+	Set   bool
+	Value *int
+}
+
+func (o *optionalInt) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	return json.Unmarshal(data, &o.Value)
 }
 
-func GetTopics(client *supabase.Client) gin.HandlerFunc {
+// GetTopics lists the topics with their post and comment counts and latest
+// activity, ordered by the sort query parameter: oldest, newest or activity.
+// category_id narrows it to a category and its subcategories.
+func GetTopics(topics store.TopicStore, categories store.CategoryStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var topics []Topic
-		_, err := client.From("topics").Select("id, title, created_by, created_at", "", false).ExecuteTo(&topics)
+		sort, err := store.ParseTopicSort(c.Query("sort"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "sort must be one of oldest, newest or activity"})
+			return
+		}
+
+		categoryID := 0
+		if categoryIDStr := c.Query("category_id"); categoryIDStr != "" {
+			categoryID, err = strconv.Atoi(categoryIDStr)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category id"})
+				return
+			}
+
+			if _, err := categories.GetCategory(c.Request.Context(), categoryID); err != nil {
+				if errors.Is(err, store.ErrNotFound) {
+					c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+					return
+				}
+
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve topics"})
+				return
+			}
+		}
+
+		result, err := topics.ListTopicStats(c.Request.Context(), categoryID, sort)
 		if err != nil {
+			log.Printf("Error fetching topics: %v", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve topics"})
-            return
+			return
 		}
 
-		c.JSON(http.StatusOK, topics)
+		c.JSON(http.StatusOK, result)
 	}
 }
 
-func CreateTopic(client *supabase.Client) gin.HandlerFunc {
+func GetTopic(topics store.TopicStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var topic Topic
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid topic id"})
+			return
+		}
+
+		topic, err := topics.GetTopic(c.Request.Context(), id)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Topic not found"})
+				return
+			}
+
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve topic"})
+			return
+		}
+
+		c.JSON(http.StatusOK, topic)
+	}
+}
+
+func CreateTopic(topics store.TopicStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var topic struct {
+			Title       string `json:"title" binding:"required"`
+			Description string `json:"description"`
+			CategoryID  *int   `json:"category_id"`
+		}
 
 		if err := c.BindJSON(&topic); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
-            return
+			return
+		}
+
+		description := strings.TrimSpace(topic.Description)
+		if utf8.RuneCountInString(description) > maxTopicDescription {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Description must be at most 1000 characters"})
+			return
 		}
 
 		user, _ := c.Get("user")
-		currentUser := user.(User)
+		currentUser := user.(store.User)
 		userID := currentUser.ID
 
-		data := map[string]interface{} {
-			"title": topic.Title,
-			"created_by": userID,
-		}
-
-		_, _, err := client.From("topics").Insert(data, false, "", "", "").Execute()
+		_, err := topics.CreateTopic(c.Request.Context(), topic.Title, description, topic.CategoryID, userID)
 		if err != nil {
-            if strings.Contains(err.Error(), "duplicate") || strings.Contains(err.Error(), "unique") {
+			if errors.Is(err, store.ErrConflict) {
 				c.JSON(http.StatusConflict, gin.H{"error": "Topic already exists"})
-                return
+				return
+			}
+			if errors.Is(err, store.ErrNotFound) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Category not found"})
+				return
 			}
-            
+
 			// Other database errors
-            c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create topic"})
-            return
-        }
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create topic"})
+			return
+		}
 
 		c.JSON(http.StatusCreated, gin.H{"message": "Topic created successfully"})
 	}
-}
\ No newline at end of file
+}
+
+// UpdateTopic renames a topic, changes its description and category and
+// archives or unarchives it. The creator of the topic, its moderators and
+// admins may.
+func UpdateTopic(topics store.TopicStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid topic id"})
+			return
+		}
+
+		var input struct {
+			Title       string `json:"title" binding:"required"`
+			Description string `json:"description"`
+			// CategoryID moves the topic, or with null takes it out of any
+			// category. Leaving it out keeps the topic where it is.
+			CategoryID optionalInt `json:"category_id"`
+			// Archived, if given, archives or unarchives the topic
+			Archived *bool `json:"archived"`
+		}
+
+		if err := c.BindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+
+		description := strings.TrimSpace(input.Description)
+		if utf8.RuneCountInString(description) > maxTopicDescription {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Description must be at most 1000 characters"})
+			return
+		}
+
+		result, err := topics.GetTopic(c.Request.Context(), id)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Topic not found"})
+				return
+			}
+
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve topic"})
+			return
+		}
+
+		if !canModify(c, result.CreatedBy, result.ID, "You can only edit topics created by you") {
+			return
+		}
+
+		result, err = topics.UpdateTopic(c.Request.Context(), id, store.TopicUpdate{
+			Title:        input.Title,
+			Description:  description,
+			MoveCategory: input.CategoryID.Set,
+			CategoryID:   input.CategoryID.Value,
+			Archived:     input.Archived,
+		})
+		if err != nil {
+			if errors.Is(err, store.ErrConflict) {
+				c.JSON(http.StatusConflict, gin.H{"error": "Topic already exists"})
+				return
+			}
+			if errors.Is(err, store.ErrNotFound) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Category not found"})
+				return
+			}
+
+			log.Printf("Error updating topic: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to edit topic"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Topic edited successfully", "topic": result})
+	}
+}
+
+// DeleteTopic deletes a topic with all its posts, their comments and
+// reactions. The creator of the topic, its moderators and admins may.
+func DeleteTopic(topics store.TopicStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid topic id"})
+			return
+		}
+
+		result, err := topics.GetTopic(c.Request.Context(), id)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Topic not found"})
+				return
+			}
+
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve topic"})
+			return
+		}
+
+		if !canModify(c, result.CreatedBy, result.ID, "You can only delete topics created by you") {
+			return
+		}
+
+		err = topics.DeleteTopic(c.Request.Context(), id)
+		if err != nil {
+			log.Printf("Error deleting topic: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete topic"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Topic deleted successfully"})
+	}
+}
+
+// requireOpenTopic checks that posts and comments in a topic may be written.
+// If the topic is missing or archived, it responds with 404 or 403 and
+// returns false.
+func requireOpenTopic(c *gin.Context, topics store.TopicStore, topicID int) bool {
+	topic, err := topics.GetTopic(c.Request.Context(), topicID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Topic not found"})
+			return false
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve topic"})
+		return false
+	}
+
+	if topic.Archived() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Topic is archived and can no longer be changed"})
+		return false
+	}
+	return true
+}
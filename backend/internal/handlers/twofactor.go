@@ -0,0 +1,329 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+	"web-forum/internal/auth"
+	"web-forum/internal/lockout"
+	"web-forum/internal/store"
+	"web-forum/internal/totp"
+
+	"github.com/gin-gonic/gin"
+)
+
+// totpIssuer names the forum in authenticator apps
+const totpIssuer = "Web Forum"
+
+func GetTwoFactor(twoFactors store.TwoFactorStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, _ := c.Get("user")
+		currentUser := user.(store.User)
+
+		t, err := twoFactors.GetTOTP(c.Request.Context(), currentUser.ID)
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve two-factor status"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"enabled":             t.Enabled(),
+			"recovery_codes_left": t.RecoveryCodesLeft,
+		})
+	}
+}
+
+// SetupTwoFactor starts enrolling an authenticator app. Two-factor is only
+// enabled once ConfirmTwoFactor sees a code from it.
+func SetupTwoFactor(twoFactors store.TwoFactorStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, _ := c.Get("user")
+		currentUser := user.(store.User)
+
+		secret, err := totp.NewSecret()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set up two-factor authentication"})
+			return
+		}
+
+		err = twoFactors.StartTOTP(c.Request.Context(), currentUser.ID, secret)
+		if err != nil {
+			if errors.Is(err, store.ErrConflict) {
+				c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled"})
+				return
+			}
+
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set up two-factor authentication"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"secret":      secret,
+			"otpauth_uri": totp.URI(totpIssuer, currentUser.Username, secret),
+		})
+	}
+}
+
+// ConfirmTwoFactor enables two-factor with the first code from the app and
+// returns the recovery codes. They are only shown this once.
+func ConfirmTwoFactor(twoFactors store.TwoFactorStore, audits store.AuditStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, _ := c.Get("user")
+		currentUser := user.(store.User)
+
+		var input struct {
+			Code string `json:"code" binding:"required"`
+		}
+
+		if err := c.BindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+
+		t, err := twoFactors.GetTOTP(c.Request.Context(), currentUser.ID)
+		if errors.Is(err, store.ErrNotFound) || err == nil && t.Enabled() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Start two-factor setup first"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enable two-factor authentication"})
+			return
+		}
+
+		step, ok := totp.Verify(t.Secret, input.Code, time.Now())
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid code"})
+			return
+		}
+
+		codes, hashes, err := auth.NewRecoveryCodes()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enable two-factor authentication"})
+			return
+		}
+
+		err = twoFactors.EnableTOTP(c.Request.Context(), currentUser.ID, step, hashes)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Start two-factor setup first"})
+				return
+			}
+
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enable two-factor authentication"})
+			return
+		}
+
+		audit(c, audits, store.AuditEntry{Action: store.AuditTwoFactorEnabled, UserID: &currentUser.ID})
+
+		c.JSON(http.StatusOK, gin.H{
+			"message":        "Two-factor authentication enabled. Keep these recovery codes somewhere safe",
+			"recovery_codes": codes,
+		})
+	}
+}
+
+// DisableTwoFactor turns two-factor off. It takes the password, or the username
+// of users without one, so an unattended browser is not enough.
+func DisableTwoFactor(users store.UserStore, twoFactors store.TwoFactorStore, guard *lockout.Guard, audits store.AuditStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, _ := c.Get("user")
+		currentUser := user.(store.User)
+
+		var input struct {
+			Password string `json:"password"`
+			Username string `json:"username"`
+		}
+
+		if err := c.BindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+
+		if !confirmPassword(c, users, guard, audits, currentUser, input.Password, input.Username) {
+			return
+		}
+
+		err := twoFactors.DisableTOTP(c.Request.Context(), currentUser.ID)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Two-factor authentication is not enabled"})
+				return
+			}
+
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disable two-factor authentication"})
+			return
+		}
+
+		audit(c, audits, store.AuditEntry{Action: store.AuditTwoFactorDisabled, UserID: &currentUser.ID})
+
+		c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+	}
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes, used or not, with new ones
+func RegenerateRecoveryCodes(users store.UserStore, twoFactors store.TwoFactorStore, guard *lockout.Guard, audits store.AuditStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, _ := c.Get("user")
+		currentUser := user.(store.User)
+
+		var input struct {
+			Password string `json:"password"`
+			Username string `json:"username"`
+		}
+
+		if err := c.BindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+
+		if !confirmPassword(c, users, guard, audits, currentUser, input.Password, input.Username) {
+			return
+		}
+
+		t, err := twoFactors.GetTOTP(c.Request.Context(), currentUser.ID)
+		if errors.Is(err, store.ErrNotFound) || err == nil && !t.Enabled() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Two-factor authentication is not enabled"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create recovery codes"})
+			return
+		}
+
+		codes, hashes, err := auth.NewRecoveryCodes()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create recovery codes"})
+			return
+		}
+
+		err = twoFactors.ReplaceRecoveryCodes(c.Request.Context(), currentUser.ID, hashes)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create recovery codes"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+	}
+}
+
+// VerifyTwoFactor is the second step of Login for users with two-factor. It
+// exchanges the challenge token from the first step and a code from the
+// authenticator app, or a recovery code, for a session. Single sign-on leaves
+// the challenge token in a cookie instead of the body.
+func VerifyTwoFactor(users store.UserStore, sessions store.SessionStore, twoFactors store.TwoFactorStore, guard *lockout.Guard, audits store.AuditStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input struct {
+			ChallengeToken string `json:"challenge_token"`
+			Code           string `json:"code"`
+			RecoveryCode   string `json:"recovery_code"`
+		}
+
+		err := c.BindJSON(&input)
+		if input.ChallengeToken == "" {
+			input.ChallengeToken, _ = c.Cookie(challengeCookie)
+		}
+		if err != nil || input.ChallengeToken == "" || (input.Code == "") == (input.RecoveryCode == "") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Send the challenge token and either a code or a recovery code"})
+			return
+		}
+
+		userID, err := auth.ParseChallengeToken(input.ChallengeToken)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Login expired. Please log in again"})
+			return
+		}
+
+		user, err := users.GetUserByID(c.Request.Context(), userID)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Login expired. Please log in again"})
+				return
+			}
+
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+
+		// Codes are far easier to guess than passwords, so they share the
+		// failed attempt counters of the username
+		if !allowAttempt(c, guard, user.Username) {
+			return
+		}
+
+		t, err := twoFactors.GetTOTP(c.Request.Context(), user.ID)
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			releaseAttempt(c, guard, user.Username)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+		if !t.Enabled() {
+			releaseAttempt(c, guard, user.Username)
+			// Two-factor was turned off since the password was checked
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Login expired. Please log in again"})
+			return
+		}
+
+		if input.RecoveryCode != "" {
+			err = twoFactors.UseRecoveryCode(c.Request.Context(), user.ID, auth.HashRecoveryCode(input.RecoveryCode))
+		} else if step, ok := totp.Verify(t.Secret, input.Code, time.Now()); ok {
+			err = twoFactors.UseTOTPStep(c.Request.Context(), user.ID, step)
+		} else {
+			err = store.ErrNotFound
+		}
+
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) || errors.Is(err, store.ErrCodeReused) {
+				recordFailure(c, guard, audits, user.Username, user.ID)
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid code"})
+				return
+			}
+
+			releaseAttempt(c, guard, user.Username)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+		releaseAttempt(c, guard, user.Username)
+
+		if input.RecoveryCode != "" {
+			audit(c, audits, store.AuditEntry{Action: store.AuditRecoveryCodeUsed, UserID: &user.ID})
+		}
+		setCookie(c, challengeCookie, "", challengeCookiePath, -1)
+
+		completeLogin(c, sessions, guard, user)
+	}
+}
+
+// confirmPassword checks the signed-in user's password again before a
+// sensitive change. Users who only sign in through single sign-on have no
+// password and type their username instead. Wrong passwords count as failed
+// logins. On failure it responds and returns false.
+func confirmPassword(c *gin.Context, users store.UserStore, guard *lockout.Guard, audits store.AuditStore, currentUser store.User, password, username string) bool {
+	if !allowAttempt(c, guard, currentUser.Username) {
+		return false
+	}
+
+	result, err := users.GetUserByID(c.Request.Context(), currentUser.ID)
+	if err != nil {
+		releaseAttempt(c, guard, currentUser.Username)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return false
+	}
+
+	if result.Password == "" {
+		// Nothing secret to guess, so this is no failed login
+		releaseAttempt(c, guard, currentUser.Username)
+		if username != result.Username {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Type your username to confirm"})
+			return false
+		}
+		return true
+	}
+
+	if !comparePasswordAndHash(password, result.Password) {
+		recordFailure(c, guard, audits, currentUser.Username, currentUser.ID)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid password"})
+		return false
+	}
+	releaseAttempt(c, guard, currentUser.Username)
+	return true
+}
@@ -1,28 +1,28 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"log"
 	"net/http"
-	"os"
-	"strconv"
-	"strings"
-	"time"
+	"web-forum/internal/auth"
+	"web-forum/internal/lockout"
+	"web-forum/internal/passwords"
+	"web-forum/internal/store"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
-	"github.com/supabase-community/supabase-go"
-	"golang.org/x/crypto/bcrypt"
 )
 
-type User struct {
-	ID        int    `json:"id"`
-	Username  string `json:"username" binding:"required,min=3,max=50"`
-	Password  string `json:"password" binding:"required,min=8"`
-	CreatedAt string `json:"created_at"`
+type Credentials struct {
+	Username string `json:"username" binding:"required,min=3,max=50"`
+	// Password length is up to the password policy
+	Password string `json:"password" binding:"required"`
 }
 
-func SignUp(client *supabase.Client) gin.HandlerFunc {
+// SignUp creates a user whose password follows rules
+func SignUp(users store.UserStore, rules *passwords.Policy) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var user User
+		var user Credentials
 
 		// Check if username and password are valid
 		if err := c.BindJSON(&user); err != nil {
@@ -30,6 +30,15 @@ func SignUp(client *supabase.Client) gin.HandlerFunc {
 			return
 		}
 
+		if reservedUsername(user.Username) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "That username is reserved"})
+			return
+		}
+
+		if !checkPassword(c, rules, user.Password, user.Username) {
+			return
+		}
+
 		hashedPassword, err := hashPassword(user.Password)
 
 		if err != nil {
@@ -37,15 +46,10 @@ func SignUp(client *supabase.Client) gin.HandlerFunc {
 			return
 		}
 
-		data := map[string]interface{}{
-			"username": user.Username,
-			"password": hashedPassword,
-		}
-
-		_, _, err = client.From("users").Insert(data, false, "", "", "").Execute()
+		_, err = users.CreateUser(c.Request.Context(), user.Username, hashedPassword)
 
 		if err != nil {
-			if strings.Contains(err.Error(), "duplicate") || strings.Contains(err.Error(), "unique") {
+			if errors.Is(err, store.ErrConflict) {
 				c.JSON(http.StatusConflict, gin.H{"error": "Username already exists"})
 				return
 			}
@@ -59,9 +63,12 @@ func SignUp(client *supabase.Client) gin.HandlerFunc {
 	}
 }
 
-func Login(client *supabase.Client) gin.HandlerFunc {
+// Login checks a username and password and starts a session, or for users with
+// two-factor hands out a challenge for VerifyTwoFactor. Failed attempts are
+// counted by guard, which turns further attempts away for a while.
+func Login(users store.UserStore, sessions store.SessionStore, twoFactors store.TwoFactorStore, guard *lockout.Guard, audits store.AuditStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var input User
+		var input Credentials
 
 		// Validate input
 		if err := c.BindJSON(&input); err != nil {
@@ -69,69 +76,97 @@ func Login(client *supabase.Client) gin.HandlerFunc {
 			return
 		}
 
-		var user User
-		_, err := client.From("users").Select("id, username, password, created_at", "", false).Eq("username", input.Username).Single().ExecuteTo(&user)
+		if !allowAttempt(c, guard, input.Username) {
+			return
+		}
+
+		user, err := users.GetUserByUsername(c.Request.Context(), input.Username)
 
 		if err != nil {
-			if strings.Contains(err.Error(), "PGRST116") || strings.Contains(err.Error(), "0 rows") {
+			if errors.Is(err, store.ErrNotFound) {
+				// Unknown usernames count too, or guessing them would be free
+				recordFailure(c, guard, audits, input.Username, 0)
 				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 				return
 			}
 
+			releaseAttempt(c, guard, input.Username)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
 			return
 		}
 
 		// Check if password is correct
 		if !comparePasswordAndHash(input.Password, user.Password) {
+			recordFailure(c, guard, audits, input.Username, user.ID)
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 			return
 		}
+		releaseAttempt(c, guard, input.Username)
 
-		// Create a new token object, specifying signing method and the claims
-		// you would like it to contain.
-		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-			"subject": user.ID,
-			"exp":     time.Now().Add(time.Hour * 24 * 30).Unix(),
-		})
-
-		// Sign and get the complete encoded token as a string using the secret
-		tokenString, err := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+		// The password is at hand only now, so this is when an old hash is upgraded
+		rehashPassword(c.Request.Context(), users, user, input.Password)
 
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Authentication error"})
+		// With two-factor on, the password only earns a challenge token for
+		// VerifyTwoFactor. Failed attempts are kept until the code is right
+		// too, or knowing the password would reset the count on codes.
+		twoFactor, err := twoFactors.GetTOTP(c.Request.Context(), user.ID)
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
 			return
 		}
+		if twoFactor.Enabled() {
+			challengeToken, err := auth.IssueChallengeToken(user.ID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Authentication error"})
+				return
+			}
 
-		isProduction := os.Getenv("ENVIRONMENT") == "production"
-		if isProduction {
-			c.SetSameSite(http.SameSiteNoneMode)
-			c.SetCookie("Authorisation", tokenString, 3600*24*30, "/", ".forum.sahishnu.dev", true, true)
-		} else {
-			c.SetSameSite(http.SameSiteLaxMode)
-			c.SetCookie("Authorisation", tokenString, 3600*24*30, "/", "", false, true)
+			c.JSON(http.StatusOK, gin.H{
+				"message":             "Enter the code from your authenticator app",
+				"two_factor_required": true,
+				"challenge_token":     challengeToken,
+			})
+			return
 		}
 
-		// Success, send user data to frontend
-		c.JSON(http.StatusOK, gin.H{
-			"message": "Login successful",
-			"user": gin.H{
-				"id":       user.ID,
-				"username": user.Username,
-			},
-		})
+		completeLogin(c, sessions, guard, user)
+	}
+}
+
+// completeLogin clears the failed attempts of a user who proved who they are,
+// starts their session and responds with the user
+func completeLogin(c *gin.Context, sessions store.SessionStore, guard *lockout.Guard, user store.User) {
+	if err := guard.Succeed(c.Request.Context(), user.Username); err != nil {
+		log.Printf("Error clearing failed login attempts: %v", err)
+	}
+
+	if !startSession(c, sessions, user) {
+		return
 	}
+
+	// Success, send user data to frontend
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Login successful",
+		"user": gin.H{
+			"id":       user.ID,
+			"username": user.Username,
+			"role":     user.Role,
+		},
+	})
 }
 
-func ResetPassword(client *supabase.Client) gin.HandlerFunc {
+// ResetPassword changes the password, to one that follows rules, and logs
+// every other session out. Wrong old passwords count as failed logins, so a
+// stolen session cannot be used to guess it.
+func ResetPassword(users store.UserStore, sessions store.SessionStore, rules *passwords.Policy, guard *lockout.Guard, audits store.AuditStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		user, _ := c.Get("user")
-		currentUser := user.(User)
+		currentUser := user.(store.User)
 		userID := currentUser.ID
 
 		var input struct {
-			Password    string `json:"password" binding:"required,min=8"`
-			NewPassword string `json:"new_password" binding:"required,min=8"`
+			Password    string `json:"password" binding:"required"`
+			NewPassword string `json:"new_password" binding:"required"`
 		}
 
 		if err := c.BindJSON(&input); err != nil {
@@ -144,16 +179,21 @@ func ResetPassword(client *supabase.Client) gin.HandlerFunc {
 			return
 		}
 
-		// Check if password is correct
-		// If yes continue, else, show error saying wrong password
-		var result struct {
-			Password string `json:"password"`
+		if !checkPassword(c, rules, input.NewPassword, currentUser.Username) {
+			return
+		}
+
+		if !allowAttempt(c, guard, currentUser.Username) {
+			return
 		}
 
-		_, err := client.From("users").Select("password", "", false).Eq("id", strconv.Itoa(userID)).Single().ExecuteTo(&result)
+		// Check if password is correct
+		// If yes continue, else, show error saying wrong password
+		result, err := users.GetUserByID(c.Request.Context(), userID)
 
 		if err != nil {
-			if strings.Contains(err.Error(), "PGRST116") || strings.Contains(err.Error(), "0 rows") {
+			releaseAttempt(c, guard, currentUser.Username)
+			if errors.Is(err, store.ErrNotFound) {
 				c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 				return
 			}
@@ -163,9 +203,11 @@ func ResetPassword(client *supabase.Client) gin.HandlerFunc {
 		}
 
 		if !comparePasswordAndHash(input.Password, result.Password) {
+			recordFailure(c, guard, audits, currentUser.Username, userID)
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid old password"})
 			return
 		}
+		releaseAttempt(c, guard, currentUser.Username)
 
 		hashedNewPassword, err := hashPassword(input.NewPassword)
 
@@ -174,17 +216,18 @@ func ResetPassword(client *supabase.Client) gin.HandlerFunc {
 			return
 		}
 
-		data := map[string]interface{}{
-			"password": hashedNewPassword,
-		}
-
-		_, _, err = client.From("users").Update(data, "", "").Eq("id", strconv.Itoa(userID)).Execute()
+		err = users.UpdatePassword(c.Request.Context(), userID, hashedNewPassword)
 
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change password"})
 			return
 		}
 
+		err = sessions.RevokeUserSessions(c.Request.Context(), userID, currentSessionID(c))
+		if err != nil {
+			log.Printf("Error revoking sessions after password change: %v", err)
+		}
+
 		c.JSON(http.StatusOK, gin.H{"message": "Password changed successfully"})
 
 	}
@@ -192,37 +235,75 @@ func ResetPassword(client *supabase.Client) gin.HandlerFunc {
 
 func Validate(c *gin.Context) {
 	user, _ := c.Get("user")
-	currentUser := user.(User)
+	currentUser := user.(store.User)
 
+	// Only the user themselves ever sees their email address
 	c.JSON(http.StatusOK, gin.H{
 		"user": gin.H{
-			"id":       currentUser.ID,
-			"username": currentUser.Username,
+			"id":             currentUser.ID,
+			"username":       currentUser.Username,
+			"role":           currentUser.Role,
+			"display_name":   currentUser.DisplayName,
+			"avatar_url":     currentUser.AvatarURL,
+			"email":          currentUser.Email,
+			"email_verified": currentUser.EmailVerified(),
 		},
 	})
 }
 
-func LogOut(c *gin.Context) {
-	isProduction := os.Getenv("ENVIRONMENT") == "production"
-	if isProduction {
-		c.SetSameSite(http.SameSiteNoneMode)
-		c.SetCookie("Authorisation", "", 3600*24*30, "/", ".forum.sahishnu.dev", true, true)
-	} else {
-		c.SetSameSite(http.SameSiteLaxMode)
-		c.SetCookie("Authorisation", "", 3600*24*30, "/", "", false, true)
-	}
+// LogOut revokes the session of the refresh token cookie, if any, and clears the cookies
+func LogOut(sessions store.SessionStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if refreshToken, err := c.Cookie(refreshCookie); err == nil && refreshToken != "" {
+			session, err := sessions.GetSessionByToken(c.Request.Context(), auth.HashToken(refreshToken))
+			if err == nil {
+				err = sessions.RevokeSession(c.Request.Context(), session.ID)
+			}
+			if err != nil && !errors.Is(err, store.ErrNotFound) {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Log Out failed"})
+				return
+			}
+		}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Logged out successfully",
-	})
+		clearSessionCookies(c)
+
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Logged out successfully",
+		})
+	}
 }
 
+// hashPassword hashes a new password with the configured hasher
 func hashPassword(password string) (string, error) {
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), 10)
-	return string(hash), err
+	hasher, err := passwords.DefaultHasher()
+	if err != nil {
+		return "", err
+	}
+	return hasher.Hash(password)
 }
 
 func comparePasswordAndHash(password string, hash string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	return err == nil
+	hasher, err := passwords.DefaultHasher()
+	if err != nil {
+		return false
+	}
+	return hasher.Verify(password, hash)
+}
+
+// rehashPassword hashes the password of a user who just proved it again if
+// their stored hash uses an outdated algorithm or parameters. Failing to is
+// not worth failing the login over; it is tried again next time.
+func rehashPassword(ctx context.Context, users store.UserStore, user store.User, password string) {
+	hasher, err := passwords.DefaultHasher()
+	if err != nil || !hasher.NeedsRehash(user.Password) {
+		return
+	}
+
+	hash, err := hasher.Hash(password)
+	if err == nil {
+		err = users.UpdatePassword(ctx, user.ID, hash)
+	}
+	if err != nil {
+		log.Printf("Error rehashing password of user %d: %v", user.ID, err)
+	}
 }
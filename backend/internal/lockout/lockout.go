@@ -0,0 +1,236 @@
+// Package lockout slows down and then stops password guessing. Failed
+// attempts are counted per username and per IP address; past a few free
+// attempts every failure makes the next attempt wait twice as long, and past
+// a threshold the key is locked out for a while.
+package lockout
+
+import (
+	"context"
+	"time"
+)
+
+// Counter is the failed attempts recorded against one key
+type Counter struct {
+	Failures    int
+	LastFailure time.Time
+	// Pending attempts are under way. They count as failures, the latest
+	// made at LastAttempt, until they turn out to have failed or not.
+	Pending     int
+	LastAttempt time.Time
+	// BlockedUntil is when the key may try again, zero if it may now
+	BlockedUntil time.Time
+	// ExpiresAt is when the counter is forgotten. A store may drop it then.
+	ExpiresAt time.Time
+}
+
+// Store keeps the counters. Counters are short-lived, so the default
+// MemoryStore is enough for a single instance; instances behind a load
+// balancer would share a store such as Redis instead.
+type Store interface {
+	// Get returns the counter of key, a zero Counter if there is none or it expired
+	Get(ctx context.Context, key string) (Counter, error)
+	// Update replaces the counter of key with fn applied to it, atomically
+	// with respect to other updates of the same key
+	Update(ctx context.Context, key string, fn func(Counter) Counter) (Counter, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// Limits is the policy for one kind of key
+type Limits struct {
+	// FreeAttempts failures go by without any delay
+	FreeAttempts int
+	// BaseDelay is the wait after the first failure beyond FreeAttempts. It
+	// doubles with every further failure, up to MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// LockoutAfter failures lock the key out for LockoutDuration
+	LockoutAfter    int
+	LockoutDuration time.Duration
+	// ResetAfter without a failure, the failures are forgotten
+	ResetAfter time.Duration
+}
+
+var (
+	UsernameLimits = Limits{
+		FreeAttempts:    3,
+		BaseDelay:       time.Second,
+		MaxDelay:        time.Minute,
+		LockoutAfter:    10,
+		LockoutDuration: 15 * time.Minute,
+		ResetAfter:      time.Hour,
+	}
+	// One address can be a whole office behind NAT, so it gets more room
+	IPLimits = Limits{
+		FreeAttempts:    10,
+		BaseDelay:       time.Second,
+		MaxDelay:        time.Minute,
+		LockoutAfter:    50,
+		LockoutDuration: 15 * time.Minute,
+		ResetAfter:      time.Hour,
+	}
+)
+
+// Lockout is a key a failed attempt locked out
+type Lockout struct {
+	Key   string
+	Until time.Time
+}
+
+// Guard tracks failed password attempts
+type Guard struct {
+	store     Store
+	usernames Limits
+	ips       Limits
+	now       func() time.Time
+}
+
+func New(store Store) *Guard {
+	return &Guard{store: store, usernames: UsernameLimits, ips: IPLimits, now: time.Now}
+}
+
+func UsernameKey(username string) string {
+	return "user:" + username
+}
+
+func IPKey(ip string) string {
+	return "ip:" + ip
+}
+
+// Attempt reserves an attempt for username from ip, or returns how long it
+// has to wait if it may not go ahead. A reserved attempt counts as failed at
+// once, so attempts made at the same time see each other; the caller settles
+// it with Fail if it did fail and Release if it did not.
+func (g *Guard) Attempt(ctx context.Context, username, ip string) (time.Duration, error) {
+	now := g.now()
+
+	var wait time.Duration
+	var reserved []guardKey
+	for _, k := range g.keys(username, ip) {
+		blocked := false
+		_, err := g.store.Update(ctx, k.key, func(counter Counter) Counter {
+			if d := counter.BlockedUntil.Sub(now); d > 0 {
+				wait, blocked = max(wait, d), true
+				return counter
+			}
+			counter.Pending++
+			counter.LastAttempt = now
+			return k.limits.block(counter)
+		})
+		if err != nil {
+			return 0, err
+		}
+		if !blocked {
+			reserved = append(reserved, k)
+		}
+	}
+
+	// A key that must wait turns the attempt away, and the other keys
+	// should not be charged for it
+	if wait > 0 {
+		for _, k := range reserved {
+			if err := g.release(ctx, k); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return wait, nil
+}
+
+// Fail settles an attempt reserved with Attempt as failed and returns the
+// lockouts it triggered
+func (g *Guard) Fail(ctx context.Context, username, ip string) ([]Lockout, error) {
+	now := g.now()
+
+	var lockouts []Lockout
+	for _, k := range g.keys(username, ip) {
+		counter, err := g.store.Update(ctx, k.key, func(counter Counter) Counter {
+			counter = settle(counter)
+			counter.Failures++
+			counter.LastFailure = now
+			return k.limits.block(counter)
+		})
+		if err != nil {
+			return lockouts, err
+		}
+
+		if counter.Failures >= k.limits.LockoutAfter {
+			lockouts = append(lockouts, Lockout{Key: k.key, Until: counter.BlockedUntil})
+		}
+	}
+	return lockouts, nil
+}
+
+// Release settles an attempt reserved with Attempt as not failed, taking
+// back the failure it was counted as
+func (g *Guard) Release(ctx context.Context, username, ip string) error {
+	for _, k := range g.keys(username, ip) {
+		if err := g.release(ctx, k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Succeed forgets the failures of username. Those of the IP address are kept,
+// or an attacker could clear them by signing in to an account of their own.
+func (g *Guard) Succeed(ctx context.Context, username string) error {
+	return g.store.Delete(ctx, UsernameKey(username))
+}
+
+// guardKey is a key with the limits that apply to it
+type guardKey struct {
+	key    string
+	limits Limits
+}
+
+func (g *Guard) keys(username, ip string) []guardKey {
+	return []guardKey{{UsernameKey(username), g.usernames}, {IPKey(ip), g.ips}}
+}
+
+// release takes back a pending attempt of k, leaving the failures as they
+// were before it. Without one, say because Succeed cleared the counter
+// meanwhile, there is nothing to take back.
+func (g *Guard) release(ctx context.Context, k guardKey) error {
+	_, err := g.store.Update(ctx, k.key, func(counter Counter) Counter {
+		return k.limits.block(settle(counter))
+	})
+	return err
+}
+
+// settle takes one pending attempt off counter
+func settle(counter Counter) Counter {
+	if counter.Pending > 0 {
+		counter.Pending--
+	}
+	if counter.Pending == 0 {
+		counter.LastAttempt = time.Time{}
+	}
+	return counter
+}
+
+// block works out from the failures and pending attempts of counter how long
+// after the last one it has to wait, and when it is forgotten
+func (l Limits) block(counter Counter) Counter {
+	failures, now := counter.Failures+counter.Pending, counter.LastFailure
+	if counter.Pending > 0 && counter.LastAttempt.After(now) {
+		now = counter.LastAttempt
+	}
+	counter.BlockedUntil = time.Time{}
+
+	if failures >= l.LockoutAfter {
+		counter.BlockedUntil = now.Add(l.LockoutDuration)
+	} else if excess := failures - l.FreeAttempts; excess > 0 {
+		delay := l.MaxDelay
+		// Past 2^20 the delay is far beyond any sensible MaxDelay anyway
+		if excess <= 20 && l.BaseDelay<<(excess-1) < l.MaxDelay {
+			delay = l.BaseDelay << (excess - 1)
+		}
+		counter.BlockedUntil = now.Add(delay)
+	}
+
+	counter.ExpiresAt = now.Add(l.ResetAfter)
+	if counter.BlockedUntil.After(counter.ExpiresAt) {
+		counter.ExpiresAt = counter.BlockedUntil
+	}
+	return counter
+}
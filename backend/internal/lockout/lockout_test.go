@@ -0,0 +1,321 @@
+package lockout
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// clock is a fake time source the tests move by hand
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// newGuard returns a guard and its store running on a fake clock
+func newGuard() (*Guard, *MemoryStore, *clock) {
+	c := &clock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	s := NewMemoryStore()
+	s.now = c.Now
+	g := New(s)
+	g.now = c.Now
+	return g, s, c
+}
+
+// step is one thing that happens to the guard, in order
+type step struct {
+	advance time.Duration
+	// op is "fail" for an attempt that fails, "release" for one that does
+	// not, "succeed" for a successful login and "attempt" for one that is
+	// left pending
+	op   string
+	wait time.Duration
+}
+
+func TestGuard(t *testing.T) {
+	// UsernameLimits apply, since the address has far more room
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{"free attempts then doubling waits", []step{
+			{op: "fail"},
+			{op: "fail"},
+			{op: "fail"},
+			{op: "fail"},
+			{op: "fail", wait: time.Second},
+			{advance: time.Second, op: "fail"},
+			{op: "fail", wait: 2 * time.Second},
+			{advance: 2 * time.Second, op: "fail"},
+			{advance: 3 * time.Second, op: "fail", wait: time.Second},
+			{advance: time.Second, op: "fail"},
+			{op: "fail", wait: 8 * time.Second},
+		}},
+		{"a wait counts from the last failure", []step{
+			{op: "fail"},
+			{op: "fail"},
+			{op: "fail"},
+			{op: "fail"},
+			{advance: 500 * time.Millisecond, op: "fail", wait: 500 * time.Millisecond},
+			{advance: 500 * time.Millisecond, op: "release"},
+		}},
+		{"releases take nothing", []step{
+			{op: "release"},
+			{op: "release"},
+			{op: "release"},
+			{op: "release"},
+			{op: "release"},
+			{op: "release"},
+			{op: "fail"},
+		}},
+		{"a release keeps earlier failures", []step{
+			{op: "fail"},
+			{op: "fail"},
+			{op: "fail"},
+			{op: "release"},
+			{op: "fail"},
+			{op: "release", wait: time.Second},
+		}},
+		{"success forgets the failures", []step{
+			{op: "fail"},
+			{op: "fail"},
+			{op: "fail"},
+			{op: "fail"},
+			{advance: time.Second, op: "succeed"},
+			{op: "fail"},
+			{op: "fail"},
+		}},
+		{"failures are forgotten after ResetAfter", []step{
+			{op: "fail"},
+			{op: "fail"},
+			{op: "fail"},
+			{op: "fail"},
+			{advance: UsernameLimits.ResetAfter, op: "fail"},
+			{op: "fail"},
+		}},
+		{"a pending attempt blocks the next", []step{
+			{op: "fail"},
+			{op: "fail"},
+			{op: "fail"},
+			{op: "attempt"},
+			{op: "fail", wait: time.Second},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, _, c := newGuard()
+			ctx := context.Background()
+
+			for i, s := range tt.steps {
+				c.Advance(s.advance)
+
+				if s.op == "succeed" {
+					if err := g.Succeed(ctx, "alice"); err != nil {
+						t.Fatal(err)
+					}
+					continue
+				}
+
+				wait, err := g.Attempt(ctx, "alice", "192.0.2.1")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if wait != s.wait {
+					t.Fatalf("step %d: wait %v, want %v", i, wait, s.wait)
+				}
+				if wait > 0 {
+					continue
+				}
+
+				switch s.op {
+				case "fail":
+					_, err = g.Fail(ctx, "alice", "192.0.2.1")
+				case "release":
+					err = g.Release(ctx, "alice", "192.0.2.1")
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestGuardLockout(t *testing.T) {
+	g, s, c := newGuard()
+	ctx := context.Background()
+
+	var lockouts []Lockout
+	for i := 0; i < UsernameLimits.LockoutAfter; i++ {
+		// Wait out each delay, leaving only the lockout to stop the attempts
+		counter, _ := s.Get(ctx, UsernameKey("alice"))
+		c.Advance(max(counter.BlockedUntil.Sub(c.Now()), 0))
+
+		wait, err := g.Attempt(ctx, "alice", "192.0.2.1")
+		if err != nil || wait != 0 {
+			t.Fatalf("attempt %d: wait %v, %v", i+1, wait, err)
+		}
+		lockouts, err = g.Fail(ctx, "alice", "192.0.2.1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(lockouts) > 0 && i+1 < UsernameLimits.LockoutAfter {
+			t.Fatalf("locked out after %d failures", i+1)
+		}
+	}
+
+	until := c.Now().Add(UsernameLimits.LockoutDuration)
+	if len(lockouts) != 1 || lockouts[0] != (Lockout{Key: UsernameKey("alice"), Until: until}) {
+		t.Fatalf("lockouts = %+v, want alice until %s", lockouts, until)
+	}
+
+	// Locked out until the last second, and free after
+	c.Advance(UsernameLimits.LockoutDuration - time.Second)
+	if wait, err := g.Attempt(ctx, "alice", "192.0.2.2"); err != nil || wait != time.Second {
+		t.Fatalf("attempt a second before the lockout ends: wait %v, %v", wait, err)
+	}
+	c.Advance(time.Second)
+	if wait, err := g.Attempt(ctx, "alice", "192.0.2.2"); err != nil || wait != 0 {
+		t.Fatalf("attempt when the lockout ends: wait %v, %v", wait, err)
+	}
+	if err := g.Release(ctx, "alice", "192.0.2.2"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The released attempt must not have started the lockout over. The lock
+	// ran out but the failures have not, so one more locks out again.
+	if wait, err := g.Attempt(ctx, "alice", "192.0.2.2"); err != nil || wait != 0 {
+		t.Fatalf("attempt after a released one: wait %v, %v", wait, err)
+	}
+	lockouts, err := g.Fail(ctx, "alice", "192.0.2.2")
+	if err != nil || len(lockouts) != 1 {
+		t.Fatalf("failure after the lockout: lockouts %+v, %v", lockouts, err)
+	}
+
+	// The counter is dropped once the lockout is over and ResetAfter has passed
+	c.Advance(UsernameLimits.LockoutDuration + UsernameLimits.ResetAfter)
+	if counter, err := s.Get(ctx, UsernameKey("alice")); err != nil || counter != (Counter{}) {
+		t.Errorf("counter after it expired = %+v, %v", counter, err)
+	}
+}
+
+func TestGuardConcurrentAttempts(t *testing.T) {
+	g, s, _ := newGuard()
+	ctx := context.Background()
+
+	// All at the same instant, as a burst of guesses would be. Only the
+	// attempts reserved before the first wait may go ahead.
+	const attempts = 40
+	var wg sync.WaitGroup
+	allowed := make(chan bool, attempts)
+	for range attempts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			wait, err := g.Attempt(ctx, "alice", "192.0.2.1")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			allowed <- wait == 0
+		}()
+	}
+	wg.Wait()
+	close(allowed)
+
+	count := 0
+	for ok := range allowed {
+		if ok {
+			count++
+		}
+	}
+	if want := UsernameLimits.FreeAttempts + 1; count != want {
+		t.Errorf("%d attempts went ahead, want %d", count, want)
+	}
+
+	counter, err := s.Get(ctx, UsernameKey("alice"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counter.Failures != 0 || counter.Pending != count {
+		t.Errorf("counter = %+v, want %d attempts pending", counter, count)
+	}
+
+	// Released, they leave nothing behind, and turned away ones never counted
+	for range count {
+		if err := g.Release(ctx, "alice", "192.0.2.1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, key := range []string{UsernameKey("alice"), IPKey("192.0.2.1")} {
+		counter, err := s.Get(ctx, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if counter.Failures != 0 || counter.Pending != 0 || !counter.BlockedUntil.IsZero() {
+			t.Errorf("%s after every release = %+v, want no failures", key, counter)
+		}
+	}
+}
+
+func TestGuardBlockedKeyChargesNoOther(t *testing.T) {
+	g, s, _ := newGuard()
+	ctx := context.Background()
+
+	for range UsernameLimits.FreeAttempts + 1 {
+		if _, err := g.Attempt(ctx, "alice", "192.0.2.1"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := g.Fail(ctx, "alice", "192.0.2.1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// alice must wait, so the new address is not charged for trying
+	wait, err := g.Attempt(ctx, "alice", "192.0.2.2")
+	if err != nil || wait == 0 {
+		t.Fatalf("attempt while blocked: wait %v, %v", wait, err)
+	}
+	if counter, _ := s.Get(ctx, IPKey("192.0.2.2")); counter.Failures != 0 || counter.Pending != 0 {
+		t.Errorf("address counter = %+v, want nothing charged", counter)
+	}
+}
+
+func TestGuardReleaseAfterSucceed(t *testing.T) {
+	g, s, _ := newGuard()
+	ctx := context.Background()
+
+	// A successful login elsewhere clears alice while an attempt is pending
+	if _, err := g.Attempt(ctx, "alice", "192.0.2.1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Succeed(ctx, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Release(ctx, "alice", "192.0.2.1"); err != nil {
+		t.Fatal(err)
+	}
+
+	counter, err := s.Get(ctx, UsernameKey("alice"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counter.Failures != 0 || counter.Pending != 0 {
+		t.Errorf("counter = %+v, want the release to take nothing back", counter)
+	}
+}
@@ -0,0 +1,69 @@
+package lockout
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often MemoryStore drops expired counters, so attempts
+// from many addresses cannot grow it without bound
+const sweepInterval = time.Minute
+
+// MemoryStore keeps counters in process memory. They are lost on restart,
+// which only means the failures are forgotten early.
+type MemoryStore struct {
+	mu        sync.Mutex
+	counters  map[string]Counter
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{counters: make(map[string]Counter), now: time.Now}
+}
+
+func (s *MemoryStore) Get(ctx context.Context, key string) (Counter, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.get(key, s.now()), nil
+}
+
+func (s *MemoryStore) Update(ctx context.Context, key string, fn func(Counter) Counter) (Counter, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.Sub(s.lastSweep) > sweepInterval {
+		for k, counter := range s.counters {
+			if !now.Before(counter.ExpiresAt) {
+				delete(s.counters, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	counter := fn(s.get(key, now))
+	s.counters[key] = counter
+	return counter, nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.counters, key)
+	return nil
+}
+
+// get returns the counter of key unless it expired. Callers must hold mu.
+func (s *MemoryStore) get(key string, now time.Time) Counter {
+	counter, ok := s.counters[key]
+	if !ok || !now.Before(counter.ExpiresAt) {
+		return Counter{}
+	}
+	return counter
+}
@@ -0,0 +1,67 @@
+// Package mailer sends the forum's emails: address verification and
+// password reset links.
+package mailer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// Message is a plain text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers messages. Send returns once the message is handed off,
+// which is no promise it arrives.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+var errHeaderInjection = errors.New("mailer: line break in recipient or subject")
+
+// validate turns away messages that would smuggle extra headers in
+func (m Message) validate() error {
+	if strings.ContainsAny(m.To, "\r\n") || strings.ContainsAny(m.Subject, "\r\n") {
+		return errHeaderInjection
+	}
+	return nil
+}
+
+// FromEnv builds the mailer named by MAILER:
+//
+//   - "smtp" sends through SMTP_HOST, see NewSMTPFromEnv
+//   - "log", the default, writes messages to MAIL_FILE, or the log if unset,
+//     for local development
+func FromEnv() (Mailer, error) {
+	switch kind := os.Getenv("MAILER"); kind {
+	case "smtp":
+		return NewSMTPFromEnv()
+
+	case "", "log":
+		if os.Getenv("ENVIRONMENT") == "production" {
+			// Reset links in the log are as good as passwords
+			log.Println("Warning: MAILER is not smtp, emails will be written to the log instead of sent")
+		}
+
+		path := os.Getenv("MAIL_FILE")
+		if path == "" {
+			return NewWriter(log.Writer()), nil
+		}
+
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+		if err != nil {
+			return nil, fmt.Errorf("mailer: open MAIL_FILE: %w", err)
+		}
+		return NewWriter(f), nil
+
+	default:
+		return nil, fmt.Errorf("mailer: unknown MAILER %q, expected smtp or log", kind)
+	}
+}
@@ -0,0 +1,85 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"time"
+)
+
+// SMTP sends messages through a mail server. net/smtp upgrades to TLS when
+// the server offers STARTTLS, and refuses to send credentials without it
+// unless the server is localhost.
+type SMTP struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+var _ Mailer = (*SMTP)(nil)
+
+// NewSMTP sends from the address from through the server at addr, "host:port".
+// auth may be nil for servers that do not want a login.
+func NewSMTP(addr string, auth smtp.Auth, from string) *SMTP {
+	return &SMTP{addr: addr, auth: auth, from: from}
+}
+
+// NewSMTPFromEnv reads SMTP_HOST, SMTP_PORT (default 587), SMTP_USERNAME,
+// SMTP_PASSWORD and MAIL_FROM. Without SMTP_USERNAME it does not log in.
+func NewSMTPFromEnv() (*SMTP, error) {
+	host := os.Getenv("SMTP_HOST")
+	from := os.Getenv("MAIL_FROM")
+	if host == "" || from == "" {
+		return nil, errors.New("mailer: SMTP_HOST and MAIL_FROM must be set for MAILER=smtp")
+	}
+
+	port := os.Getenv("SMTP_PORT")
+	if port == "" {
+		port = "587"
+	}
+
+	var auth smtp.Auth
+	if username := os.Getenv("SMTP_USERNAME"); username != "" {
+		auth = smtp.PlainAuth("", username, os.Getenv("SMTP_PASSWORD"), host)
+	}
+
+	return NewSMTP(net.JoinHostPort(host, port), auth, from), nil
+}
+
+func (m *SMTP) Send(ctx context.Context, msg Message) error {
+	if err := msg.validate(); err != nil {
+		return err
+	}
+
+	var body bytes.Buffer
+	fmt.Fprintf(&body, "From: %s\r\n", m.from)
+	fmt.Fprintf(&body, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&body, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&body, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	body.WriteString("MIME-Version: 1.0\r\n")
+	body.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	body.WriteString("\r\n")
+	body.WriteString(msg.Body)
+
+	// smtp.SendMail takes no context, so a request that gives up does not
+	// stop it. Run it aside and stop waiting instead.
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(m.addr, m.auth, m.from, []string{msg.To}, body.Bytes())
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("mailer: send: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,35 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Writer writes messages to w instead of sending them, so links can be
+// followed locally without a mail server
+type Writer struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+var _ Mailer = (*Writer)(nil)
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+func (m *Writer) Send(ctx context.Context, msg Message) error {
+	if err := msg.validate(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, err := fmt.Fprintf(m.w, "----- %s\nTo: %s\nSubject: %s\n\n%s\n-----\n",
+		time.Now().UTC().Format(time.RFC3339), msg.To, msg.Subject, msg.Body)
+	return err
+}
@@ -1,76 +1,143 @@
 package middleware
 
 import (
+	"log"
 	"net/http"
-	"os"
-	"strconv"
+	"strings"
 	"time"
-	"web-forum/internal/database"
-	"web-forum/internal/handlers"
+	"web-forum/internal/auth"
+	"web-forum/internal/policy"
+	"web-forum/internal/store"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
-func RequireAuthentication(c *gin.Context) {
-	// Get the cookie off request
-	tokenString, err := c.Cookie("Authorisation")
+// touchInterval is how stale a personal access token's last use may get
+// before it is written again, so busy bots do not write on every request
+const touchInterval = time.Minute
 
-	if err != nil {
-		c.AbortWithStatus(http.StatusUnauthorized)
-		return
-	}
-
-	// Decode / Validate it
-
-	// Parse takes the token string and a function for looking up the key. The latter is especially
-	// useful if you use multiple keys for your application.  The standard is to use 'kid' in the
-	// head of the token to identify which key to use, but the parsed token (head and claims) is provided
-	// to the callback, providing flexibility.
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (any, error) {
-		return []byte(os.Getenv("JWT_SECRET")), nil
-	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
-
-	if err != nil {
-		c.AbortWithStatus(http.StatusUnauthorized)
-		return
-	}
-
-	if claims, ok := token.Claims.(jwt.MapClaims); ok {
-		// Check the expiry
-		if float64(time.Now().Unix()) > claims["exp"].(float64) {
-			c.AbortWithStatus(http.StatusUnauthorized)
-			return
-		}
+// AuthStore is what RequireAuthentication needs from the store
+type AuthStore interface {
+	store.UserStore
+	store.SessionStore
+	store.PersonalTokenStore
+	store.ModeratorStore
+}
 
-		sub, ok := claims["subject"].(float64)
+// RequireAuthentication attaches the signed-in user to the request as "user"
+// and what they may do as "permissions". The token comes from an
+// "Authorization: Bearer" header or else the "Authorisation" cookie, and is
+// either an access token, whose session is attached as "session_id", or a
+// personal access token, attached as "personal_token".
+func RequireAuthentication(db AuthStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString, ok := requestToken(c)
 		if !ok {
 			c.AbortWithStatus(http.StatusUnauthorized)
 			return
 		}
 
-		userID := int(sub)
+		var userID int
+		if auth.IsPersonalToken(tokenString) {
+			token, err := db.GetPersonalTokenByHash(c.Request.Context(), auth.HashToken(tokenString))
+			if err != nil || !token.Active(time.Now()) {
+				c.AbortWithStatus(http.StatusUnauthorized)
+				return
+			}
+
+			if !token.Scope.CanWrite() && !isReadOnly(c.Request.Method) {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "This token is read-only"})
+				return
+			}
+
+			if token.LastUsedAt == nil || time.Since(*token.LastUsedAt) > touchInterval {
+				if err := db.TouchPersonalToken(c.Request.Context(), token.ID); err != nil {
+					log.Printf("Error recording personal access token use: %v", err)
+				}
+			}
+
+			userID = token.UserID
+			c.Set("personal_token", token)
+		} else {
+			// Decode / Validate it
+			claims, err := auth.ParseAccessToken(tokenString)
+			if err != nil {
+				c.AbortWithStatus(http.StatusUnauthorized)
+				return
+			}
+
+			// A revoked session locks its access tokens out straight away rather
+			// than when they expire
+			session, err := db.GetSession(c.Request.Context(), claims.SessionID)
+			if err != nil || session.UserID != claims.UserID || !session.Active(time.Now()) {
+				c.AbortWithStatus(http.StatusUnauthorized)
+				return
+			}
+
+			userID = claims.UserID
+			c.Set("session_id", session.ID)
+		}
 
 		// Find the user with the token subject
-		var user handlers.User
-		_, err := database.GetClient().From("users").Select("id, username, password, created_at", "", false).Eq("id", strconv.Itoa(userID)).Single().ExecuteTo(&user)
+		user, err := db.GetUserByID(c.Request.Context(), userID)
 		if err != nil {
 			c.AbortWithStatus(http.StatusUnauthorized)
 			return
 		}
 
-		if user.ID == 0 {
+		// Deleted accounts keep their row but can no longer sign in
+		if user.ID == 0 || user.Deleted() {
 			c.AbortWithStatus(http.StatusUnauthorized)
 			return
 		}
 
+		permissions, err := policy.Load(c.Request.Context(), db, user)
+		if err != nil {
+			c.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+
 		// Attach to request
 		c.Set("user", user)
+		c.Set("permissions", permissions)
 
 		// Continue
 		c.Next()
+	}
+}
+
+// RequireSession turns away requests authenticated with a personal access
+// token. It guards the routes that manage the account itself, so a leaked
+// token cannot be used to mint more tokens or lock the owner out.
+func RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := c.Get("session_id"); !ok {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Sign in with your password to do this"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// requestToken returns the bearer token of the request, falling back to the
+// cookie. A malformed Authorization header is not ignored in favour of the cookie.
+func requestToken(c *gin.Context) (string, bool) {
+	if header := c.GetHeader("Authorization"); header != "" {
+		scheme, token, ok := strings.Cut(header, " ")
+		token = strings.TrimSpace(token)
+		if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+			return "", false
+		}
+		return token, true
+	}
 
-	} else {
-		c.AbortWithStatus(http.StatusUnauthorized)
+	token, err := c.Cookie("Authorisation")
+	if err != nil || token == "" {
+		return "", false
 	}
+	return token, true
+}
+
+func isReadOnly(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
 }
@@ -0,0 +1,62 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+var ErrInvalidFlow = errors.New("oidc: invalid login flow")
+
+// Flow is the secret state of one login, kept by the browser between sending
+// the user to the provider and the provider sending them back. State ties the
+// callback to the browser that started it, Nonce ties the ID token to it, and
+// Verifier is the PKCE code verifier, without which the code is useless.
+type Flow struct {
+	State    string
+	Nonce    string
+	Verifier string
+}
+
+// NewFlow returns a flow with fresh random values
+func NewFlow() (Flow, error) {
+	var values [3]string
+	for i := range values {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			return Flow{}, err
+		}
+		values[i] = base64.RawURLEncoding.EncodeToString(b)
+	}
+	return Flow{State: values[0], Nonce: values[1], Verifier: values[2]}, nil
+}
+
+// String encodes the flow for a cookie. The values are base64url, so the dots
+// between them are unambiguous.
+func (f Flow) String() string {
+	return f.State + "." + f.Nonce + "." + f.Verifier
+}
+
+// ParseFlow decodes a flow encoded by String
+func ParseFlow(s string) (Flow, error) {
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return Flow{}, ErrInvalidFlow
+	}
+	return Flow{State: parts[0], Nonce: parts[1], Verifier: parts[2]}, nil
+}
+
+// CheckState reports whether state, as sent back by the provider, is the
+// flow's, in constant time
+func (f Flow) CheckState(state string) bool {
+	return subtle.ConstantTimeCompare([]byte(f.State), []byte(state)) == 1
+}
+
+// Challenge is the S256 PKCE code challenge of the verifier
+func (f Flow) Challenge() string {
+	sum := sha256.Sum256([]byte(f.Verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,166 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// jwksRefreshInterval limits how often an unknown kid makes the provider's
+// keys be fetched again, so tokens with made-up kids cannot flood it
+const jwksRefreshInterval = time.Minute
+
+// jwk is the part of a JSON Web Key needed to verify signatures
+type jwk struct {
+	KeyType string `json:"kty"`
+	ID      string `json:"kid"`
+	Use     string `json:"use"`
+	Curve   string `json:"crv"`
+	X       string `json:"x"`
+	Y       string `json:"y"`
+	N       string `json:"n"`
+	E       string `json:"e"`
+}
+
+// publicKey decodes the key. Keys of unsupported types return an error and
+// are skipped.
+func (k jwk) publicKey() (any, error) {
+	b64 := base64.RawURLEncoding
+
+	switch k.KeyType {
+	case "RSA":
+		n, err := b64.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := b64.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() > 1<<31-1 {
+			return nil, errors.New("oidc: RSA exponent out of range")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+
+	case "EC":
+		if k.Curve != "P-256" {
+			return nil, fmt.Errorf("oidc: unsupported curve %q", k.Curve)
+		}
+		x, err := b64.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := b64.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != 32 || len(y) != 32 {
+			return nil, errors.New("oidc: invalid P-256 key")
+		}
+		// Uncompressed point encoding, which ParseUncompressedPublicKey checks is on the curve
+		point := append(append([]byte{4}, x...), y...)
+		return ecdsa.ParseUncompressedPublicKey(elliptic.P256(), point)
+
+	case "OKP":
+		if k.Curve != "Ed25519" {
+			return nil, fmt.Errorf("oidc: unsupported curve %q", k.Curve)
+		}
+		x, err := b64.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("oidc: invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("oidc: unsupported key type %q", k.KeyType)
+}
+
+// fetchKeys downloads the provider's signing keys, keyed by kid
+func (p *Provider) fetchKeys(ctx context.Context, jwksURI string) (map[string]any, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := p.getJSON(ctx, jwksURI, &set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]any, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			continue
+		}
+		keys[k.ID] = key
+	}
+	return keys, nil
+}
+
+// keyfunc finds the provider key an ID token names, fetching the keys again
+// if the kid is new, since providers rotate keys on their own schedule
+func (p *Provider) keyfunc(ctx context.Context) jwt.Keyfunc {
+	return func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+
+		key, err := p.key(ctx, kid)
+		if err != nil {
+			return nil, err
+		}
+
+		// The algorithm has to suit the key, or an RSA public key could be
+		// passed off as an HMAC secret
+		ok := false
+		switch key.(type) {
+		case *rsa.PublicKey:
+			ok = token.Method.Alg() == "RS256"
+		case *ecdsa.PublicKey:
+			ok = token.Method.Alg() == "ES256"
+		case ed25519.PublicKey:
+			ok = token.Method.Alg() == "EdDSA"
+		}
+		if !ok {
+			return nil, fmt.Errorf("oidc: key %q does not sign with %s", kid, token.Method.Alg())
+		}
+		return key, nil
+	}
+}
+
+func (p *Provider) key(ctx context.Context, kid string) (any, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+	if time.Since(p.keysFetchedAt) < jwksRefreshInterval {
+		return nil, fmt.Errorf("oidc: unknown key id %q", kid)
+	}
+
+	keys, err := p.fetchKeys(ctx, meta.JWKSURI)
+	if err != nil {
+		return nil, err
+	}
+	p.keys, p.keysFetchedAt = keys, time.Now()
+
+	key, ok := keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("oidc: unknown key id %q", kid)
+	}
+	return key, nil
+}
@@ -0,0 +1,293 @@
+// Package mockoidc is a stand-in OpenID Connect provider, for trying single
+// sign-on locally and for testing it. It signs in whoever it is told to, so
+// never expose it.
+package mockoidc
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"web-forum/internal/auth"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// codeTTL is how long an authorization code can be redeemed for
+const codeTTL = time.Minute
+
+// grant is an authorization code waiting to be redeemed
+type grant struct {
+	clientID      string
+	redirectURI   string
+	codeChallenge string
+	nonce         string
+	subject       string
+	username      string
+	email         string
+	emailVerified bool
+	expiresAt     time.Time
+}
+
+// Provider is the mock provider, an http.Handler serving the endpoints its
+// discovery document lists under Issuer
+type Provider struct {
+	// Issuer is put in ID tokens exactly as it is; endpoints hang off it
+	// without its trailing slash
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+
+	keys *auth.Keyring
+	mux  *http.ServeMux
+
+	mu     sync.Mutex
+	grants map[string]grant
+}
+
+// New returns a provider signing ID tokens with a fresh key for alg, RS256 or
+// EdDSA
+func New(issuer, clientID, clientSecret, alg string) (*Provider, error) {
+	var private crypto.Signer
+	var err error
+	switch alg {
+	case auth.AlgRS256:
+		private, err = rsa.GenerateKey(rand.Reader, 2048)
+	case auth.AlgEdDSA:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, fmt.Errorf("mockoidc: unsupported algorithm %q", alg)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := auth.NewPrivateKey("mock-"+strings.ToLower(alg), private)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := auth.NewKeyring(key)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Provider{
+		Issuer:       issuer,
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		keys:         keys,
+		mux:          http.NewServeMux(),
+		grants:       make(map[string]grant),
+	}
+	p.mux.HandleFunc("GET /.well-known/openid-configuration", p.discovery)
+	p.mux.HandleFunc("GET /jwks", p.jwks)
+	p.mux.HandleFunc("GET /authorize", p.authorize)
+	p.mux.HandleFunc("GET /authorize/approve", p.approve)
+	p.mux.HandleFunc("POST /token", p.token)
+	return p, nil
+}
+
+func (p *Provider) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.mux.ServeHTTP(w, r)
+}
+
+// base is the issuer without its trailing slash, for building endpoint URLs
+func (p *Provider) base() string {
+	return strings.TrimSuffix(p.Issuer, "/")
+}
+
+func (p *Provider) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                                p.Issuer,
+		"authorization_endpoint":                p.base() + "/authorize",
+		"token_endpoint":                        p.base() + "/token",
+		"jwks_uri":                              p.base() + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{auth.AlgRS256, auth.AlgEdDSA},
+		"code_challenge_methods_supported":      []string{"S256"},
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post", "none"},
+	})
+}
+
+func (p *Provider) jwks(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{"keys": p.keys.PublicKeys()})
+}
+
+var loginPage = template.Must(template.New("login").Parse(`<!doctype html>
+<title>Mock identity provider</title>
+<h1>Mock identity provider</h1>
+<p>Sign in to {{.ClientID}} as anyone. The subject identifies the account.</p>
+<form action="{{.Approve}}">
+{{range $name, $values := .Query}}<input type="hidden" name="{{$name}}" value="{{index $values 0}}">
+{{end}}<p><label>Subject <input name="sub" value="alice" required></label></p>
+<p><label>Username <input name="preferred_username" value="alice"></label></p>
+<p><label>Email <input name="email" value="alice@example.com"></label></p>
+<p><label><input type="checkbox" name="email_verified" value="true" checked> Email verified</label></p>
+<p><button>Sign in</button> <button name="deny" value="1">Deny</button></p>
+</form>`))
+
+// authorize checks the request like a real provider would and asks who to
+// sign in as
+func (p *Provider) authorize(w http.ResponseWriter, r *http.Request) {
+	if !p.checkAuthorize(w, r.URL.Query()) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	loginPage.Execute(w, map[string]any{"ClientID": p.ClientID, "Approve": p.base() + "/authorize/approve", "Query": r.URL.Query()})
+}
+
+// approve issues a code for the account filled in on the login page. It is a
+// GET so scripts can sign in without the form.
+func (p *Provider) approve(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if !p.checkAuthorize(w, query) {
+		return
+	}
+
+	redirect, _ := url.Parse(query.Get("redirect_uri"))
+	params := url.Values{"state": {query.Get("state")}}
+
+	if query.Get("deny") != "" {
+		params.Set("error", "access_denied")
+	} else {
+		if query.Get("sub") == "" {
+			http.Error(w, "sub is required", http.StatusBadRequest)
+			return
+		}
+
+		code := randomString()
+		p.mu.Lock()
+		p.grants[code] = grant{
+			clientID:      query.Get("client_id"),
+			redirectURI:   query.Get("redirect_uri"),
+			codeChallenge: query.Get("code_challenge"),
+			nonce:         query.Get("nonce"),
+			subject:       query.Get("sub"),
+			username:      query.Get("preferred_username"),
+			email:         query.Get("email"),
+			emailVerified: query.Get("email_verified") == "true",
+			expiresAt:     time.Now().Add(codeTTL),
+		}
+		p.mu.Unlock()
+		params.Set("code", code)
+	}
+
+	redirect.RawQuery = params.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (p *Provider) checkAuthorize(w http.ResponseWriter, query url.Values) bool {
+	redirect, err := url.Parse(query.Get("redirect_uri"))
+	switch {
+	case query.Get("client_id") != p.ClientID:
+		http.Error(w, "unknown client_id", http.StatusBadRequest)
+	case err != nil || !redirect.IsAbs():
+		http.Error(w, "redirect_uri must be an absolute URL", http.StatusBadRequest)
+	case query.Get("response_type") != "code":
+		http.Error(w, "response_type must be code", http.StatusBadRequest)
+	case !strings.Contains(" "+query.Get("scope")+" ", " openid "):
+		http.Error(w, "scope must include openid", http.StatusBadRequest)
+	case query.Get("code_challenge") == "" || query.Get("code_challenge_method") != "S256":
+		http.Error(w, "PKCE with S256 is required", http.StatusBadRequest)
+	default:
+		return true
+	}
+	return false
+}
+
+// token redeems a code, once, for an ID token
+func (p *Provider) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		tokenError(w, "unsupported_grant_type")
+		return
+	}
+
+	clientID, clientSecret, basic := r.BasicAuth()
+	if basic {
+		clientID, _ = url.QueryUnescape(clientID)
+		clientSecret, _ = url.QueryUnescape(clientSecret)
+	} else {
+		clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientID != p.ClientID || subtle.ConstantTimeCompare([]byte(clientSecret), []byte(p.ClientSecret)) != 1 {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	code := r.PostForm.Get("code")
+	p.mu.Lock()
+	g, ok := p.grants[code]
+	delete(p.grants, code)
+	p.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	challenge := base64.RawURLEncoding.EncodeToString(sum[:])
+
+	if !ok || time.Now().After(g.expiresAt) || g.clientID != clientID ||
+		g.redirectURI != r.PostForm.Get("redirect_uri") || g.codeChallenge != challenge {
+		tokenError(w, "invalid_grant")
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":            p.Issuer,
+		"sub":            g.subject,
+		"aud":            clientID,
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+		"email_verified": g.emailVerified,
+	}
+	if g.nonce != "" {
+		claims["nonce"] = g.nonce
+	}
+	if g.username != "" {
+		claims["preferred_username"] = g.username
+	}
+	if g.email != "" {
+		claims["email"] = g.email
+	}
+
+	idToken, err := p.keys.Sign(claims)
+	if err != nil {
+		tokenError(w, "server_error")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": randomString(),
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     idToken,
+	})
+}
+
+func tokenError(w http.ResponseWriter, code string) {
+	writeJSON(w, http.StatusBadRequest, map[string]string{"error": code})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func randomString() string {
+	b := make([]byte, 24)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
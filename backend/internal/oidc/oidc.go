@@ -0,0 +1,271 @@
+// Package oidc signs users in with an OpenID Connect provider, using the
+// authorization code flow with PKCE.
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Config describes the client registered with the provider
+type Config struct {
+	// Issuer is the provider's issuer URL, which its metadata is discovered from
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is the callback the provider sends users back to
+	RedirectURL string
+	// Name is what the login button calls the provider
+	Name string
+}
+
+// Claims are what the ID token says about the user. Subject, together with
+// Issuer, identifies them; the rest is only a hint.
+type Claims struct {
+	Issuer            string
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	PreferredUsername string
+	Name              string
+}
+
+// metadata is the part of the provider's discovery document the flow needs
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Provider talks to one OpenID Connect provider. Its metadata and keys are
+// fetched on first use rather than on start, so the forum still starts while
+// the provider is down.
+type Provider struct {
+	config Config
+	client *http.Client
+
+	mu            sync.Mutex
+	meta          *metadata
+	keys          map[string]any
+	keysFetchedAt time.Time
+}
+
+func New(config Config, client *http.Client) *Provider {
+	if config.Name == "" {
+		config.Name = "single sign-on"
+	}
+	return &Provider{config: config, client: client}
+}
+
+// FromEnv configures the provider from OIDC_ISSUER, OIDC_CLIENT_ID,
+// OIDC_CLIENT_SECRET (optional for public clients), OIDC_REDIRECT_URL and
+// OIDC_NAME. It returns nil if OIDC_ISSUER is unset, which turns single
+// sign-on off.
+func FromEnv() (*Provider, error) {
+	config := Config{
+		Issuer:       os.Getenv("OIDC_ISSUER"),
+		ClientID:     os.Getenv("OIDC_CLIENT_ID"),
+		ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+		RedirectURL:  os.Getenv("OIDC_REDIRECT_URL"),
+		Name:         os.Getenv("OIDC_NAME"),
+	}
+	if config.Issuer == "" {
+		return nil, nil
+	}
+	if config.ClientID == "" {
+		return nil, errors.New("oidc: OIDC_CLIENT_ID must be set with OIDC_ISSUER")
+	}
+	if config.RedirectURL == "" {
+		config.RedirectURL = "http://localhost:8080/api/users/oidc/callback"
+	}
+
+	return New(config, &http.Client{Timeout: 10 * time.Second}), nil
+}
+
+// Name is what the login button calls the provider
+func (p *Provider) Name() string {
+	return p.config.Name
+}
+
+// Issuer identifies the provider. Identities are linked per issuer.
+func (p *Provider) Issuer() string {
+	return p.config.Issuer
+}
+
+// AuthCodeURL is where to send the user to sign in for flow
+func (p *Provider) AuthCodeURL(ctx context.Context, flow Flow) (string, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.config.ClientID},
+		"redirect_uri":          {p.config.RedirectURL},
+		"scope":                 {"openid profile email"},
+		"state":                 {flow.State},
+		"nonce":                 {flow.Nonce},
+		"code_challenge":        {flow.Challenge()},
+		"code_challenge_method": {"S256"},
+	}
+
+	sep := "?"
+	if strings.Contains(meta.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return meta.AuthorizationEndpoint + sep + query.Encode(), nil
+}
+
+// Exchange redeems the code the provider sent back for an ID token, verifies
+// it belongs to flow and returns its claims
+func (p *Provider) Exchange(ctx context.Context, code string, flow Flow) (Claims, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.config.RedirectURL},
+		"code_verifier": {flow.Verifier},
+	}
+	if p.config.ClientSecret == "" {
+		form.Set("client_id", p.config.ClientID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Claims{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.config.ClientSecret != "" {
+		// client_secret_basic, which the spec says providers must support
+		req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+	}
+
+	var token struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return Claims{}, fmt.Errorf("oidc: token request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&token); err != nil {
+		return Claims{}, fmt.Errorf("oidc: token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK || token.IDToken == "" {
+		return Claims{}, fmt.Errorf("oidc: token request failed with %d: %s %s", resp.StatusCode, token.Error, token.ErrorDescription)
+	}
+
+	return p.verify(ctx, meta, token.IDToken, flow.Nonce)
+}
+
+// verify checks the ID token's signature, issuer, audience, expiry and nonce.
+// The issuer has to be the one in the provider's metadata, to the character.
+func (p *Provider) verify(ctx context.Context, meta *metadata, idToken, nonce string) (Claims, error) {
+	var claims struct {
+		jwt.RegisteredClaims
+		Nonce             string `json:"nonce"`
+		AuthorizedParty   string `json:"azp"`
+		Email             string `json:"email"`
+		EmailVerified     any    `json:"email_verified"`
+		PreferredUsername string `json:"preferred_username"`
+		Name              string `json:"name"`
+	}
+
+	_, err := jwt.ParseWithClaims(idToken, &claims, p.keyfunc(ctx),
+		jwt.WithValidMethods([]string{"RS256", "ES256", "EdDSA"}),
+		jwt.WithIssuer(meta.Issuer),
+		jwt.WithAudience(p.config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute))
+	if err != nil {
+		return Claims{}, fmt.Errorf("oidc: invalid ID token: %w", err)
+	}
+
+	if claims.Subject == "" || claims.Nonce != nonce {
+		return Claims{}, errors.New("oidc: invalid ID token: missing subject or wrong nonce")
+	}
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != p.config.ClientID {
+		return Claims{}, errors.New("oidc: invalid ID token: issued to another party")
+	}
+
+	return Claims{
+		Issuer:  meta.Issuer,
+		Subject: claims.Subject,
+		Email:   claims.Email,
+		// Some providers send the boolean as a string
+		EmailVerified:     claims.EmailVerified == true || claims.EmailVerified == "true",
+		PreferredUsername: claims.PreferredUsername,
+		Name:              claims.Name,
+	}, nil
+}
+
+// discover fetches the provider's metadata once it is first needed. Failures
+// are not remembered, so the next login tries again.
+func (p *Provider) discover(ctx context.Context) (*metadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.meta != nil {
+		return p.meta, nil
+	}
+
+	var meta metadata
+	// The issuer is kept as given, since ID tokens must name it exactly, and
+	// only trimmed to build the discovery URL
+	issuer := strings.TrimSuffix(p.config.Issuer, "/")
+	if err := p.getJSON(ctx, issuer+"/.well-known/openid-configuration", &meta); err != nil {
+		return nil, err
+	}
+	if strings.TrimSuffix(meta.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("oidc: provider says its issuer is %q, expected %q", meta.Issuer, p.config.Issuer)
+	}
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.JWKSURI == "" {
+		return nil, errors.New("oidc: provider metadata is missing endpoints")
+	}
+
+	p.meta = &meta
+	return p.meta, nil
+}
+
+func (p *Provider) getJSON(ctx context.Context, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("oidc: fetch %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("oidc: fetch %s: status %d", url, resp.StatusCode)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v); err != nil {
+		return fmt.Errorf("oidc: decode %s: %w", url, err)
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+package passwords
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// PrefixLength is how many hex digits of a SHA-1 a range lookup reveals.
+// There are over a million prefixes, so each stands for hundreds of
+// breached passwords and says next to nothing about the one asked about.
+const PrefixLength = 5
+
+// BreachedList answers k-anonymity range lookups like the Pwned Passwords
+// API: given the first PrefixLength upper-case hex digits of a SHA-1, it
+// returns the remaining digits of every breached password's hash that starts
+// with them
+type BreachedList interface {
+	Range(ctx context.Context, prefix string) ([]string, error)
+}
+
+// BreachedFile is a BreachedList kept in a local file with a line per
+// password, its upper-case SHA-1 in hex and optionally ":" and how often it
+// was seen, sorted by hash. That is the format the Pwned Passwords downloader
+// writes, and "sort" over a list of hashes makes a small one. The file is
+// searched in place, so even the full list is never read into memory.
+type BreachedFile struct {
+	f    *os.File
+	size int64
+}
+
+// OpenBreachedFile opens a breached password list and checks it looks like one
+func OpenBreachedFile(path string) (*BreachedFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("passwords: open breached password list: %w", err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("passwords: open breached password list: %w", err)
+	}
+
+	b := &BreachedFile{f: f, size: info.Size()}
+	if b.size > 0 {
+		_, line, _, err := b.lineFrom(0)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("passwords: read breached password list: %w", err)
+		}
+		if _, ok := hashOf(line); !ok {
+			f.Close()
+			return nil, fmt.Errorf("passwords: %s is not a list of SHA-1 hashes, its first line is %q", path, line)
+		}
+		if err := b.checkSorted(); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("passwords: %s: %w", path, err)
+		}
+	}
+	return b, nil
+}
+
+// sortProbes is how many lines checkSorted compares
+const sortProbes = 64
+
+// checkSorted compares lines spread evenly over the file. An unsorted list
+// would make Range miss hashes without any error, and one that was never
+// sorted at all is almost sure to be caught.
+func (b *BreachedFile) checkSorted() error {
+	var prev string
+	for i := int64(0); i <= sortProbes; i++ {
+		start, line, _, err := b.lineFrom(b.size * i / sortProbes)
+		if err != nil {
+			return err
+		}
+		if start >= b.size {
+			break
+		}
+
+		hash, ok := hashOf(line)
+		if !ok {
+			continue
+		}
+		if hash < prev {
+			return fmt.Errorf("breached password list is not sorted by hash: %s comes after %s", hash, prev)
+		}
+		prev = hash
+	}
+	return nil
+}
+
+func (b *BreachedFile) Close() error {
+	return b.f.Close()
+}
+
+// Range binary searches the file for the first line with the prefix and
+// reads the lines from there on that have it
+func (b *BreachedFile) Range(ctx context.Context, prefix string) ([]string, error) {
+	if len(prefix) != PrefixLength {
+		return nil, fmt.Errorf("passwords: range prefix must be %d hex digits", PrefixLength)
+	}
+
+	// Find the lowest offset whose next line sorts at or after the prefix
+	lo, hi := int64(0), b.size
+	for lo < hi {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		mid := lo + (hi-lo)/2
+		start, line, _, err := b.lineFrom(mid)
+		if err != nil {
+			return nil, err
+		}
+		if start >= b.size || strings.ToUpper(hashPrefix(line)) >= prefix {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+
+	var suffixes []string
+	for offset := lo; offset < b.size; {
+		_, line, next, err := b.lineFrom(offset)
+		if err != nil {
+			return nil, err
+		}
+
+		hash, ok := hashOf(line)
+		if !ok {
+			// A blank line at the end, or worse; either way nothing more to find
+			break
+		}
+		if !strings.HasPrefix(hash, prefix) {
+			break
+		}
+		suffixes = append(suffixes, hash[PrefixLength:])
+		offset = next
+	}
+	return suffixes, nil
+}
+
+// lineFrom reads the first line starting at or after offset. It returns where
+// the line starts, the line without its line break, and where the next one
+// starts. At the end of the file start is the size and line is empty.
+func (b *BreachedFile) lineFrom(offset int64) (start int64, line string, next int64, err error) {
+	start = offset
+	if offset > 0 {
+		// Back up one byte: if it is a line break, offset starts a line
+		start = offset - 1
+	}
+	r := bufio.NewReaderSize(io.NewSectionReader(b.f, start, b.size-start), 128)
+
+	if offset > 0 {
+		skipped, err := r.ReadSlice('\n')
+		if err == io.EOF {
+			return b.size, "", b.size, nil
+		}
+		if err != nil && err != bufio.ErrBufferFull {
+			return 0, "", 0, err
+		}
+		// Lines are far shorter than the buffer; one that is not is skipped whole
+		for err == bufio.ErrBufferFull {
+			start += int64(len(skipped))
+			skipped, err = r.ReadSlice('\n')
+		}
+		if err == io.EOF {
+			return b.size, "", b.size, nil
+		}
+		if err != nil {
+			return 0, "", 0, err
+		}
+		start += int64(len(skipped))
+	}
+
+	raw, err := r.ReadSlice('\n')
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return 0, "", 0, err
+	}
+	next = start + int64(len(raw))
+	return start, string(bytes.TrimRight(raw, "\r\n")), next, nil
+}
+
+// hashOf returns the upper-cased hash of a line of the list
+func hashOf(line string) (string, bool) {
+	hash := strings.ToUpper(hashPrefix(line))
+	if len(hash) != 40 {
+		return "", false
+	}
+	for _, r := range hash {
+		if !strings.ContainsRune("0123456789ABCDEF", r) {
+			return "", false
+		}
+	}
+	return hash, true
+}
+
+// hashPrefix is the part of a line before the count
+func hashPrefix(line string) string {
+	hash, _, _ := strings.Cut(line, ":")
+	return strings.TrimSpace(hash)
+}
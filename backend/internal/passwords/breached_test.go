@@ -0,0 +1,159 @@
+package passwords
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func sha1Hex(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// writeList writes lines to a breached password list file and opens it
+func writeList(t *testing.T, content string) (*BreachedFile, error) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	list, err := OpenBreachedFile(path)
+	if err == nil {
+		t.Cleanup(func() { list.Close() })
+	}
+	return list, err
+}
+
+// sortedList returns the hashes of password0 to password(n-1), sorted as a
+// breached password list has to be
+func sortedList(n int) []string {
+	hashes := make([]string, n)
+	for i := range hashes {
+		hashes[i] = sha1Hex(fmt.Sprintf("password%d", i))
+	}
+	slices.Sort(hashes)
+	return hashes
+}
+
+func TestBreachedFileRange(t *testing.T) {
+	hashes := sortedList(500)
+	// Two hashes sharing a prefix, to find both
+	shared := []string{hashes[0][:PrefixLength] + strings.Repeat("0", 35), hashes[0][:PrefixLength] + strings.Repeat("F", 35)}
+	hashes = append(hashes, shared...)
+	slices.Sort(hashes)
+
+	var content strings.Builder
+	for i, hash := range hashes {
+		fmt.Fprintf(&content, "%s:%d\r\n", hash, i+1)
+	}
+	list, err := writeList(t, content.String())
+	if err != nil {
+		t.Fatalf("OpenBreachedFile: %v", err)
+	}
+
+	ctx := context.Background()
+	check := func(name, hash string, want bool) {
+		t.Helper()
+
+		suffixes, err := list.Range(ctx, hash[:PrefixLength])
+		if err != nil {
+			t.Fatalf("%s: Range: %v", name, err)
+		}
+		for _, suffix := range suffixes {
+			if len(suffix) != 40-PrefixLength {
+				t.Errorf("%s: Range returned %q", name, suffix)
+			}
+		}
+		if got := slices.Contains(suffixes, hash[PrefixLength:]); got != want {
+			t.Errorf("%s: found = %v, want %v (suffixes %v)", name, got, want, suffixes)
+		}
+	}
+
+	check("first entry", hashes[0], true)
+	check("last entry", hashes[len(hashes)-1], true)
+	check("middle entry", hashes[len(hashes)/2], true)
+	for _, hash := range shared {
+		check("entry sharing a prefix", hash, true)
+	}
+	check("missing entry", sha1Hex("not in the list"), false)
+	check("before the first entry", "00000"+strings.Repeat("0", 35), false)
+	check("after the last entry", "FFFFF"+strings.Repeat("F", 35), false)
+
+	if _, err := list.Range(ctx, "ABC"); err == nil {
+		t.Errorf("Range of a short prefix succeeded")
+	}
+}
+
+func TestBreachedFileFormats(t *testing.T) {
+	ctx := context.Background()
+	hashes := sortedList(3)
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"bare hashes", strings.Join(hashes, "\n")},
+		{"trailing newline", strings.Join(hashes, "\n") + "\n"},
+		{"trailing blank lines", strings.Join(hashes, "\n") + "\n\n\n"},
+		{"lower case", strings.ToLower(strings.Join(hashes, "\n"))},
+		{"counts", strings.Join(hashes, ":7\n") + ":7"},
+	}
+
+	for _, tt := range tests {
+		list, err := writeList(t, tt.content)
+		if err != nil {
+			t.Errorf("%s: OpenBreachedFile: %v", tt.name, err)
+			continue
+		}
+		for _, hash := range hashes {
+			suffixes, err := list.Range(ctx, hash[:PrefixLength])
+			if err != nil || !slices.Contains(suffixes, hash[PrefixLength:]) {
+				t.Errorf("%s: %s not found: %v, %v", tt.name, hash, suffixes, err)
+			}
+		}
+	}
+}
+
+func TestOpenBreachedFileRejects(t *testing.T) {
+	hashes := sortedList(200)
+	reversed := slices.Clone(hashes)
+	slices.Reverse(reversed)
+	swapped := slices.Clone(hashes)
+	swapped[0], swapped[len(swapped)-1] = swapped[len(swapped)-1], swapped[0]
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"unsorted", strings.Join(reversed, "\n")},
+		{"two lines unsorted", hashes[1] + "\n" + hashes[0] + "\n"},
+		{"first and last swapped", strings.Join(swapped, "\n")},
+		{"plain passwords", "password\nletmein\n"},
+		{"short hashes", "5BAA6\n"},
+	}
+
+	for _, tt := range tests {
+		if _, err := writeList(t, tt.content); err == nil {
+			t.Errorf("%s: OpenBreachedFile succeeded", tt.name)
+		}
+	}
+}
+
+func TestBreachedFileEmpty(t *testing.T) {
+	list, err := writeList(t, "")
+	if err != nil {
+		t.Fatalf("OpenBreachedFile: %v", err)
+	}
+	suffixes, err := list.Range(context.Background(), "5BAA6")
+	if err != nil || len(suffixes) != 0 {
+		t.Errorf("Range of an empty list = %v, %v", suffixes, err)
+	}
+}
@@ -0,0 +1,235 @@
+package passwords
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Hashing algorithms a Hasher can hash new passwords with. Both are verified
+// whichever is configured, so switching only takes effect as users log in.
+const (
+	AlgArgon2id = "argon2id"
+	AlgBcrypt   = "bcrypt"
+)
+
+// Argon2Params are the argon2id cost parameters. Memory is in KiB.
+type Argon2Params struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2 is the OWASP minimum for argon2id: 19 MiB, 2 passes, 1 lane
+var DefaultArgon2 = Argon2Params{
+	Memory:      19 * 1024,
+	Iterations:  2,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// BcryptMaxBytes is the longest password bcrypt hashes. It refuses longer ones.
+const BcryptMaxBytes = 72
+
+// DefaultBcryptCost is the cost passwords were always hashed with before
+// argon2id, so existing hashes are not rehashed for it alone
+const DefaultBcryptCost = 10
+
+// Hasher hashes new passwords with Algorithm and verifies hashes made with
+// either algorithm and any parameters. Hashes are stored as PHC strings:
+//
+//	$argon2id$v=19$m=19456,t=2,p=1$<salt>$<hash>
+//
+// or in bcrypt's own $2a$<cost>$ format, which predates PHC and which it
+// follows in spirit, so hashes made before argon2id stay valid.
+type Hasher struct {
+	Algorithm  string
+	Argon2     Argon2Params
+	BcryptCost int
+}
+
+var errUnknownHash = errors.New("passwords: unrecognised password hash")
+
+// HasherFromEnv builds the hasher from the environment:
+//
+//   - PASSWORD_HASH picks argon2id, the default, or bcrypt for new hashes
+//   - ARGON2_MEMORY (KiB), ARGON2_ITERATIONS and ARGON2_PARALLELISM tune
+//     argon2id, defaulting to DefaultArgon2
+//   - BCRYPT_COST tunes bcrypt, defaulting to DefaultBcryptCost
+func HasherFromEnv() (*Hasher, error) {
+	h := &Hasher{Algorithm: AlgArgon2id, Argon2: DefaultArgon2, BcryptCost: DefaultBcryptCost}
+
+	switch alg := os.Getenv("PASSWORD_HASH"); alg {
+	case "", AlgArgon2id:
+	case AlgBcrypt:
+		h.Algorithm = AlgBcrypt
+	default:
+		return nil, fmt.Errorf("passwords: unknown PASSWORD_HASH %q, expected argon2id or bcrypt", alg)
+	}
+
+	for _, param := range []struct {
+		name string
+		into *uint32
+		max  uint64
+	}{
+		{"ARGON2_MEMORY", &h.Argon2.Memory, 1 << 22},
+		{"ARGON2_ITERATIONS", &h.Argon2.Iterations, 100},
+	} {
+		if s := os.Getenv(param.name); s != "" {
+			n, err := strconv.ParseUint(s, 10, 32)
+			if err != nil || n == 0 || n > param.max {
+				return nil, fmt.Errorf("passwords: %s must be a number from 1 to %d, got %q", param.name, param.max, s)
+			}
+			*param.into = uint32(n)
+		}
+	}
+	if s := os.Getenv("ARGON2_PARALLELISM"); s != "" {
+		n, err := strconv.ParseUint(s, 10, 8)
+		if err != nil || n == 0 {
+			return nil, fmt.Errorf("passwords: ARGON2_PARALLELISM must be a number from 1 to 255, got %q", s)
+		}
+		h.Argon2.Parallelism = uint8(n)
+	}
+	// argon2 needs 8 KiB of memory per lane
+	if h.Argon2.Memory < 8*uint32(h.Argon2.Parallelism) {
+		return nil, fmt.Errorf("passwords: ARGON2_MEMORY must be at least 8 KiB per lane of ARGON2_PARALLELISM")
+	}
+
+	if s := os.Getenv("BCRYPT_COST"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < bcrypt.MinCost || n > bcrypt.MaxCost {
+			return nil, fmt.Errorf("passwords: BCRYPT_COST must be a number from %d to %d, got %q", bcrypt.MinCost, bcrypt.MaxCost, s)
+		}
+		h.BcryptCost = n
+	}
+
+	return h, nil
+}
+
+var (
+	hasherOnce sync.Once
+	hasher     *Hasher
+	hasherErr  error
+)
+
+// DefaultHasher returns the hasher configured in the environment, loading it
+// on first use
+func DefaultHasher() (*Hasher, error) {
+	hasherOnce.Do(func() {
+		hasher, hasherErr = HasherFromEnv()
+	})
+	return hasher, hasherErr
+}
+
+// MaxPasswordBytes is the longest password, in bytes, h can hash new
+// passwords of, 0 if there is no limit
+func (h *Hasher) MaxPasswordBytes() int {
+	if h.Algorithm == AlgBcrypt {
+		return BcryptMaxBytes
+	}
+	return 0
+}
+
+// Hash hashes password with a fresh salt
+func (h *Hasher) Hash(password string) (string, error) {
+	if h.Algorithm == AlgBcrypt {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), h.BcryptCost)
+		return string(hash), err
+	}
+
+	p := h.Argon2
+	salt := make([]byte, p.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+
+	return fmt.Sprintf("$%s$v=%d$m=%d,t=%d,p=%d$%s$%s", AlgArgon2id, argon2.Version, p.Memory, p.Iterations, p.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// Verify reports whether password matches hash. An empty or unrecognised
+// hash matches nothing, which is how accounts without a password stay that way.
+func (h *Hasher) Verify(password, hash string) bool {
+	if isBcrypt(hash) {
+		return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+	}
+
+	params, salt, key, err := parseArgon2id(hash)
+	if err != nil {
+		return false
+	}
+	got := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+	return subtle.ConstantTimeCompare(got, key) == 1
+}
+
+// NeedsRehash reports whether hash was made with another algorithm or other
+// parameters than h would use now. Call it once the password is verified,
+// while it is at hand to hash again. Empty hashes never need one.
+func (h *Hasher) NeedsRehash(hash string) bool {
+	if hash == "" {
+		return false
+	}
+
+	if isBcrypt(hash) {
+		if h.Algorithm != AlgBcrypt {
+			return true
+		}
+		cost, err := bcrypt.Cost([]byte(hash))
+		return err != nil || cost != h.BcryptCost
+	}
+
+	params, _, _, err := parseArgon2id(hash)
+	return err != nil || h.Algorithm != AlgArgon2id || params != h.Argon2
+}
+
+func isBcrypt(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+// parseArgon2id takes an argon2id PHC string apart. The salt and key lengths
+// are reported in the params.
+func parseArgon2id(hash string) (params Argon2Params, salt, key []byte, err error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[0] != "" || parts[1] != AlgArgon2id || parts[2] != "v="+strconv.Itoa(argon2.Version) {
+		return Argon2Params{}, nil, nil, errUnknownHash
+	}
+
+	var m, t, p uint64
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &m, &t, &p); err != nil {
+		return Argon2Params{}, nil, nil, errUnknownHash
+	}
+	if m == 0 || m > 1<<32-1 || t == 0 || t > 1<<32-1 || p == 0 || p > 255 {
+		return Argon2Params{}, nil, nil, errUnknownHash
+	}
+
+	salt, err = base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil || len(salt) == 0 {
+		return Argon2Params{}, nil, nil, errUnknownHash
+	}
+	key, err = base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return Argon2Params{}, nil, nil, errUnknownHash
+	}
+
+	params = Argon2Params{
+		Memory:      uint32(m),
+		Iterations:  uint32(t),
+		Parallelism: uint8(p),
+		SaltLength:  uint32(len(salt)),
+		KeyLength:   uint32(len(key)),
+	}
+	return params, salt, key, nil
+}
@@ -0,0 +1,183 @@
+package passwords
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// cheapArgon2 keeps the tests fast; only the encoding is under test
+var cheapArgon2 = Argon2Params{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func argon2Hasher(p Argon2Params) *Hasher {
+	return &Hasher{Algorithm: AlgArgon2id, Argon2: p, BcryptCost: bcrypt.MinCost}
+}
+
+func bcryptHasher(cost int) *Hasher {
+	return &Hasher{Algorithm: AlgBcrypt, Argon2: cheapArgon2, BcryptCost: cost}
+}
+
+func mustHash(t *testing.T, h *Hasher, password string) string {
+	t.Helper()
+
+	hash, err := h.Hash(password)
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	return hash
+}
+
+func TestHashVerify(t *testing.T) {
+	tests := []struct {
+		name   string
+		hasher *Hasher
+		prefix string
+	}{
+		{"argon2id", argon2Hasher(cheapArgon2), "$argon2id$v=19$m=64,t=1,p=1$"},
+		{"bcrypt", bcryptHasher(bcrypt.MinCost), "$2a$04$"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hash := mustHash(t, tt.hasher, "correct horse")
+			if !strings.HasPrefix(hash, tt.prefix) {
+				t.Errorf("hash %q does not start with %q", hash, tt.prefix)
+			}
+
+			if !tt.hasher.Verify("correct horse", hash) {
+				t.Errorf("Verify rejected the right password")
+			}
+			if tt.hasher.Verify("correct horse ", hash) {
+				t.Errorf("Verify accepted a wrong password")
+			}
+			if tt.hasher.NeedsRehash(hash) {
+				t.Errorf("NeedsRehash of a hash just made")
+			}
+
+			// Every hash has its own salt
+			if again := mustHash(t, tt.hasher, "correct horse"); again == hash {
+				t.Errorf("two hashes of the same password are equal")
+			}
+		})
+	}
+}
+
+func TestVerifyEitherAlgorithm(t *testing.T) {
+	argonHash := mustHash(t, argon2Hasher(cheapArgon2), "secret")
+	bcryptHash := mustHash(t, bcryptHasher(bcrypt.MinCost), "secret")
+
+	// Whatever a hasher is configured to hash with, it verifies both kinds
+	for _, h := range []*Hasher{argon2Hasher(DefaultArgon2), bcryptHasher(DefaultBcryptCost)} {
+		for _, hash := range []string{argonHash, bcryptHash} {
+			if !h.Verify("secret", hash) {
+				t.Errorf("%s hasher rejected %q", h.Algorithm, hash)
+			}
+		}
+	}
+}
+
+func TestVerifyNoPassword(t *testing.T) {
+	h := argon2Hasher(cheapArgon2)
+	for _, hash := range []string{"", "plaintext", "$unknown$v=1$x$y$z"} {
+		if h.Verify("", hash) || h.Verify("plaintext", hash) {
+			t.Errorf("Verify matched hash %q", hash)
+		}
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	argonHash := mustHash(t, argon2Hasher(cheapArgon2), "secret")
+	bcryptHash := mustHash(t, bcryptHasher(bcrypt.MinCost), "secret")
+
+	with := func(change func(p *Argon2Params)) *Hasher {
+		p := cheapArgon2
+		change(&p)
+		return argon2Hasher(p)
+	}
+
+	tests := []struct {
+		name   string
+		hasher *Hasher
+		hash   string
+		want   bool
+	}{
+		{"same argon2id parameters", argon2Hasher(cheapArgon2), argonHash, false},
+		{"more memory", with(func(p *Argon2Params) { p.Memory = 128 }), argonHash, true},
+		{"more iterations", with(func(p *Argon2Params) { p.Iterations = 2 }), argonHash, true},
+		{"more lanes", with(func(p *Argon2Params) { p.Parallelism = 2 }), argonHash, true},
+		{"longer salt", with(func(p *Argon2Params) { p.SaltLength = 32 }), argonHash, true},
+		{"longer key", with(func(p *Argon2Params) { p.KeyLength = 64 }), argonHash, true},
+		{"argon2id to bcrypt", bcryptHasher(bcrypt.MinCost), argonHash, true},
+		{"same bcrypt cost", bcryptHasher(bcrypt.MinCost), bcryptHash, false},
+		{"higher bcrypt cost", bcryptHasher(bcrypt.MinCost + 1), bcryptHash, true},
+		{"bcrypt to argon2id", argon2Hasher(cheapArgon2), bcryptHash, true},
+		{"no password", argon2Hasher(cheapArgon2), "", false},
+		{"unrecognised hash", argon2Hasher(cheapArgon2), "plaintext", true},
+	}
+
+	for _, tt := range tests {
+		if got := tt.hasher.NeedsRehash(tt.hash); got != tt.want {
+			t.Errorf("%s: NeedsRehash = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseArgon2id(t *testing.T) {
+	hash := mustHash(t, argon2Hasher(cheapArgon2), "secret")
+
+	params, salt, key, err := parseArgon2id(hash)
+	if err != nil {
+		t.Fatalf("parseArgon2id(%q): %v", hash, err)
+	}
+	if params != cheapArgon2 {
+		t.Errorf("params = %+v, want %+v", params, cheapArgon2)
+	}
+	if len(salt) != 16 || len(key) != 32 {
+		t.Errorf("salt of %d bytes and key of %d, want 16 and 32", len(salt), len(key))
+	}
+}
+
+func TestParseArgon2idMalformed(t *testing.T) {
+	const (
+		salt = "c29tZXNhbHRzb21lc2FsdA"
+		key  = "a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2U"
+	)
+
+	// Each case breaks one thing about this otherwise valid hash
+	if _, _, _, err := parseArgon2id("$argon2id$v=19$m=64,t=1,p=1$" + salt + "$" + key); err != nil {
+		t.Fatalf("parseArgon2id of the valid hash: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		hash string
+	}{
+		{"wrong version", "$argon2id$v=16$m=64,t=1,p=1$" + salt + "$" + key},
+		{"no version", "$argon2id$m=64,t=1,p=1$" + salt + "$" + key},
+		{"argon2i", "$argon2i$v=19$m=64,t=1,p=1$" + salt + "$" + key},
+		{"no lanes", "$argon2id$v=19$m=64,t=1,p=0$" + salt + "$" + key},
+		{"too many lanes", "$argon2id$v=19$m=64,t=1,p=256$" + salt + "$" + key},
+		{"no memory", "$argon2id$v=19$m=0,t=1,p=1$" + salt + "$" + key},
+		{"no iterations", "$argon2id$v=19$m=64,t=0,p=1$" + salt + "$" + key},
+		{"memory overflows", "$argon2id$v=19$m=4294967296,t=1,p=1$" + salt + "$" + key},
+		{"parameters not numbers", "$argon2id$v=19$m=x,t=1,p=1$" + salt + "$" + key},
+		{"empty salt", "$argon2id$v=19$m=64,t=1,p=1$$" + key},
+		{"empty key", "$argon2id$v=19$m=64,t=1,p=1$" + salt + "$"},
+		{"salt not base64", "$argon2id$v=19$m=64,t=1,p=1$not*base64$" + key},
+		{"key not base64", "$argon2id$v=19$m=64,t=1,p=1$" + salt + "$not*base64"},
+		{"padded base64", "$argon2id$v=19$m=64,t=1,p=1$" + salt + "==$" + key},
+		{"missing key", "$argon2id$v=19$m=64,t=1,p=1$" + salt},
+		{"extra field", "$argon2id$v=19$m=64,t=1,p=1$" + salt + "$" + key + "$"},
+		{"no leading dollar", "argon2id$v=19$m=64,t=1,p=1$" + salt + "$" + key + "$"},
+	}
+
+	for _, tt := range tests {
+		if _, _, _, err := parseArgon2id(tt.hash); err == nil {
+			t.Errorf("%s: parseArgon2id(%q) succeeded", tt.name, tt.hash)
+		}
+		if argon2Hasher(cheapArgon2).Verify("secret", tt.hash) {
+			t.Errorf("%s: Verify matched %q", tt.name, tt.hash)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+// Package passwords decides which passwords users may choose, with a
+// configurable policy of length and character classes, no username inside the
+// password and no password known from a breach, and how they are hashed.
+package passwords
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Rules a password can break, as reported in Violation.Rule
+const (
+	RuleMinLength = "min_length"
+	RuleMaxLength = "max_length"
+	RuleMaxBytes  = "max_bytes"
+	RuleUpper     = "upper"
+	RuleLower     = "lower"
+	RuleDigit     = "digit"
+	RuleSymbol    = "symbol"
+	RuleUsername  = "username"
+	RuleBreached  = "breached"
+)
+
+// Violation is a rule a password broke, with a message fit to show the user
+type Violation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Error lists every rule a password broke, so the user can fix them at once
+type Error struct {
+	Violations []Violation
+}
+
+func (e *Error) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		messages[i] = v.Message
+	}
+	return "passwords: " + strings.Join(messages, "; ")
+}
+
+// Policy is what a new password has to satisfy. The zero Policy accepts
+// anything; Default is what the forum uses unless configured otherwise.
+type Policy struct {
+	// MinLength and MaxLength count characters. A MaxLength of 0 means none.
+	MinLength int `json:"min_length"`
+	MaxLength int `json:"max_length"`
+	// MaxBytes limits the UTF-8 length, for hashes that only take so many
+	// bytes. 0 means no limit.
+	MaxBytes int `json:"max_bytes"`
+
+	RequireUpper  bool `json:"require_upper"`
+	RequireLower  bool `json:"require_lower"`
+	RequireDigit  bool `json:"require_digit"`
+	RequireSymbol bool `json:"require_symbol"`
+
+	// DisallowUsername turns away passwords containing the username, in any case
+	DisallowUsername bool `json:"disallow_username"`
+
+	// Breached, if set, turns away passwords found in it
+	Breached BreachedList `json:"-"`
+}
+
+// Default asks for length over complexity, as NIST SP 800-63B does. Its
+// maximum is in characters, which can take up to 4 bytes each, so FromEnv
+// adds the byte limit of the hasher on top.
+var Default = Policy{
+	MinLength:        8,
+	MaxLength:        64,
+	DisallowUsername: true,
+}
+
+// FromEnv builds the policy from the environment, starting from Default:
+//
+//   - PASSWORD_MIN_LENGTH and PASSWORD_MAX_LENGTH set the length limits
+//   - PASSWORD_REQUIRE lists required character classes, comma separated,
+//     out of upper, lower, digit and symbol
+//   - PASSWORD_ALLOW_USERNAME=true lets the password contain the username
+//   - PASSWORD_BREACHED_FILE names a breached password list, see OpenBreachedFile
+//
+// MaxBytes is taken from hasher, so no password is accepted that it cannot hash.
+func FromEnv(hasher *Hasher) (*Policy, error) {
+	p := Default
+	p.MaxBytes = hasher.MaxPasswordBytes()
+
+	for name, limit := range map[string]*int{"PASSWORD_MIN_LENGTH": &p.MinLength, "PASSWORD_MAX_LENGTH": &p.MaxLength} {
+		if s := os.Getenv(name); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("passwords: %s must be a number of characters, got %q", name, s)
+			}
+			*limit = n
+		}
+	}
+	if p.MinLength < 1 {
+		return nil, fmt.Errorf("passwords: PASSWORD_MIN_LENGTH must be at least 1")
+	}
+	if p.MaxLength != 0 && p.MaxLength < p.MinLength {
+		return nil, fmt.Errorf("passwords: PASSWORD_MAX_LENGTH %d is below PASSWORD_MIN_LENGTH %d", p.MaxLength, p.MinLength)
+	}
+
+	if s := os.Getenv("PASSWORD_REQUIRE"); s != "" {
+		for _, class := range strings.Split(s, ",") {
+			switch strings.TrimSpace(class) {
+			case RuleUpper:
+				p.RequireUpper = true
+			case RuleLower:
+				p.RequireLower = true
+			case RuleDigit:
+				p.RequireDigit = true
+			case RuleSymbol:
+				p.RequireSymbol = true
+			default:
+				return nil, fmt.Errorf("passwords: unknown character class %q in PASSWORD_REQUIRE, expected upper, lower, digit or symbol", class)
+			}
+		}
+	}
+
+	if s := os.Getenv("PASSWORD_ALLOW_USERNAME"); s != "" {
+		allow, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, fmt.Errorf("passwords: PASSWORD_ALLOW_USERNAME must be true or false, got %q", s)
+		}
+		p.DisallowUsername = !allow
+	}
+
+	if path := os.Getenv("PASSWORD_BREACHED_FILE"); path != "" {
+		list, err := OpenBreachedFile(path)
+		if err != nil {
+			return nil, err
+		}
+		p.Breached = list
+	}
+
+	return &p, nil
+}
+
+// Check returns an *Error listing every rule password breaks for the user
+// called username, or nil if it breaks none. Other errors come from looking
+// it up in the breached list.
+func (p *Policy) Check(ctx context.Context, password, username string) error {
+	var violations []Violation
+	add := func(rule, format string, args ...any) {
+		violations = append(violations, Violation{Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		add(RuleMinLength, "Password must be at least %d characters long", p.MinLength)
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		add(RuleMaxLength, "Password must be at most %d characters long", p.MaxLength)
+	} else if p.MaxBytes > 0 && len(password) > p.MaxBytes {
+		add(RuleMaxBytes, "Password must be at most %d bytes long, and letters outside the English alphabet take 2 to 4 bytes each", p.MaxBytes)
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case !unicode.IsLetter(r):
+			symbol = true
+		}
+	}
+	if p.RequireUpper && !upper {
+		add(RuleUpper, "Password must contain an uppercase letter")
+	}
+	if p.RequireLower && !lower {
+		add(RuleLower, "Password must contain a lowercase letter")
+	}
+	if p.RequireDigit && !digit {
+		add(RuleDigit, "Password must contain a digit")
+	}
+	if p.RequireSymbol && !symbol {
+		add(RuleSymbol, "Password must contain a symbol or space")
+	}
+
+	if p.DisallowUsername && username != "" && strings.Contains(strings.ToLower(password), strings.ToLower(username)) {
+		add(RuleUsername, "Password must not contain your username")
+	}
+
+	if p.Breached != nil {
+		breached, err := p.breached(ctx, password)
+		if err != nil {
+			return err
+		}
+		if breached {
+			add(RuleBreached, "This password has appeared in a data breach. Choose another")
+		}
+	}
+
+	if len(violations) > 0 {
+		return &Error{Violations: violations}
+	}
+	return nil
+}
+
+// breached asks the list about the first 5 hex digits of the password's
+// SHA-1 and looks for the rest among the suffixes it answers with, so the
+// list never learns the password or even its hash
+func (p *Policy) breached(ctx context.Context, password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	suffixes, err := p.Breached.Range(ctx, hash[:PrefixLength])
+	if err != nil {
+		return false, fmt.Errorf("passwords: breached password lookup: %w", err)
+	}
+
+	for _, suffix := range suffixes {
+		if suffix == hash[PrefixLength:] {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,127 @@
+package passwords
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// rulesBroken returns the rules password breaks, failing on other errors
+func rulesBroken(t *testing.T, p *Policy, password, username string) []string {
+	t.Helper()
+
+	err := p.Check(context.Background(), password, username)
+	if err == nil {
+		return nil
+	}
+	var policyErr *Error
+	if !errors.As(err, &policyErr) {
+		t.Fatalf("Check(%q): %v", password, err)
+	}
+
+	var rules []string
+	for _, v := range policyErr.Violations {
+		rules = append(rules, v.Rule)
+	}
+	return rules
+}
+
+func TestPolicyCheck(t *testing.T) {
+	strict := Default
+	strict.RequireUpper, strict.RequireLower, strict.RequireDigit, strict.RequireSymbol = true, true, true, true
+
+	tests := []struct {
+		name     string
+		policy   Policy
+		password string
+		username string
+		want     []string
+	}{
+		{"default accepts a long password", Default, "correct horse battery", "alice", nil},
+		{"too short", Default, "short", "alice", []string{RuleMinLength}},
+		{"too long", Default, strings.Repeat("a", 65), "alice", []string{RuleMaxLength}},
+		{"length counts characters", Default, strings.Repeat("é", 64), "alice", nil},
+		{"contains the username", Default, "my name is Alice!", "alice", []string{RuleUsername}},
+		{"username allowed", Policy{MinLength: 8}, "my name is alice", "alice", nil},
+		{"every class missing", strict, "        ", "", []string{RuleUpper, RuleLower, RuleDigit}},
+		{"every class present", strict, "Tr0ub4dor &3", "", nil},
+		{"every rule at once", strict, "alice", "alice", []string{RuleMinLength, RuleUpper, RuleDigit, RuleSymbol, RuleUsername}},
+	}
+
+	for _, tt := range tests {
+		if got := rulesBroken(t, &tt.policy, tt.password, tt.username); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: broke %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPolicyMaxBytes(t *testing.T) {
+	t.Setenv("PASSWORD_MIN_LENGTH", "")
+	t.Setenv("PASSWORD_MAX_LENGTH", "")
+	t.Setenv("PASSWORD_REQUIRE", "")
+	t.Setenv("PASSWORD_ALLOW_USERNAME", "")
+	t.Setenv("PASSWORD_BREACHED_FILE", "")
+
+	bcryptPolicy, err := FromEnv(bcryptHasher(bcrypt.MinCost))
+	if err != nil {
+		t.Fatal(err)
+	}
+	argon2Policy, err := FromEnv(argon2Hasher(cheapArgon2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bcryptPolicy.MaxBytes != BcryptMaxBytes || argon2Policy.MaxBytes != 0 {
+		t.Fatalf("MaxBytes = %d with bcrypt and %d with argon2id, want %d and none", bcryptPolicy.MaxBytes, argon2Policy.MaxBytes, BcryptMaxBytes)
+	}
+
+	// 40 characters, well within the maximum, but 80 bytes
+	long := strings.Repeat("é", 40)
+	if got := rulesBroken(t, bcryptPolicy, long, ""); !slices.Equal(got, []string{RuleMaxBytes}) {
+		t.Errorf("bcrypt policy broke %v for 80 bytes, want %v", got, []string{RuleMaxBytes})
+	}
+	if got := rulesBroken(t, argon2Policy, long, ""); got != nil {
+		t.Errorf("argon2id policy broke %v for 80 bytes, want none", got)
+	}
+
+	// Whatever the bcrypt policy accepts, bcrypt hashes without truncating
+	for _, password := range []string{strings.Repeat("é", 36), strings.Repeat("a", 64), strings.Repeat("€", 24)} {
+		if got := rulesBroken(t, bcryptPolicy, password, ""); got != nil {
+			t.Errorf("bcrypt policy broke %v for %d bytes", got, len(password))
+			continue
+		}
+		hash := mustHash(t, bcryptHasher(bcrypt.MinCost), password)
+		if bcryptHasher(bcrypt.MinCost).Verify(password[:len(password)-1], hash) {
+			t.Errorf("bcrypt ignored the end of a %d byte password", len(password))
+		}
+	}
+}
+
+func TestPolicyBreached(t *testing.T) {
+	hashes := sortedList(100)
+	list, err := writeList(t, strings.Join(hashes, "\n")+"\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := Policy{MinLength: 1, Breached: list}
+
+	// The list is sorted by hash, so find which passwords hash first and last
+	passwordOf := make(map[string]string)
+	for i := range 100 {
+		password := fmt.Sprintf("password%d", i)
+		passwordOf[sha1Hex(password)] = password
+	}
+
+	for _, password := range []string{passwordOf[hashes[0]], passwordOf[hashes[len(hashes)-1]], passwordOf[hashes[50]]} {
+		if got := rulesBroken(t, &p, password, ""); !slices.Equal(got, []string{RuleBreached}) {
+			t.Errorf("%q broke %v, want %v", password, got, []string{RuleBreached})
+		}
+	}
+	if got := rulesBroken(t, &p, "password100", ""); got != nil {
+		t.Errorf("password missing from the list broke %v", got)
+	}
+}
@@ -0,0 +1,49 @@
+package policy
+
+import (
+	"context"
+	"web-forum/internal/store"
+)
+
+// Permissions is what the signed-in user may do. It is loaded once per
+// request by the authentication middleware and is the one place deciding
+// who may change what.
+type Permissions struct {
+	UserID int
+	Role   store.Role
+	// Topics the user is assigned to moderate. Only consulted for moderators.
+	Topics map[int]bool
+}
+
+// Load looks up the topics a moderator is assigned to. Other roles need no
+// lookup.
+func Load(ctx context.Context, moderators store.ModeratorStore, user store.User) (Permissions, error) {
+	p := Permissions{UserID: user.ID, Role: user.Role, Topics: make(map[int]bool)}
+	if user.Role != store.RoleModerator {
+		return p, nil
+	}
+
+	topicIDs, err := moderators.ModeratedTopics(ctx, user.ID)
+	if err != nil {
+		return Permissions{}, err
+	}
+	for _, topicID := range topicIDs {
+		p.Topics[topicID] = true
+	}
+	return p, nil
+}
+
+func (p Permissions) IsAdmin() bool {
+	return p.Role == store.RoleAdmin
+}
+
+// Moderates reports whether the user may edit and delete anything in the topic
+func (p Permissions) Moderates(topicID int) bool {
+	return p.IsAdmin() || p.Role == store.RoleModerator && p.Topics[topicID]
+}
+
+// CanModify reports whether the user may edit or delete content created by
+// createdBy in the topic: their own content, or anything they moderate
+func (p Permissions) CanModify(createdBy, topicID int) bool {
+	return createdBy == p.UserID || p.Moderates(topicID)
+}
@@ -2,29 +2,47 @@ package router
 
 import (
 	"time"
-	"web-forum/internal/database"
 	"web-forum/internal/handlers"
+	"web-forum/internal/lockout"
+	"web-forum/internal/mailer"
 	"web-forum/internal/middleware"
+	"web-forum/internal/oidc"
+	"web-forum/internal/passwords"
+	"web-forum/internal/store"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
 
-func SetUpRouter() *gin.Engine {
+// SetUpRouter wires the routes to db, to m for the emails they send, to
+// provider for single sign-on, which is off if provider is nil, and to rules
+// for the passwords users choose. Only the proxies at trustedProxies, none if
+// it is empty, are believed about the address a request came from.
+func SetUpRouter(db store.Store, m mailer.Mailer, provider *oidc.Provider, rules *passwords.Policy, trustedProxies []string) (*gin.Engine, error) {
 	// Create a new gin router
 	router := gin.Default()
 
+	// Failed logins are counted per client address, so a client must not be
+	// able to pick its own with X-Forwarded-For
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		return nil, err
+	}
+
 	// This is to send cookies from frontend to backend and vice versa
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:5173", "https://forum.sahishnu.dev"},
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept"},
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
 
-	// Get database to connect
-	client := database.GetClient()
+	// Every protected route looks the token's user and their permissions up in the store
+	requireAuth := middleware.RequireAuthentication(db)
+	// Managing the account itself needs a password login, not a personal access token
+	requireSession := middleware.RequireSession()
+	// Failed password attempts are counted in memory, per username and per IP
+	guard := lockout.New(lockout.NewMemoryStore())
 
 	// Define routes
 	router.GET("/", func(c *gin.Context) {
@@ -36,34 +54,88 @@ func SetUpRouter() *gin.Engine {
 		c.JSON(200, gin.H{"status": "ok"})
 	})
 
+	// Public keys for verifying access tokens
+	router.GET("/.well-known/jwks.json", handlers.JWKS)
+
 	// Users
-	router.POST("/api/users/signup", handlers.SignUp(client))
-	router.POST("/api/users/login", handlers.Login(client))
-	router.GET("/api/users/validate", middleware.RequireAuthentication, handlers.Validate)
-	router.PUT("/api/users/changepassword", middleware.RequireAuthentication, handlers.ResetPassword(client))
-	router.POST("/api/users/logout", handlers.LogOut)
+	router.POST("/api/users/signup", handlers.SignUp(db, rules))
+	router.GET("/api/users/password-policy", handlers.GetPasswordPolicy(rules))
+	router.POST("/api/users/login", handlers.Login(db, db, db, guard, db))
+	router.POST("/api/users/login/2fa", handlers.VerifyTwoFactor(db, db, db, guard, db))
+	router.GET("/api/users/oidc", handlers.GetSingleSignOn(provider))
+	router.GET("/api/users/oidc/login", handlers.StartSingleSignOn(provider))
+	router.GET("/api/users/oidc/callback", handlers.FinishSingleSignOn(provider, db, db, db, db, db))
+	router.POST("/api/users/refresh", handlers.Refresh(db))
+	router.GET("/api/users/validate", requireAuth, handlers.Validate)
+	router.PUT("/api/users/changepassword", requireAuth, requireSession, handlers.ResetPassword(db, db, rules, guard, db))
+	router.POST("/api/users/logout", handlers.LogOut(db))
+	router.POST("/api/users/forgot-password", handlers.ForgotPassword(db, db, m))
+	router.POST("/api/users/reset-password", handlers.ResetForgottenPassword(db, db, db, rules, guard, db))
+	router.PUT("/api/users/email", requireAuth, requireSession, handlers.ChangeEmail(db, db, m, guard, db))
+	router.POST("/api/users/email/resend", requireAuth, requireSession, handlers.ResendVerification(db, m))
+	router.POST("/api/users/email/verify", handlers.VerifyEmail(db, db))
+	router.GET("/api/users/sessions", requireAuth, requireSession, handlers.GetSessions(db))
+	router.DELETE("/api/users/sessions", requireAuth, requireSession, handlers.RevokeAllSessions(db))
+	router.DELETE("/api/users/sessions/:id", requireAuth, requireSession, handlers.RevokeSession(db))
+	router.GET("/api/users/tokens", requireAuth, requireSession, handlers.GetPersonalTokens(db))
+	router.POST("/api/users/tokens", requireAuth, requireSession, handlers.CreatePersonalToken(db))
+	router.DELETE("/api/users/tokens/:id", requireAuth, requireSession, handlers.RevokePersonalToken(db))
+	router.GET("/api/users/2fa", requireAuth, requireSession, handlers.GetTwoFactor(db))
+	router.POST("/api/users/2fa/setup", requireAuth, requireSession, handlers.SetupTwoFactor(db))
+	router.POST("/api/users/2fa/confirm", requireAuth, requireSession, handlers.ConfirmTwoFactor(db, db))
+	router.POST("/api/users/2fa/disable", requireAuth, requireSession, handlers.DisableTwoFactor(db, db, guard, db))
+	router.POST("/api/users/2fa/recovery-codes", requireAuth, requireSession, handlers.RegenerateRecoveryCodes(db, db, guard, db))
+	router.GET("/api/users/:id", requireAuth, handlers.GetProfile(db))
+	router.PATCH("/api/users/:id", requireAuth, requireSession, handlers.UpdateProfile(db))
+	router.DELETE("/api/users/:id", requireAuth, requireSession, handlers.DeleteAccount(db, db, guard, db))
+	router.PUT("/api/users/:id/username", requireAuth, requireSession, handlers.ChangeUsername(db))
+	router.PUT("/api/users/:id/role", requireAuth, requireSession, handlers.SetUserRole(db))
+
+	// Audit log
+	router.GET("/api/audit", requireAuth, handlers.GetAuditLog(db))
+
+	// Categories
+	router.GET("/api/categories", requireAuth, handlers.GetCategories(db))
+	router.POST("/api/categories", requireAuth, handlers.CreateCategory(db))
+	router.PUT("/api/categories/:id", requireAuth, handlers.UpdateCategory(db))
+	router.DELETE("/api/categories/:id", requireAuth, handlers.DeleteCategory(db))
 
 	// Topics
-	router.GET("/api/topics", middleware.RequireAuthentication, handlers.GetTopics(client))
-	router.POST("/api/topics", middleware.RequireAuthentication, handlers.CreateTopic(client))
+	router.GET("/api/topics", requireAuth, handlers.GetTopics(db, db))
+	router.POST("/api/topics", requireAuth, handlers.CreateTopic(db))
+	router.GET("/api/topics/:id", requireAuth, handlers.GetTopic(db))
+	router.PUT("/api/topics/:id", requireAuth, handlers.UpdateTopic(db))
+	router.DELETE("/api/topics/:id", requireAuth, handlers.DeleteTopic(db))
+	router.GET("/api/topics/:id/moderators", requireAuth, handlers.GetModerators(db))
+	router.PUT("/api/topics/:id/moderators/:user_id", requireAuth, handlers.AddModerator(db, db))
+	router.DELETE("/api/topics/:id/moderators/:user_id", requireAuth, handlers.RemoveModerator(db))
 
 	// Posts
-	router.GET("/api/posts", middleware.RequireAuthentication, handlers.GetPosts(client))
-	router.GET("/api/posts/:id", middleware.RequireAuthentication, handlers.GetPost(client))
-	router.POST("/api/posts", middleware.RequireAuthentication, handlers.CreatePost(client))
-	router.PUT("/api/posts/:id", middleware.RequireAuthentication, handlers.UpdatePost(client))
-	router.DELETE("/api/posts/:id", middleware.RequireAuthentication, handlers.DeletePost(client))
+	router.GET("/api/posts", requireAuth, handlers.GetPosts(db))
+	router.GET("/api/posts/:id", requireAuth, handlers.GetPost(db))
+	router.POST("/api/posts", requireAuth, handlers.CreatePost(db, db))
+	router.PUT("/api/posts/:id", requireAuth, handlers.UpdatePost(db, db))
+	router.DELETE("/api/posts/:id", requireAuth, handlers.DeletePost(db, db))
+	router.PUT("/api/posts/:id/pinned", requireAuth, handlers.PinPost(db, db))
+	router.PUT("/api/posts/:id/locked", requireAuth, handlers.LockPost(db, db))
+	router.GET("/api/posts/:id/revisions", requireAuth, handlers.GetPostRevisions(db))
+	router.GET("/api/posts/:id/revisions/diff", requireAuth, handlers.GetPostRevisionDiff(db))
 
 	// Comments
-	router.GET("/api/comments", middleware.RequireAuthentication, handlers.GetComments(client))
-	router.GET("/api/comments/:id", middleware.RequireAuthentication, handlers.GetComment(client))
-	router.POST("/api/comments", middleware.RequireAuthentication, handlers.CreateComment(client))
-	router.PUT("/api/comments/:id", middleware.RequireAuthentication, handlers.UpdateComment(client))
-	router.DELETE("/api/comments/:id", middleware.RequireAuthentication, handlers.DeleteComment(client))
+	router.GET("/api/comments", requireAuth, handlers.GetComments(db))
+	router.GET("/api/comments/:id", requireAuth, handlers.GetComment(db))
+	router.POST("/api/comments", requireAuth, handlers.CreateComment(db, db, db))
+	router.PUT("/api/comments/:id", requireAuth, handlers.UpdateComment(db, db))
+	router.DELETE("/api/comments/:id", requireAuth, handlers.DeleteComment(db, db))
+	router.GET("/api/comments/:id/revisions", requireAuth, handlers.GetCommentRevisions(db))
+	router.GET("/api/comments/:id/revisions/diff", requireAuth, handlers.GetCommentRevisionDiff(db))
 
 	// Reactions
-	router.POST("/api/posts/:id/reactions", middleware.RequireAuthentication, handlers.CreatePostReaction(client))
-	router.POST("/api/comments/:id/reactions", middleware.RequireAuthentication, handlers.CreateCommentReaction(client))
+	router.POST("/api/posts/:id/reactions", requireAuth, handlers.CreatePostReaction(db, db, db))
+	router.POST("/api/comments/:id/reactions", requireAuth, handlers.CreateCommentReaction(db, db, db))
+
+	// Search
+	router.GET("/api/search", requireAuth, handlers.Search(db))
 
-	return router
+	return router, nil
 }
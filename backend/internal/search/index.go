@@ -0,0 +1,313 @@
+package search
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+	"web-forum/internal/store"
+)
+
+// Document is one topic, post or comment as the index sees it. Title is
+// weighted above Body when ranking.
+type Document struct {
+	Kind      store.SearchType
+	ID        int
+	TopicID   int
+	PostID    int
+	AuthorID  int
+	CreatedAt time.Time
+	Title     string
+	Body      string
+}
+
+// Filter narrows a search. Zero values match everything.
+type Filter struct {
+	Kinds    []store.SearchType
+	TopicID  int
+	AuthorID int
+	From     time.Time
+	To       time.Time
+}
+
+func (f Filter) matches(doc Document) bool {
+	if len(f.Kinds) > 0 {
+		found := false
+		for _, kind := range f.Kinds {
+			found = found || kind == doc.Kind
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if f.TopicID != 0 && doc.TopicID != f.TopicID {
+		return false
+	}
+	if f.AuthorID != 0 && doc.AuthorID != f.AuthorID {
+		return false
+	}
+	if !f.From.IsZero() && doc.CreatedAt.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && doc.CreatedAt.After(f.To) {
+		return false
+	}
+	return true
+}
+
+// Hit is a matching document with its relevance and a highlighted snippet
+type Hit struct {
+	Document
+	Score   float64
+	Snippet string
+}
+
+const (
+	titleWeight = 2
+	// BM25 parameters
+	k1 = 1.2
+	b  = 0.75
+)
+
+type key struct {
+	kind store.SearchType
+	id   int
+}
+
+type entry struct {
+	doc Document
+	// Weighted term frequencies and the weighted length they add up to
+	terms  map[string]int
+	length int
+}
+
+// Index is an in-process inverted index over topics, posts and comments,
+// ranked with BM25. It is safe for concurrent use.
+type Index struct {
+	mu       sync.RWMutex
+	docs     map[key]*entry
+	postings map[string]map[key]int
+	totalLen int
+}
+
+func NewIndex() *Index {
+	return &Index{
+		docs:     make(map[key]*entry),
+		postings: make(map[string]map[key]int),
+	}
+}
+
+// Put adds a document, replacing any earlier version of it
+func (ix *Index) Put(doc Document) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	k := key{doc.Kind, doc.ID}
+	ix.remove(k)
+
+	e := &entry{doc: doc, terms: make(map[string]int)}
+	for _, term := range Terms(doc.Title) {
+		e.terms[term] += titleWeight
+		e.length += titleWeight
+	}
+	for _, term := range Terms(doc.Body) {
+		e.terms[term]++
+		e.length++
+	}
+
+	for term, tf := range e.terms {
+		if ix.postings[term] == nil {
+			ix.postings[term] = make(map[key]int)
+		}
+		ix.postings[term][k] = tf
+	}
+	ix.docs[k] = e
+	ix.totalLen += e.length
+}
+
+func (ix *Index) Remove(kind store.SearchType, id int) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	ix.remove(key{kind, id})
+}
+
+// RemoveFunc removes every document match returns true for, e.g. the
+// comments of a deleted post
+func (ix *Index) RemoveFunc(match func(Document) bool) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	for k, e := range ix.docs {
+		if match(e.doc) {
+			ix.remove(k)
+		}
+	}
+}
+
+// remove drops a document. Callers must hold mu.
+func (ix *Index) remove(k key) {
+	e, ok := ix.docs[k]
+	if !ok {
+		return
+	}
+
+	for term := range e.terms {
+		delete(ix.postings[term], k)
+		if len(ix.postings[term]) == 0 {
+			delete(ix.postings, term)
+		}
+	}
+	ix.totalLen -= e.length
+	delete(ix.docs, k)
+}
+
+// Search returns the documents containing every term of text that pass the
+// filter, best match first
+func (ix *Index) Search(text string, filter Filter) []Hit {
+	terms := unique(Terms(text))
+	if len(terms) == 0 {
+		return nil
+	}
+
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	// Walk the rarest term's postings and check the others against them
+	sort.Slice(terms, func(i, j int) bool {
+		return len(ix.postings[terms[i]]) < len(ix.postings[terms[j]])
+	})
+
+	n := float64(len(ix.docs))
+	avgLen := float64(ix.totalLen) / math.Max(n, 1)
+
+	hits := make([]Hit, 0)
+	for k := range ix.postings[terms[0]] {
+		e := ix.docs[k]
+		if !filter.matches(e.doc) {
+			continue
+		}
+
+		score := 0.0
+		for _, term := range terms {
+			tf, ok := ix.postings[term][k]
+			if !ok {
+				score = -1
+				break
+			}
+
+			df := float64(len(ix.postings[term]))
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			norm := 1 - b + b*float64(e.length)/avgLen
+			score += idf * float64(tf) * (k1 + 1) / (float64(tf) + k1*norm)
+		}
+		if score < 0 {
+			continue
+		}
+
+		body := e.doc.Body
+		if e.doc.Kind == store.SearchTopic {
+			body = e.doc.Title
+		}
+		hits = append(hits, Hit{Document: e.doc, Score: score, Snippet: Snippet(body, terms)})
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		return store.SortRelevance.Before(hits[i].cursor(), hits[j].cursor())
+	})
+	return hits
+}
+
+func (h Hit) cursor() store.Cursor {
+	return store.Cursor{Sort: store.SortRelevance, Score: h.Score, Kind: h.Kind, ID: h.ID}
+}
+
+// Page runs a store query against the index and cuts out the page after
+// query.After. authorID is query.Author resolved to a user id. Title and
+// Username of the hits are left for the caller to fill in.
+func (ix *Index) Page(query store.SearchQuery, authorID int) store.Page[store.SearchHit] {
+	hits := ix.Search(query.Text, Filter{
+		Kinds:    query.Types,
+		TopicID:  query.TopicID,
+		AuthorID: authorID,
+		From:     query.From,
+		To:       query.To,
+	})
+
+	start := 0
+	if query.After != nil {
+		start = sort.Search(len(hits), func(i int) bool {
+			return store.SortRelevance.Before(*query.After, hits[i].cursor())
+		})
+	}
+
+	end := min(start+query.Limit, len(hits))
+	page := store.Page[store.SearchHit]{Items: make([]store.SearchHit, 0, end-start)}
+	for _, hit := range hits[start:end] {
+		page.Items = append(page.Items, store.SearchHit{
+			Type:      hit.Kind,
+			ID:        hit.ID,
+			TopicID:   hit.TopicID,
+			PostID:    hit.PostID,
+			Title:     hit.Title,
+			Snippet:   hit.Snippet,
+			CreatedBy: hit.AuthorID,
+			CreatedAt: hit.CreatedAt,
+			Rank:      hit.Score,
+		})
+	}
+	if end < len(hits) && end > start {
+		next := hits[end-1].cursor()
+		page.Next = &next
+	}
+	return page
+}
+
+func unique(terms []string) []string {
+	seen := make(map[string]bool)
+	out := terms[:0]
+	for _, term := range terms {
+		if !seen[term] {
+			seen[term] = true
+			out = append(out, term)
+		}
+	}
+	return out
+}
+
+func TopicDocument(topic store.Topic) Document {
+	return Document{
+		Kind:      store.SearchTopic,
+		ID:        topic.ID,
+		TopicID:   topic.ID,
+		AuthorID:  topic.CreatedBy,
+		CreatedAt: topic.CreatedAt,
+		Title:     topic.Title,
+	}
+}
+
+func PostDocument(post store.Post) Document {
+	return Document{
+		Kind:      store.SearchPost,
+		ID:        post.ID,
+		TopicID:   post.TopicID,
+		PostID:    post.ID,
+		AuthorID:  post.CreatedBy,
+		CreatedAt: post.CreatedAt,
+		Title:     post.Title,
+		Body:      post.Content,
+	}
+}
+
+func CommentDocument(comment store.Comment) Document {
+	return Document{
+		Kind:      store.SearchComment,
+		ID:        comment.ID,
+		TopicID:   comment.TopicID,
+		PostID:    comment.PostID,
+		AuthorID:  comment.CreatedBy,
+		CreatedAt: comment.CreatedAt,
+		Body:      comment.Content,
+	}
+}
@@ -0,0 +1,193 @@
+package search
+
+import (
+	"slices"
+	"testing"
+	"time"
+	"web-forum/internal/store"
+)
+
+var day = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// ids returns the kind and id of every hit, in order
+func ids(hits []Hit) []key {
+	keys := make([]key, len(hits))
+	for i, hit := range hits {
+		keys[i] = key{hit.Kind, hit.ID}
+	}
+	return keys
+}
+
+func post(id, topicID, authorID int, title, body string) Document {
+	return Document{Kind: store.SearchPost, ID: id, TopicID: topicID, PostID: id, AuthorID: authorID,
+		CreatedAt: day.AddDate(0, 0, id), Title: title, Body: body}
+}
+
+func comment(id, postID, topicID, authorID int, body string) Document {
+	return Document{Kind: store.SearchComment, ID: id, TopicID: topicID, PostID: postID, AuthorID: authorID,
+		CreatedAt: day.AddDate(0, 0, id), Body: body}
+}
+
+func TestSearchRanking(t *testing.T) {
+	ix := NewIndex()
+	ix.Put(post(1, 1, 1, "Gardening", "Tomatoes need sun and water"))
+	ix.Put(post(2, 1, 1, "Tomatoes", "How to grow them on a balcony"))
+	ix.Put(post(3, 1, 1, "Cooking", "Tomatoes, tomatoes and more tomatoes in every sauce"))
+	ix.Put(post(4, 1, 1, "Cooking", "Pasta with sauce"))
+
+	hits := ix.Search("tomato", Filter{})
+	// A title match counts twice, and repeats count for less than their number
+	want := []key{{store.SearchPost, 3}, {store.SearchPost, 2}, {store.SearchPost, 1}}
+	if got := ids(hits); !slices.Equal(got, want) {
+		t.Fatalf("Search(tomato) = %v, want %v", got, want)
+	}
+	for i := 1; i < len(hits); i++ {
+		if hits[i].Score > hits[i-1].Score {
+			t.Errorf("hit %d scores %f above hit %d at %f", i, hits[i].Score, i-1, hits[i-1].Score)
+		}
+	}
+
+	// Every term has to match
+	if got := ids(ix.Search("tomatoes sauce", Filter{})); !slices.Equal(got, []key{{store.SearchPost, 3}}) {
+		t.Errorf("Search(tomatoes sauce) = %v, want only post 3", got)
+	}
+	// A rare term ranks its documents above a common one's
+	ix.Put(post(5, 1, 1, "", "sauce balcony"))
+	hits = ix.Search("balcony", Filter{})
+	if got := ids(hits); len(got) != 2 {
+		t.Fatalf("Search(balcony) = %v, want 2 hits", got)
+	}
+	if sauce := ix.Search("sauce", Filter{}); hits[0].Score <= sauce[0].Score {
+		t.Errorf("rare term scores %f, not above the common one's %f", hits[0].Score, sauce[0].Score)
+	}
+
+	for _, text := range []string{"", "the and", "unknown", "tomato unknown"} {
+		if hits := ix.Search(text, Filter{}); len(hits) != 0 {
+			t.Errorf("Search(%q) = %v, want nothing", text, ids(hits))
+		}
+	}
+}
+
+func TestSearchEqualScores(t *testing.T) {
+	ix := NewIndex()
+	for id := 1; id <= 5; id++ {
+		ix.Put(post(id, 1, 1, "", "same words"))
+	}
+
+	// Ties are broken the same way every time
+	first := ids(ix.Search("same", Filter{}))
+	for range 10 {
+		if got := ids(ix.Search("same", Filter{})); !slices.Equal(got, first) {
+			t.Fatalf("Search order changed from %v to %v", first, got)
+		}
+	}
+}
+
+func TestSearchFilter(t *testing.T) {
+	ix := NewIndex()
+	ix.Put(Document{Kind: store.SearchTopic, ID: 1, TopicID: 1, AuthorID: 1, CreatedAt: day, Title: "Bikes"})
+	ix.Put(post(2, 1, 2, "Bikes", "road bikes"))
+	ix.Put(post(3, 2, 1, "Bikes", "mountain bikes"))
+	ix.Put(comment(4, 2, 1, 2, "bikes are great"))
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []int
+	}{
+		{"everything", Filter{}, []int{1, 2, 3, 4}},
+		{"kinds", Filter{Kinds: []store.SearchType{store.SearchPost, store.SearchComment}}, []int{2, 3, 4}},
+		{"topic", Filter{TopicID: 1}, []int{1, 2, 4}},
+		{"author", Filter{AuthorID: 2}, []int{2, 4}},
+		{"from", Filter{From: day.AddDate(0, 0, 3)}, []int{3, 4}},
+		{"to", Filter{To: day.AddDate(0, 0, 2)}, []int{1, 2}},
+		{"all of them", Filter{Kinds: []store.SearchType{store.SearchPost}, TopicID: 1, AuthorID: 2, To: day.AddDate(0, 0, 2)}, []int{2}},
+	}
+
+	for _, tt := range tests {
+		got := make([]int, 0)
+		for _, hit := range ix.Search("bikes", tt.filter) {
+			got = append(got, hit.ID)
+		}
+		slices.Sort(got)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: hits %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIndexUpdates(t *testing.T) {
+	ix := NewIndex()
+	ix.Put(post(1, 1, 1, "Old title", "old words"))
+	ix.Put(post(2, 1, 1, "Other", "other words"))
+
+	// An edit replaces the earlier version, leaving nothing of it behind
+	ix.Put(post(1, 1, 1, "New title", "new words"))
+	if hits := ix.Search("old", Filter{}); len(hits) != 0 {
+		t.Errorf("Search(old) after the edit = %v, want nothing", ids(hits))
+	}
+	if got := ids(ix.Search("new", Filter{})); !slices.Equal(got, []key{{store.SearchPost, 1}}) {
+		t.Errorf("Search(new) after the edit = %v, want post 1", got)
+	}
+	if got := ix.Search("new", Filter{}); got[0].Snippet != "<mark>new</mark> words" {
+		t.Errorf("snippet after the edit = %q", got[0].Snippet)
+	}
+
+	// A post and a comment with the same id are different documents
+	ix.Put(comment(1, 2, 1, 1, "words in a comment"))
+	if got := ix.Search("words", Filter{}); len(got) != 3 {
+		t.Errorf("Search(words) = %v, want 3 hits", ids(got))
+	}
+
+	ix.Remove(store.SearchPost, 1)
+	ix.Remove(store.SearchPost, 1)
+	ix.Remove(store.SearchTopic, 99)
+	if got := ids(ix.Search("words", Filter{})); len(got) != 2 || slices.Contains(got, key{store.SearchPost, 1}) {
+		t.Errorf("Search(words) after removing post 1 = %v", got)
+	}
+	if _, ok := ix.postings["new"]; ok {
+		t.Errorf("the terms of a removed document are still indexed")
+	}
+
+	// Removing the comments of a deleted post, as stores do
+	ix.RemoveFunc(func(doc Document) bool { return doc.PostID == 2 })
+	if len(ix.docs) != 0 || len(ix.postings) != 0 || ix.totalLen != 0 {
+		t.Errorf("index after removing everything: %d documents, %d terms, length %d",
+			len(ix.docs), len(ix.postings), ix.totalLen)
+	}
+}
+
+func TestIndexPage(t *testing.T) {
+	ix := NewIndex()
+	for id := 1; id <= 7; id++ {
+		ix.Put(post(id, 1, 1, "", "page"))
+	}
+	ix.Put(post(8, 1, 1, "Page", "page page"))
+
+	all := ids(ix.Search("page", Filter{}))
+	var got []key
+	query := store.SearchQuery{Text: "page", Limit: 3}
+	for pages := 0; ; pages++ {
+		if pages > len(all) {
+			t.Fatalf("Page did not run out of pages")
+		}
+		page := ix.Page(query, 0)
+		if len(page.Items) > query.Limit {
+			t.Fatalf("page of %d hits, limit %d", len(page.Items), query.Limit)
+		}
+		for _, hit := range page.Items {
+			got = append(got, key{hit.Type, hit.ID})
+		}
+		if page.Next == nil {
+			break
+		}
+		query.After = page.Next
+	}
+
+	if !slices.Equal(got, all) {
+		t.Errorf("pages = %v, want %v", got, all)
+	}
+	if all[0] != (key{store.SearchPost, 8}) {
+		t.Errorf("best match = %v, want post 8", all[0])
+	}
+}
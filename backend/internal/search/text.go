@@ -0,0 +1,161 @@
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Snippets mark matches with these runes before they are escaped, so the
+// <mark> tags are the only markup that reaches clients. Postgres headlines
+// use them as StartSel and StopSel.
+const (
+	MatchStart = "\x01"
+	MatchStop  = "\x02"
+)
+
+const (
+	// Words of context kept before the first match and in total
+	snippetLead  = 8
+	snippetWords = 30
+)
+
+// Same spirit as the Postgres english configuration, which drops these too
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "by": true, "for": true, "from": true, "in": true, "is": true,
+	"it": true, "of": true, "on": true, "or": true, "that": true, "the": true,
+	"this": true, "to": true, "was": true, "with": true,
+}
+
+type token struct {
+	term       string
+	start, end int
+}
+
+// tokenize splits text into words and normalises them, keeping their byte
+// offsets so snippets can be cut from the original text
+func tokenize(text string) []token {
+	var tokens []token
+	start := -1
+	for i, r := range text + " " {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '\'' && start >= 0 {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			if term := normalise(text[start:i]); term != "" {
+				tokens = append(tokens, token{term, start, i})
+			}
+			start = -1
+		}
+	}
+	return tokens
+}
+
+// Terms returns the index terms of text in order
+func Terms(text string) []string {
+	tokens := tokenize(text)
+	terms := make([]string, len(tokens))
+	for i, t := range tokens {
+		terms[i] = t.term
+	}
+	return terms
+}
+
+// normalise lower-cases a word, drops stop words and strips the commonest
+// English suffixes so "posts" finds "post" and "replied" finds "reply"
+func normalise(word string) string {
+	word = strings.ToLower(strings.TrimRight(word, "'"))
+	word = strings.TrimSuffix(word, "'s")
+	if stopWords[word] {
+		return ""
+	}
+
+	n := utf8.RuneCountInString(word)
+	switch {
+	case n > 4 && strings.HasSuffix(word, "ies"):
+		return strings.TrimSuffix(word, "ies") + "y"
+	case n > 4 && strings.HasSuffix(word, "ied"):
+		return strings.TrimSuffix(word, "ied") + "y"
+	case n > 4 && hasAnySuffix(word, "oes", "ches", "shes", "sses", "xes", "zes"):
+		return strings.TrimSuffix(word, "es")
+	case n > 5 && strings.HasSuffix(word, "ing"):
+		return strings.TrimSuffix(word, "ing")
+	case n > 4 && strings.HasSuffix(word, "ed"):
+		return strings.TrimSuffix(word, "ed")
+	case n > 3 && strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss"):
+		return strings.TrimSuffix(word, "s")
+	}
+	return word
+}
+
+func hasAnySuffix(word string, suffixes ...string) bool {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(word, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Snippet cuts the part of text around the first match of terms and marks
+// every match in it
+func Snippet(text string, terms []string) string {
+	text = strings.NewReplacer(MatchStart, "", MatchStop, "").Replace(text)
+	wanted := make(map[string]bool, len(terms))
+	for _, term := range terms {
+		wanted[term] = true
+	}
+
+	tokens := tokenize(text)
+	first := 0
+	for i, t := range tokens {
+		if wanted[t.term] {
+			first = i
+			break
+		}
+	}
+
+	from := max(first-snippetLead, 0)
+	to := min(from+snippetWords, len(tokens))
+	if to-from < snippetWords {
+		from = max(to-snippetWords, 0)
+	}
+
+	var sb strings.Builder
+	pos := 0
+	if from > 0 {
+		pos = tokens[from].start
+		sb.WriteString("…")
+	}
+	end := len(text)
+	if to < len(tokens) {
+		end = tokens[to-1].end
+	}
+
+	for _, t := range tokens[from:to] {
+		if !wanted[t.term] {
+			continue
+		}
+		sb.WriteString(text[pos:t.start])
+		sb.WriteString(MatchStart + text[t.start:t.end] + MatchStop)
+		pos = t.end
+	}
+	sb.WriteString(text[pos:end])
+	if end < len(text) {
+		sb.WriteString("…")
+	}
+	return Highlight(sb.String())
+}
+
+// Highlight escapes a snippet whose matches are delimited by MatchStart and
+// MatchStop and wraps the matches in <mark> tags
+func Highlight(snippet string) string {
+	snippet = html.EscapeString(snippet)
+	snippet = strings.ReplaceAll(snippet, MatchStart, "<mark>")
+	return strings.ReplaceAll(snippet, MatchStop, "</mark>")
+}
@@ -0,0 +1,80 @@
+package search
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestTerms(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"", []string{}},
+		{"Hello, World!", []string{"hello", "world"}},
+		{"the cat and a dog", []string{"cat", "dog"}},
+		{"a an the", []string{}},
+		{"Replies were REPLIED", []string{"reply", "were", "reply"}},
+		{"posting posts post", []string{"post", "post", "post"}},
+		{"boxes classes class", []string{"box", "class", "class"}},
+		{"Alice's book, don't it's", []string{"alice", "book", "don't"}},
+		{"'quoted'", []string{"quot"}},
+		{"Über café-bar", []string{"über", "café", "bar"}},
+		{"version 2024 v2", []string{"version", "2024", "v2"}},
+		{"is bus gas", []string{"bus", "gas"}},
+	}
+
+	for _, tt := range tests {
+		if got := Terms(tt.text); !slices.Equal(got, tt.want) {
+			t.Errorf("Terms(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestTokenizeOffsets(t *testing.T) {
+	text := "Ça va, Élodie's friends?"
+	for _, tok := range tokenize(text) {
+		if got := normalise(text[tok.start:tok.end]); got != tok.term {
+			t.Errorf("token %q at %d:%d reads %q in the text", tok.term, tok.start, tok.end, got)
+		}
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	long := strings.Repeat("filler ", 40) + "needle " + strings.Repeat("padding ", 40)
+
+	tests := []struct {
+		name  string
+		text  string
+		terms []string
+		want  string
+	}{
+		{"marks every match", "Hello world, hello again", []string{"hello"},
+			"<mark>Hello</mark> world, <mark>hello</mark> again"},
+		{"marks normalised matches", "She replied to the replies", []string{"reply"},
+			"She <mark>replied</mark> to the <mark>replies</mark>"},
+		{"escapes html", "<b>bold</b> & <i>more</i>", []string{"bold"},
+			"&lt;b&gt;<mark>bold</mark>&lt;/b&gt; &amp; &lt;i&gt;more&lt;/i&gt;"},
+		{"drops markers in the text", "a \x01fake\x02 match", []string{"match"},
+			"a fake <mark>match</mark>"},
+		{"no match keeps the start", "nothing here", []string{"absent"}, "nothing here"},
+		{"empty text", "", []string{"word"}, ""},
+		{"cuts around the match", long, []string{"needle"},
+			"…" + strings.Repeat("filler ", snippetLead) + "<mark>needle</mark> " +
+				strings.TrimSuffix(strings.Repeat("padding ", snippetWords-snippetLead-1), " ") + "…"},
+	}
+
+	for _, tt := range tests {
+		if got := Snippet(tt.text, tt.terms); got != tt.want {
+			t.Errorf("%s: Snippet = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	got := Highlight("x < " + MatchStart + "y" + MatchStop + " & z")
+	if want := "x &lt; <mark>y</mark> &amp; z"; got != want {
+		t.Errorf("Highlight = %q, want %q", got, want)
+	}
+}
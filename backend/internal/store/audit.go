@@ -0,0 +1,47 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// Audit actions
+const (
+	// AuditLoginLockout is recorded when failed logins lock out a username or IP address
+	AuditLoginLockout = "login.lockout"
+	// AuditTwoFactorEnabled and AuditTwoFactorDisabled record changes to a
+	// user's two-factor authentication
+	AuditTwoFactorEnabled  = "2fa.enabled"
+	AuditTwoFactorDisabled = "2fa.disabled"
+	// AuditRecoveryCodeUsed is recorded when a recovery code stands in for
+	// the authenticator app
+	AuditRecoveryCodeUsed = "2fa.recovery_code_used"
+	// AuditPasswordReset is recorded when a password is reset through an
+	// emailed link
+	AuditPasswordReset = "password.reset"
+	// AuditIdentityLinked is recorded when single sign-on links a provider
+	// account to an existing user
+	AuditIdentityLinked = "sso.linked"
+	// AuditAccountDeleted is recorded when an account is deleted, by its owner
+	// or an admin. Detail names the deletion policy.
+	AuditAccountDeleted = "account.deleted"
+)
+
+// AuditEntry records a security-relevant event. UserID is the user it
+// concerns, if any; Subject names what it concerns otherwise, e.g. an IP.
+type AuditEntry struct {
+	ID        int       `json:"id"`
+	Action    string    `json:"action"`
+	UserID    *int      `json:"user_id"`
+	Subject   string    `json:"subject"`
+	IPAddress string    `json:"ip_address"`
+	Detail    string    `json:"detail"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type AuditStore interface {
+	RecordAudit(ctx context.Context, entry AuditEntry) error
+	// ListAudit returns the latest entries, newest first, older than the
+	// entry with id before if before is not 0
+	ListAudit(ctx context.Context, before, limit int) ([]AuditEntry, error)
+}
@@ -0,0 +1,76 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+)
+
+// ErrCategoryCycle is returned when a category would be moved under itself or
+// one of its own subcategories
+var ErrCategoryCycle = errors.New("store: category cycle")
+
+// Category groups topics. Categories nest through ParentID, top-level ones
+// have none, and siblings are listed by Position, then by id.
+type Category struct {
+	ID          int       `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	ParentID    *int      `json:"parent_id"`
+	Position    int       `json:"position"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// CategoryNode is a category with its subcategories, as laid out by CategoryTree
+type CategoryNode struct {
+	Category
+	Children []CategoryNode `json:"children"`
+}
+
+// CategoryTree nests categories under their parents, siblings in order.
+// Categories whose parent is not among them are left out.
+func CategoryTree(categories []Category) []CategoryNode {
+	children := make(map[int][]Category)
+	var roots []Category
+	for _, category := range categories {
+		if category.ParentID == nil {
+			roots = append(roots, category)
+		} else {
+			children[*category.ParentID] = append(children[*category.ParentID], category)
+		}
+	}
+
+	var build func(siblings []Category) []CategoryNode
+	build = func(siblings []Category) []CategoryNode {
+		sort.Slice(siblings, func(i, j int) bool {
+			if siblings[i].Position != siblings[j].Position {
+				return siblings[i].Position < siblings[j].Position
+			}
+			return siblings[i].ID < siblings[j].ID
+		})
+
+		nodes := make([]CategoryNode, len(siblings))
+		for i, category := range siblings {
+			nodes[i] = CategoryNode{Category: category, Children: build(children[category.ID])}
+		}
+		return nodes
+	}
+	return build(roots)
+}
+
+type CategoryStore interface {
+	ListCategories(ctx context.Context) ([]Category, error)
+	GetCategory(ctx context.Context, id int) (Category, error)
+	// CreateCategory returns ErrNotFound if the parent does not exist and
+	// ErrConflict if a sibling has the name
+	CreateCategory(ctx context.Context, name, description string, parentID *int, position int) (Category, error)
+	// UpdateCategory also returns ErrCategoryCycle if parentID is the
+	// category or below it
+	UpdateCategory(ctx context.Context, id int, name, description string, parentID *int, position int) (Category, error)
+	// DeleteCategory moves the category's subcategories and topics up to its
+	// parent, or to the top level, and deletes it. It returns ErrConflict if
+	// a subcategory has the name of one it would move next to, or of the
+	// category itself.
+	DeleteCategory(ctx context.Context, id int) error
+}
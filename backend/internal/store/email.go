@@ -0,0 +1,33 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// EmailPurpose is what a link sent by email is for
+type EmailPurpose string
+
+const (
+	EmailVerify EmailPurpose = "verify"
+	EmailReset  EmailPurpose = "reset"
+)
+
+// EmailToken is a single-use token sent to Email for Purpose. The store keeps
+// its hash, like refresh tokens.
+type EmailToken struct {
+	UserID    int
+	Purpose   EmailPurpose
+	Email     string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+type EmailTokenStore interface {
+	// CreateEmailToken stores a new token, throwing away the user's unused
+	// tokens for the same purpose so only the latest link works
+	CreateEmailToken(ctx context.Context, tokenHash string, token EmailToken) error
+	// UseEmailToken spends a token. It returns ErrNotFound if the token is
+	// unknown, for another purpose, used or expired.
+	UseEmailToken(ctx context.Context, tokenHash string, purpose EmailPurpose) (EmailToken, error)
+}
@@ -0,0 +1,21 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// Identity links an account at an OpenID Connect provider to a user
+type Identity struct {
+	Issuer    string
+	Subject   string
+	UserID    int
+	CreatedAt time.Time
+}
+
+type IdentityStore interface {
+	// GetIdentity returns ErrNotFound if nobody has signed in with the subject yet
+	GetIdentity(ctx context.Context, issuer, subject string) (Identity, error)
+	// LinkIdentity returns ErrConflict if the subject is linked to a user already
+	LinkIdentity(ctx context.Context, identity Identity) error
+}
@@ -0,0 +1,29 @@
+package memory
+
+import (
+	"context"
+	"web-forum/internal/store"
+)
+
+func (s *Store) RecordAudit(ctx context.Context, entry store.AuditEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry.ID = s.nextID("audit_log")
+	entry.CreatedAt = now()
+	s.audit = append(s.audit, entry)
+	return nil
+}
+
+func (s *Store) ListAudit(ctx context.Context, before, limit int) ([]store.AuditEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entries := make([]store.AuditEntry, 0)
+	for i := len(s.audit) - 1; i >= 0 && len(entries) < limit; i-- {
+		if before == 0 || s.audit[i].ID < before {
+			entries = append(entries, s.audit[i])
+		}
+	}
+	return entries, nil
+}
@@ -0,0 +1,165 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"web-forum/internal/store"
+)
+
+func (s *Store) ListCategories(ctx context.Context) ([]store.Category, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	categories := make([]store.Category, 0, len(s.categories))
+	for _, category := range s.categories {
+		categories = append(categories, category)
+	}
+
+	sort.Slice(categories, func(i, j int) bool {
+		if categories[i].Position != categories[j].Position {
+			return categories[i].Position < categories[j].Position
+		}
+		return categories[i].ID < categories[j].ID
+	})
+	return categories, nil
+}
+
+func (s *Store) GetCategory(ctx context.Context, id int) (store.Category, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	category, ok := s.categories[id]
+	if !ok {
+		return store.Category{}, store.ErrNotFound
+	}
+	return category, nil
+}
+
+func (s *Store) CreateCategory(ctx context.Context, name, description string, parentID *int, position int) (store.Category, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if parentID != nil {
+		if _, ok := s.categories[*parentID]; !ok {
+			return store.Category{}, store.ErrNotFound
+		}
+	}
+	parentID = copyInt(parentID)
+	if s.siblingNamed(0, parentID, name) {
+		return store.Category{}, store.ErrConflict
+	}
+
+	category := store.Category{
+		ID:          s.nextID("categories"),
+		Name:        name,
+		Description: description,
+		ParentID:    parentID,
+		Position:    position,
+		CreatedAt:   now(),
+	}
+	s.categories[category.ID] = category
+
+	return category, nil
+}
+
+func (s *Store) UpdateCategory(ctx context.Context, id int, name, description string, parentID *int, position int) (store.Category, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	category, ok := s.categories[id]
+	if !ok {
+		return store.Category{}, store.ErrNotFound
+	}
+	if parentID != nil {
+		if _, ok := s.categories[*parentID]; !ok {
+			return store.Category{}, store.ErrNotFound
+		}
+		if s.inCategory(parentID, id) {
+			return store.Category{}, store.ErrCategoryCycle
+		}
+	}
+	parentID = copyInt(parentID)
+	if s.siblingNamed(id, parentID, name) {
+		return store.Category{}, store.ErrConflict
+	}
+
+	category.Name = name
+	category.Description = description
+	category.ParentID = parentID
+	category.Position = position
+	s.categories[id] = category
+
+	return category, nil
+}
+
+// DeleteCategory moves subcategories and topics up a level, like the SQL
+// stores, and turns the move down if it would put two categories with the
+// same name next to each other, counting the category itself as the unique
+// index does
+func (s *Store) DeleteCategory(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	category, ok := s.categories[id]
+	if !ok {
+		return store.ErrNotFound
+	}
+
+	for _, child := range s.categories {
+		if child.ParentID != nil && *child.ParentID == id && s.siblingNamed(0, category.ParentID, child.Name) {
+			return store.ErrConflict
+		}
+	}
+
+	for childID, child := range s.categories {
+		if child.ParentID != nil && *child.ParentID == id {
+			child.ParentID = copyInt(category.ParentID)
+			s.categories[childID] = child
+		}
+	}
+	for topicID, topic := range s.topics {
+		if topic.CategoryID != nil && *topic.CategoryID == id {
+			topic.CategoryID = copyInt(category.ParentID)
+			s.topics[topicID] = topic
+		}
+	}
+	delete(s.categories, id)
+
+	return nil
+}
+
+// siblingNamed reports whether a category other than id is called name under
+// parentID, like the unique index on categories. Callers must hold mu.
+func (s *Store) siblingNamed(id int, parentID *int, name string) bool {
+	for _, category := range s.categories {
+		if category.ID == id || category.Name != name {
+			continue
+		}
+		if category.ParentID == nil && parentID == nil ||
+			category.ParentID != nil && parentID != nil && *category.ParentID == *parentID {
+			return true
+		}
+	}
+	return false
+}
+
+// inCategory reports whether categoryID is the category id or one below it,
+// walking up from categoryID. Callers must hold mu.
+func (s *Store) inCategory(categoryID *int, id int) bool {
+	for ancestor := categoryID; ancestor != nil; ancestor = s.categories[*ancestor].ParentID {
+		if *ancestor == id {
+			return true
+		}
+	}
+	return false
+}
+
+// copyInt copies an optional id, so stored rows never share it with callers
+func copyInt(v *int) *int {
+	if v == nil {
+		return nil
+	}
+
+	i := *v
+	return &i
+}
@@ -0,0 +1,153 @@
+package memory
+
+import (
+	"context"
+	"web-forum/internal/search"
+	"web-forum/internal/store"
+)
+
+// withCommentReactions returns a copy of the comment with its author and reaction
+// counts filled in. Callers must hold mu.
+func (s *Store) withCommentReactions(comment store.Comment, viewerID int) store.Comment {
+	comment.Username = s.users[comment.CreatedBy].Username
+	comment.LikeCount, comment.DislikeCount, comment.UserReaction = tally(s.commentReactions[comment.ID], viewerID)
+	comment.NetScore = comment.LikeCount - comment.DislikeCount
+	return comment
+}
+
+func (s *Store) ListComments(ctx context.Context, postID, viewerID int, opts store.ListOptions) (store.Page[store.Comment], error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	comments := make([]store.Comment, 0)
+	for _, comment := range s.comments {
+		if comment.PostID == postID {
+			comments = append(comments, s.withCommentReactions(comment, viewerID))
+		}
+	}
+
+	return paginate(comments, opts), nil
+}
+
+func (s *Store) ListThread(ctx context.Context, postID, viewerID int, opts store.ListOptions) (store.Page[store.ThreadComment], error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	roots := make([]store.Comment, 0)
+	replies := make([]store.Comment, 0)
+	for _, comment := range s.comments {
+		if comment.PostID != postID {
+			continue
+		}
+
+		if comment.ParentID == nil {
+			roots = append(roots, s.withCommentReactions(comment, viewerID))
+		} else {
+			replies = append(replies, s.withCommentReactions(comment, viewerID))
+		}
+	}
+
+	page := paginate(roots, opts)
+	return store.Page[store.ThreadComment]{Items: store.Thread(page.Items, replies), Next: page.Next}, nil
+}
+
+func (s *Store) GetComment(ctx context.Context, id, viewerID int) (store.Comment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	comment, ok := s.comments[id]
+	if !ok {
+		return store.Comment{}, store.ErrNotFound
+	}
+	return s.withCommentReactions(comment, viewerID), nil
+}
+
+func (s *Store) CreateComment(ctx context.Context, postID int, parentID *int, content string, createdBy int) (store.Comment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.posts[postID]; !ok {
+		return store.Comment{}, store.ErrNotFound
+	}
+	if _, ok := s.users[createdBy]; !ok {
+		return store.Comment{}, store.ErrNotFound
+	}
+	if parentID != nil {
+		if parent, ok := s.comments[*parentID]; !ok || parent.PostID != postID {
+			return store.Comment{}, store.ErrInvalidParent
+		}
+
+		id := *parentID
+		parentID = &id
+	}
+
+	createdAt := now()
+	comment := store.Comment{
+		ID:        s.nextID("comments"),
+		PostID:    postID,
+		TopicID:   s.posts[postID].TopicID,
+		ParentID:  parentID,
+		Content:   content,
+		CreatedBy: createdBy,
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+	}
+	s.comments[comment.ID] = comment
+	s.commentRevisions[comment.ID] = addRevision(nil, store.Revision{
+		Content: content, EditedBy: createdBy, EditedAt: createdAt,
+	})
+	s.index.Put(search.CommentDocument(comment))
+
+	return s.withCommentReactions(comment, createdBy), nil
+}
+
+func (s *Store) UpdateComment(ctx context.Context, id int, content string, editedBy int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	comment, ok := s.comments[id]
+	if !ok {
+		return store.ErrNotFound
+	}
+	if _, ok := s.users[editedBy]; !ok {
+		return store.ErrNotFound
+	}
+
+	comment.Content = content
+	comment.UpdatedAt = now()
+	s.comments[id] = comment
+	s.commentRevisions[id] = addRevision(s.commentRevisions[id], store.Revision{
+		Content: content, EditedBy: editedBy, EditedAt: comment.UpdatedAt,
+	})
+	s.index.Put(search.CommentDocument(comment))
+	return nil
+}
+
+func (s *Store) DeleteComment(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.comments[id]; !ok {
+		return store.ErrNotFound
+	}
+
+	s.deleteComment(id)
+	return nil
+}
+
+// deleteComment removes a comment with its reactions and revisions, and
+// moves the replies to it up to its parent. Callers must hold mu.
+func (s *Store) deleteComment(id int) {
+	parentID := s.comments[id].ParentID
+	for replyID, reply := range s.comments {
+		if reply.ParentID != nil && *reply.ParentID == id {
+			reply.ParentID = copyInt(parentID)
+			s.comments[replyID] = reply
+		}
+	}
+
+	delete(s.commentReactions, id)
+	delete(s.commentRevisions, id)
+	delete(s.comments, id)
+	s.index.Remove(store.SearchComment, id)
+}
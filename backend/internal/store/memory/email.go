@@ -0,0 +1,44 @@
+package memory
+
+import (
+	"context"
+	"web-forum/internal/store"
+)
+
+type emailToken struct {
+	store.EmailToken
+	used bool
+}
+
+func (s *Store) CreateEmailToken(ctx context.Context, tokenHash string, token store.EmailToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[token.UserID]; !ok {
+		return store.ErrNotFound
+	}
+
+	for hash, t := range s.emailTokens {
+		if t.UserID == token.UserID && t.Purpose == token.Purpose && !t.used {
+			delete(s.emailTokens, hash)
+		}
+	}
+
+	token.CreatedAt = now()
+	s.emailTokens[tokenHash] = emailToken{EmailToken: token}
+	return nil
+}
+
+func (s *Store) UseEmailToken(ctx context.Context, tokenHash string, purpose store.EmailPurpose) (store.EmailToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.emailTokens[tokenHash]
+	if !ok || t.Purpose != purpose || t.used || !now().Before(t.ExpiresAt) {
+		return store.EmailToken{}, store.ErrNotFound
+	}
+
+	t.used = true
+	s.emailTokens[tokenHash] = t
+	return t.EmailToken, nil
+}
@@ -0,0 +1,40 @@
+package memory
+
+import (
+	"context"
+	"web-forum/internal/store"
+)
+
+type identityKey struct {
+	issuer  string
+	subject string
+}
+
+func (s *Store) GetIdentity(ctx context.Context, issuer, subject string) (store.Identity, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	identity, ok := s.identities[identityKey{issuer, subject}]
+	if !ok {
+		return store.Identity{}, store.ErrNotFound
+	}
+	return identity, nil
+}
+
+func (s *Store) LinkIdentity(ctx context.Context, identity store.Identity) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[identity.UserID]; !ok {
+		return store.ErrNotFound
+	}
+
+	key := identityKey{identity.Issuer, identity.Subject}
+	if _, ok := s.identities[key]; ok {
+		return store.ErrConflict
+	}
+
+	identity.CreatedAt = now()
+	s.identities[key] = identity
+	return nil
+}
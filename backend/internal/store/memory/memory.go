@@ -0,0 +1,142 @@
+package memory
+
+import (
+	"sort"
+	"sync"
+	"time"
+	"web-forum/internal/search"
+	"web-forum/internal/store"
+)
+
+// Store implements store.Store in process memory. Everything is lost on
+// restart, which makes it suitable for local development and tests only.
+type Store struct {
+	mu sync.RWMutex
+
+	users     map[int]store.User
+	usernames map[string]int
+	emails    map[string]int
+
+	sessions map[int]store.Session
+	// Keyed by token hash, like the refresh_tokens table
+	refreshTokens map[string]refreshToken
+
+	personalTokens map[int]store.PersonalToken
+	// Token hash to token id, like the unique token_hash column
+	personalTokenHashes map[string]int
+
+	totp map[int]store.TOTP
+	// Keyed by user id, then code hash; true once used
+	recoveryCodes map[int]map[string]bool
+
+	// Keyed by token hash, like the email_tokens table
+	emailTokens map[string]emailToken
+
+	identities map[identityKey]store.Identity
+
+	categories map[int]store.Category
+
+	topics      map[int]store.Topic
+	topicTitles map[string]int
+	// Keyed by topic id, then user id
+	moderators map[int]map[int]bool
+
+	posts    map[int]store.Post
+	comments map[int]store.Comment
+
+	// Keyed by post/comment id, then user id, like the unique
+	// (post_id, user_id) constraint on the reaction tables
+	postReactions    map[int]map[int]int
+	commentReactions map[int]map[int]int
+
+	// Keyed by post/comment id, oldest first
+	postRevisions    map[int][]store.Revision
+	commentRevisions map[int][]store.Revision
+
+	// Oldest first, like the ids of the audit_log table
+	audit []store.AuditEntry
+
+	// Last id handed out per table, like a serial column
+	sequences map[string]int
+
+	index *search.Index
+}
+
+var _ store.Store = (*Store)(nil)
+
+func New() *Store {
+	return &Store{
+		users:               make(map[int]store.User),
+		usernames:           make(map[string]int),
+		emails:              make(map[string]int),
+		sessions:            make(map[int]store.Session),
+		refreshTokens:       make(map[string]refreshToken),
+		personalTokens:      make(map[int]store.PersonalToken),
+		personalTokenHashes: make(map[string]int),
+		totp:                make(map[int]store.TOTP),
+		recoveryCodes:       make(map[int]map[string]bool),
+		emailTokens:         make(map[string]emailToken),
+		identities:          make(map[identityKey]store.Identity),
+		categories:          make(map[int]store.Category),
+		topics:              make(map[int]store.Topic),
+		topicTitles:         make(map[string]int),
+		moderators:          make(map[int]map[int]bool),
+		posts:               make(map[int]store.Post),
+		comments:            make(map[int]store.Comment),
+		postReactions:       make(map[int]map[int]int),
+		commentReactions:    make(map[int]map[int]int),
+		postRevisions:       make(map[int][]store.Revision),
+		commentRevisions:    make(map[int][]store.Revision),
+		sequences:           make(map[string]int),
+		index:               search.NewIndex(),
+	}
+}
+
+// nextID hands out the next id for a table. Callers must hold mu.
+func (s *Store) nextID(table string) int {
+	s.sequences[table]++
+	return s.sequences[table]
+}
+
+func now() time.Time {
+	return time.Now().UTC()
+}
+
+// tally fills in the reaction counts of a post or comment from its reactions
+func tally(reactions map[int]int, viewerID int) (likes, dislikes int, userReaction *int) {
+	for userID, reaction := range reactions {
+		if reaction == 1 {
+			likes++
+		} else if reaction == -1 {
+			dislikes++
+		}
+
+		if userID == viewerID {
+			r := reaction
+			userReaction = &r
+		}
+	}
+	return likes, dislikes, userReaction
+}
+
+// paginate orders items by opts.Sort and cuts out the page that follows opts.After
+func paginate[T interface{ Cursor(store.Sort) store.Cursor }](items []T, opts store.ListOptions) store.Page[T] {
+	sort.SliceStable(items, func(i, j int) bool {
+		return opts.Sort.Before(items[i].Cursor(opts.Sort), items[j].Cursor(opts.Sort))
+	})
+
+	start := 0
+	if opts.After != nil {
+		start = sort.Search(len(items), func(i int) bool {
+			return opts.Sort.Before(*opts.After, items[i].Cursor(opts.Sort))
+		})
+	}
+
+	end := min(start+opts.Limit, len(items))
+	page := store.Page[T]{Items: items[start:end]}
+	if end < len(items) && end > start {
+		next := items[end-1].Cursor(opts.Sort)
+		page.Next = &next
+	}
+	return page
+}
@@ -0,0 +1,14 @@
+package memory_test
+
+import (
+	"testing"
+	"web-forum/internal/store"
+	"web-forum/internal/store/memory"
+	"web-forum/internal/store/storetest"
+)
+
+func TestStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) store.Store {
+		return memory.New()
+	})
+}
@@ -0,0 +1,67 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"web-forum/internal/store"
+)
+
+func (s *Store) ModeratedTopics(ctx context.Context, userID int) ([]int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	topicIDs := make([]int, 0)
+	for topicID, moderators := range s.moderators {
+		if moderators[userID] {
+			topicIDs = append(topicIDs, topicID)
+		}
+	}
+
+	sort.Ints(topicIDs)
+	return topicIDs, nil
+}
+
+func (s *Store) ListModerators(ctx context.Context, topicID int) ([]store.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	users := make([]store.User, 0)
+	for userID := range s.moderators[topicID] {
+		users = append(users, s.users[userID])
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].ID < users[j].ID
+	})
+	return users, nil
+}
+
+func (s *Store) AddModerator(ctx context.Context, topicID, userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.topics[topicID]; !ok {
+		return store.ErrNotFound
+	}
+	if _, ok := s.users[userID]; !ok {
+		return store.ErrNotFound
+	}
+
+	if s.moderators[topicID] == nil {
+		s.moderators[topicID] = make(map[int]bool)
+	}
+	s.moderators[topicID][userID] = true
+	return nil
+}
+
+func (s *Store) RemoveModerator(ctx context.Context, topicID, userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.moderators[topicID][userID] {
+		return store.ErrNotFound
+	}
+
+	delete(s.moderators[topicID], userID)
+	return nil
+}
@@ -0,0 +1,162 @@
+package memory
+
+import (
+	"context"
+	"time"
+	"web-forum/internal/search"
+	"web-forum/internal/store"
+)
+
+// withReactions returns a copy of the post with its author and reaction counts filled in.
+// Callers must hold mu.
+func (s *Store) withReactions(post store.Post, viewerID int) store.Post {
+	post.Username = s.users[post.CreatedBy].Username
+	post.LikeCount, post.DislikeCount, post.UserReaction = tally(s.postReactions[post.ID], viewerID)
+	post.NetScore = post.LikeCount - post.DislikeCount
+	return post
+}
+
+func (s *Store) ListPosts(ctx context.Context, topicID, viewerID int, opts store.ListOptions) (store.Page[store.Post], error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	posts := make([]store.Post, 0)
+	for _, post := range s.posts {
+		if post.TopicID == topicID {
+			posts = append(posts, s.withReactions(post, viewerID))
+		}
+	}
+
+	return paginate(posts, opts), nil
+}
+
+func (s *Store) GetPost(ctx context.Context, id, viewerID int) (store.Post, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	post, ok := s.posts[id]
+	if !ok {
+		return store.Post{}, store.ErrNotFound
+	}
+	return s.withReactions(post, viewerID), nil
+}
+
+func (s *Store) CreatePost(ctx context.Context, topicID int, title, content string, createdBy int) (store.Post, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.topics[topicID]; !ok {
+		return store.Post{}, store.ErrNotFound
+	}
+	if _, ok := s.users[createdBy]; !ok {
+		return store.Post{}, store.ErrNotFound
+	}
+
+	createdAt := now()
+	post := store.Post{
+		ID:        s.nextID("posts"),
+		TopicID:   topicID,
+		Title:     title,
+		Content:   content,
+		CreatedBy: createdBy,
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+	}
+	s.posts[post.ID] = post
+	s.postRevisions[post.ID] = addRevision(nil, store.Revision{
+		Title: title, Content: content, EditedBy: createdBy, EditedAt: createdAt,
+	})
+	s.index.Put(search.PostDocument(post))
+
+	return s.withReactions(post, createdBy), nil
+}
+
+func (s *Store) UpdatePost(ctx context.Context, id int, title, content string, editedBy int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	post, ok := s.posts[id]
+	if !ok {
+		return store.ErrNotFound
+	}
+	if _, ok := s.users[editedBy]; !ok {
+		return store.ErrNotFound
+	}
+
+	post.Title = title
+	post.Content = content
+	post.UpdatedAt = now()
+	s.posts[id] = post
+	s.postRevisions[id] = addRevision(s.postRevisions[id], store.Revision{
+		Title: title, Content: content, EditedBy: editedBy, EditedAt: post.UpdatedAt,
+	})
+	s.index.Put(search.PostDocument(post))
+	return nil
+}
+
+func (s *Store) SetPostPinned(ctx context.Context, id int, pinned bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	post, ok := s.posts[id]
+	if !ok {
+		return store.ErrNotFound
+	}
+
+	post.PinnedAt = stateAt(post.PinnedAt, pinned)
+	s.posts[id] = post
+	return nil
+}
+
+func (s *Store) SetPostLocked(ctx context.Context, id int, locked bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	post, ok := s.posts[id]
+	if !ok {
+		return store.ErrNotFound
+	}
+
+	post.LockedAt = stateAt(post.LockedAt, locked)
+	s.posts[id] = post
+	return nil
+}
+
+// stateAt is the new value of a state timestamp such as PinnedAt: kept while
+// the state stays on, set to now when it turns on and nil when it turns off
+func stateAt(at *time.Time, on bool) *time.Time {
+	if !on {
+		return nil
+	}
+	if at == nil {
+		t := now()
+		return &t
+	}
+	return at
+}
+
+func (s *Store) DeletePost(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.posts[id]; !ok {
+		return store.ErrNotFound
+	}
+
+	s.deletePost(id)
+	return nil
+}
+
+// deletePost removes a post, cascading like the foreign keys on comments,
+// post_reactions and post_revisions. Callers must hold mu.
+func (s *Store) deletePost(id int) {
+	for commentID, comment := range s.comments {
+		if comment.PostID == id {
+			s.deleteComment(commentID)
+		}
+	}
+	delete(s.postReactions, id)
+	delete(s.postRevisions, id)
+	delete(s.posts, id)
+	s.index.Remove(store.SearchPost, id)
+}
@@ -0,0 +1,164 @@
+package memory
+
+import (
+	"context"
+	"web-forum/internal/store"
+)
+
+func (s *Store) GetProfile(ctx context.Context, id int) (store.Profile, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	user, ok := s.users[id]
+	if !ok || user.Deleted() {
+		return store.Profile{}, store.ErrNotFound
+	}
+
+	// Reactions the user gave their own content do not count
+	profile := store.Profile{User: user}
+	for postID, post := range s.posts {
+		if post.CreatedBy != id {
+			continue
+		}
+		profile.PostCount++
+		for userID, reaction := range s.postReactions[postID] {
+			if userID != id {
+				profile.Reputation += reaction
+			}
+		}
+	}
+	for commentID, comment := range s.comments {
+		if comment.CreatedBy != id {
+			continue
+		}
+		profile.CommentCount++
+		for userID, reaction := range s.commentReactions[commentID] {
+			if userID != id {
+				profile.Reputation += reaction
+			}
+		}
+	}
+	return profile, nil
+}
+
+func (s *Store) UpdateProfile(ctx context.Context, id int, update store.ProfileUpdate) (store.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[id]
+	if !ok || user.Deleted() {
+		return store.User{}, store.ErrNotFound
+	}
+
+	if update.DisplayName != nil {
+		user.DisplayName = *update.DisplayName
+	}
+	if update.Bio != nil {
+		user.Bio = *update.Bio
+	}
+	if update.AvatarURL != nil {
+		user.AvatarURL = *update.AvatarURL
+	}
+	s.users[id] = user
+	return user, nil
+}
+
+func (s *Store) SetUsername(ctx context.Context, id int, username string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[id]
+	if !ok || user.Deleted() {
+		return store.ErrNotFound
+	}
+	if other, ok := s.usernames[username]; ok && other != id {
+		return store.ErrConflict
+	}
+
+	s.renameUser(user, username)
+	return nil
+}
+
+func (s *Store) DeleteUser(ctx context.Context, id int, policy store.DeletionPolicy) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[id]
+	if !ok || user.Deleted() {
+		return store.ErrNotFound
+	}
+
+	delete(s.emails, user.Email)
+	user = s.renameUser(user, store.DeletedUsername(id))
+	deletedAt := now()
+	s.users[id] = store.User{
+		ID:        id,
+		Username:  user.Username,
+		Role:      store.RoleMember,
+		DeletedAt: &deletedAt,
+		CreatedAt: user.CreatedAt,
+	}
+
+	// Like the foreign keys and DeleteUser of the SQL store
+	for sessionID, session := range s.sessions {
+		if session.UserID == id {
+			delete(s.sessions, sessionID)
+		}
+	}
+	for hash, token := range s.refreshTokens {
+		if _, ok := s.sessions[token.sessionID]; !ok {
+			delete(s.refreshTokens, hash)
+		}
+	}
+	for hash, tokenID := range s.personalTokenHashes {
+		if s.personalTokens[tokenID].UserID == id {
+			delete(s.personalTokens, tokenID)
+			delete(s.personalTokenHashes, hash)
+		}
+	}
+	delete(s.totp, id)
+	delete(s.recoveryCodes, id)
+	for hash, token := range s.emailTokens {
+		if token.UserID == id {
+			delete(s.emailTokens, hash)
+		}
+	}
+	for key, identity := range s.identities {
+		if identity.UserID == id {
+			delete(s.identities, key)
+		}
+	}
+	for _, moderators := range s.moderators {
+		delete(moderators, id)
+	}
+
+	if policy == store.DeleteContent {
+		for _, reactions := range s.postReactions {
+			delete(reactions, id)
+		}
+		for _, reactions := range s.commentReactions {
+			delete(reactions, id)
+		}
+		for postID, post := range s.posts {
+			if post.CreatedBy == id {
+				s.deletePost(postID)
+			}
+		}
+		for commentID, comment := range s.comments {
+			if comment.CreatedBy == id {
+				s.deleteComment(commentID)
+			}
+		}
+	}
+	return nil
+}
+
+// renameUser changes a user's username and returns the renamed user. Callers
+// must hold mu and have checked the username is free.
+func (s *Store) renameUser(user store.User, username string) store.User {
+	delete(s.usernames, user.Username)
+	s.usernames[username] = user.ID
+	user.Username = username
+	s.users[user.ID] = user
+	return user
+}
@@ -0,0 +1,51 @@
+package memory
+
+import (
+	"context"
+	"web-forum/internal/store"
+)
+
+func (s *Store) TogglePostReaction(ctx context.Context, postID, userID, reaction int) (store.ReactionChange, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.posts[postID]; !ok {
+		return 0, store.ErrNotFound
+	}
+	return toggle(s.postReactions, postID, userID, reaction), nil
+}
+
+func (s *Store) ToggleCommentReaction(ctx context.Context, commentID, userID, reaction int) (store.ReactionChange, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.comments[commentID]; !ok {
+		return 0, store.ErrNotFound
+	}
+	return toggle(s.commentReactions, commentID, userID, reaction), nil
+}
+
+// toggle applies the same insert / flip / remove rules as the database backends.
+// Callers must hold mu.
+func toggle(reactions map[int]map[int]int, targetID, userID, reaction int) store.ReactionChange {
+	byUser := reactions[targetID]
+	if byUser == nil {
+		byUser = make(map[int]int)
+		reactions[targetID] = byUser
+	}
+
+	current, ok := byUser[userID]
+	if !ok {
+		byUser[userID] = reaction
+		return store.ReactionCreated
+	}
+
+	if current == reaction {
+		// Means double click
+		delete(byUser, userID)
+		return store.ReactionDeleted
+	}
+
+	byUser[userID] = reaction
+	return store.ReactionUpdated
+}
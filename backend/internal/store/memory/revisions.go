@@ -0,0 +1,44 @@
+package memory
+
+import (
+	"context"
+	"web-forum/internal/store"
+)
+
+// addRevision appends the version of a post or comment just written to its
+// revisions, numbered after the latest
+func addRevision(revisions []store.Revision, revision store.Revision) []store.Revision {
+	revision.Number = len(revisions) + 1
+	return append(revisions, revision)
+}
+
+func (s *Store) ListPostRevisions(ctx context.Context, postID int) ([]store.Revision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.posts[postID]; !ok {
+		return nil, store.ErrNotFound
+	}
+	return s.withEditors(s.postRevisions[postID]), nil
+}
+
+func (s *Store) ListCommentRevisions(ctx context.Context, commentID int) ([]store.Revision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.comments[commentID]; !ok {
+		return nil, store.ErrNotFound
+	}
+	return s.withEditors(s.commentRevisions[commentID]), nil
+}
+
+// withEditors returns a copy of the revisions with the editors' usernames
+// filled in. Callers must hold mu.
+func (s *Store) withEditors(revisions []store.Revision) []store.Revision {
+	result := make([]store.Revision, len(revisions))
+	for i, revision := range revisions {
+		revision.Editor = s.users[revision.EditedBy].Username
+		result[i] = revision
+	}
+	return result
+}
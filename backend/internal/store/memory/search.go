@@ -0,0 +1,29 @@
+package memory
+
+import (
+	"context"
+	"web-forum/internal/store"
+)
+
+func (s *Store) Search(ctx context.Context, query store.SearchQuery) (store.Page[store.SearchHit], error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	authorID := 0
+	if query.Author != "" {
+		id, ok := s.usernames[query.Author]
+		if !ok {
+			return store.Page[store.SearchHit]{Items: []store.SearchHit{}}, nil
+		}
+		authorID = id
+	}
+
+	page := s.index.Page(query, authorID)
+	for i, hit := range page.Items {
+		page.Items[i].Username = s.users[hit.CreatedBy].Username
+		if hit.Type == store.SearchComment {
+			page.Items[i].Title = s.posts[hit.PostID].Title
+		}
+	}
+	return page, nil
+}
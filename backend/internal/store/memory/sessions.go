@@ -0,0 +1,140 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+	"web-forum/internal/store"
+)
+
+type refreshToken struct {
+	sessionID int
+	used      bool
+}
+
+func (s *Store) CreateSession(ctx context.Context, userID int, tokenHash, userAgent, ipAddress string, expiresAt time.Time) (store.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[userID]; !ok {
+		return store.Session{}, store.ErrNotFound
+	}
+
+	createdAt := now()
+	session := store.Session{
+		ID:         s.nextID("sessions"),
+		UserID:     userID,
+		UserAgent:  userAgent,
+		IPAddress:  ipAddress,
+		CreatedAt:  createdAt,
+		LastUsedAt: createdAt,
+		ExpiresAt:  expiresAt,
+	}
+	s.sessions[session.ID] = session
+	s.refreshTokens[tokenHash] = refreshToken{sessionID: session.ID}
+
+	return session, nil
+}
+
+func (s *Store) GetSession(ctx context.Context, id int) (store.Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	session, ok := s.sessions[id]
+	if !ok {
+		return store.Session{}, store.ErrNotFound
+	}
+	return session, nil
+}
+
+func (s *Store) GetSessionByToken(ctx context.Context, tokenHash string) (store.Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	token, ok := s.refreshTokens[tokenHash]
+	if !ok {
+		return store.Session{}, store.ErrNotFound
+	}
+	return s.sessions[token.sessionID], nil
+}
+
+func (s *Store) RotateRefreshToken(ctx context.Context, oldHash, newHash string) (store.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token, ok := s.refreshTokens[oldHash]
+	if !ok {
+		return store.Session{}, store.ErrNotFound
+	}
+
+	session := s.sessions[token.sessionID]
+	if token.used {
+		s.revokeSession(session.ID)
+		return store.Session{}, store.ErrTokenReused
+	}
+	if !session.Active(now()) {
+		return store.Session{}, store.ErrNotFound
+	}
+
+	token.used = true
+	s.refreshTokens[oldHash] = token
+	s.refreshTokens[newHash] = refreshToken{sessionID: session.ID}
+
+	session.LastUsedAt = now()
+	s.sessions[session.ID] = session
+	return session, nil
+}
+
+func (s *Store) ListSessions(ctx context.Context, userID int) ([]store.Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sessions := make([]store.Session, 0)
+	for _, session := range s.sessions {
+		if session.UserID == userID && session.Active(now()) {
+			sessions = append(sessions, session)
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		if !sessions[i].LastUsedAt.Equal(sessions[j].LastUsedAt) {
+			return sessions[i].LastUsedAt.After(sessions[j].LastUsedAt)
+		}
+		return sessions[i].ID > sessions[j].ID
+	})
+	return sessions, nil
+}
+
+func (s *Store) RevokeSession(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if session, ok := s.sessions[id]; !ok || !session.Active(now()) {
+		return store.ErrNotFound
+	}
+
+	s.revokeSession(id)
+	return nil
+}
+
+func (s *Store) RevokeUserSessions(ctx context.Context, userID, except int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, session := range s.sessions {
+		if session.UserID == userID && id != except && session.RevokedAt == nil {
+			s.revokeSession(id)
+		}
+	}
+	return nil
+}
+
+// revokeSession marks a session revoked if it is not already. Callers must hold mu.
+func (s *Store) revokeSession(id int) {
+	session := s.sessions[id]
+	if session.RevokedAt == nil {
+		revokedAt := now()
+		session.RevokedAt = &revokedAt
+		s.sessions[id] = session
+	}
+}
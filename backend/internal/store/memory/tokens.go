@@ -0,0 +1,102 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+	"web-forum/internal/store"
+)
+
+func (s *Store) CreatePersonalToken(ctx context.Context, userID int, name string, scope store.TokenScope, tokenHash string, expiresAt time.Time) (store.PersonalToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[userID]; !ok {
+		return store.PersonalToken{}, store.ErrNotFound
+	}
+	if _, ok := s.personalTokenHashes[tokenHash]; ok {
+		return store.PersonalToken{}, store.ErrConflict
+	}
+
+	token := store.PersonalToken{
+		ID:        s.nextID("personal_tokens"),
+		UserID:    userID,
+		Name:      name,
+		Scope:     scope,
+		CreatedAt: now(),
+		ExpiresAt: expiresAt,
+	}
+	s.personalTokens[token.ID] = token
+	s.personalTokenHashes[tokenHash] = token.ID
+
+	return token, nil
+}
+
+func (s *Store) GetPersonalToken(ctx context.Context, id int) (store.PersonalToken, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	token, ok := s.personalTokens[id]
+	if !ok {
+		return store.PersonalToken{}, store.ErrNotFound
+	}
+	return token, nil
+}
+
+func (s *Store) GetPersonalTokenByHash(ctx context.Context, tokenHash string) (store.PersonalToken, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	id, ok := s.personalTokenHashes[tokenHash]
+	if !ok {
+		return store.PersonalToken{}, store.ErrNotFound
+	}
+	return s.personalTokens[id], nil
+}
+
+func (s *Store) ListPersonalTokens(ctx context.Context, userID int) ([]store.PersonalToken, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tokens := make([]store.PersonalToken, 0)
+	for _, token := range s.personalTokens {
+		if token.UserID == userID && token.Active(now()) {
+			tokens = append(tokens, token)
+		}
+	}
+
+	sort.Slice(tokens, func(i, j int) bool {
+		return tokens[i].ID > tokens[j].ID
+	})
+	return tokens, nil
+}
+
+func (s *Store) RevokePersonalToken(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token, ok := s.personalTokens[id]
+	if !ok || !token.Active(now()) {
+		return store.ErrNotFound
+	}
+
+	revokedAt := now()
+	token.RevokedAt = &revokedAt
+	s.personalTokens[id] = token
+	return nil
+}
+
+func (s *Store) TouchPersonalToken(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token, ok := s.personalTokens[id]
+	if !ok {
+		return store.ErrNotFound
+	}
+
+	usedAt := now()
+	token.LastUsedAt = &usedAt
+	s.personalTokens[id] = token
+	return nil
+}
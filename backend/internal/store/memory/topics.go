@@ -0,0 +1,202 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+	"web-forum/internal/search"
+	"web-forum/internal/store"
+)
+
+func (s *Store) ListTopics(ctx context.Context) ([]store.Topic, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	topics := make([]store.Topic, 0, len(s.topics))
+	for _, topic := range s.topics {
+		topics = append(topics, topic)
+	}
+
+	sort.Slice(topics, func(i, j int) bool {
+		return topics[i].ID < topics[j].ID
+	})
+	return topics, nil
+}
+
+func (s *Store) ListTopicStats(ctx context.Context, categoryID int, sort store.TopicSort) ([]store.TopicStats, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stats := make(map[int]*store.TopicStats, len(s.topics))
+	for id, topic := range s.topics {
+		if categoryID != 0 && !s.inCategory(topic.CategoryID, categoryID) {
+			continue
+		}
+		stats[id] = &store.TopicStats{Topic: topic, LastActivityAt: topic.CreatedAt}
+	}
+
+	// latest tracks the row behind each LastActivityAt, to break ties on the
+	// time the way the SQL stores do: comments over posts, then the higher id
+	type row struct{ kind, id int }
+	latest := make(map[int]row)
+	record := func(topicID int, r row, createdBy int, createdAt time.Time) {
+		topic := stats[topicID]
+		if previous, ok := latest[topicID]; ok {
+			if createdAt.Before(topic.LastActivityAt) ||
+				createdAt.Equal(topic.LastActivityAt) && (r.kind < previous.kind || r.kind == previous.kind && r.id < previous.id) {
+				return
+			}
+		}
+
+		latest[topicID] = r
+		username := s.users[createdBy].Username
+		topic.LastActivityAt = createdAt
+		topic.LastPosterID = &createdBy
+		topic.LastPoster = &username
+	}
+
+	for _, post := range s.posts {
+		if topic, ok := stats[post.TopicID]; ok {
+			topic.PostCount++
+			record(post.TopicID, row{0, post.ID}, post.CreatedBy, post.CreatedAt)
+		}
+	}
+	for _, comment := range s.comments {
+		if topic, ok := stats[comment.TopicID]; ok {
+			topic.CommentCount++
+			record(comment.TopicID, row{1, comment.ID}, comment.CreatedBy, comment.CreatedAt)
+		}
+	}
+
+	topics := make([]store.TopicStats, 0, len(stats))
+	for _, topic := range stats {
+		topics = append(topics, *topic)
+	}
+
+	sortTopics(topics, sort)
+	return topics, nil
+}
+
+// sortTopics orders topics like ListTopicStats in the SQL stores
+func sortTopics(topics []store.TopicStats, order store.TopicSort) {
+	sort.Slice(topics, func(i, j int) bool {
+		a, b := topics[i], topics[j]
+		switch order {
+		case store.TopicSortNewest:
+			if !a.CreatedAt.Equal(b.CreatedAt) {
+				return a.CreatedAt.After(b.CreatedAt)
+			}
+			return a.ID > b.ID
+		case store.TopicSortActivity:
+			if !a.LastActivityAt.Equal(b.LastActivityAt) {
+				return a.LastActivityAt.After(b.LastActivityAt)
+			}
+			return a.ID > b.ID
+		}
+		return a.ID < b.ID
+	})
+}
+
+func (s *Store) GetTopic(ctx context.Context, id int) (store.Topic, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	topic, ok := s.topics[id]
+	if !ok {
+		return store.Topic{}, store.ErrNotFound
+	}
+	return topic, nil
+}
+
+func (s *Store) CreateTopic(ctx context.Context, title, description string, categoryID *int, createdBy int) (store.Topic, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.topicTitles[title]; ok {
+		return store.Topic{}, store.ErrConflict
+	}
+	if _, ok := s.users[createdBy]; !ok {
+		return store.Topic{}, store.ErrNotFound
+	}
+	if categoryID != nil {
+		if _, ok := s.categories[*categoryID]; !ok {
+			return store.Topic{}, store.ErrNotFound
+		}
+	}
+
+	topic := store.Topic{
+		ID:          s.nextID("topics"),
+		Title:       title,
+		Description: description,
+		CategoryID:  copyInt(categoryID),
+		CreatedBy:   createdBy,
+		CreatedAt:   now(),
+	}
+	s.topics[topic.ID] = topic
+	s.topicTitles[title] = topic.ID
+	s.index.Put(search.TopicDocument(topic))
+
+	return topic, nil
+}
+
+func (s *Store) UpdateTopic(ctx context.Context, id int, update store.TopicUpdate) (store.Topic, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	topic, ok := s.topics[id]
+	if !ok {
+		return store.Topic{}, store.ErrNotFound
+	}
+	if other, ok := s.topicTitles[update.Title]; ok && other != id {
+		return store.Topic{}, store.ErrConflict
+	}
+	if update.MoveCategory && update.CategoryID != nil {
+		if _, ok := s.categories[*update.CategoryID]; !ok {
+			return store.Topic{}, store.ErrNotFound
+		}
+	}
+
+	delete(s.topicTitles, topic.Title)
+	topic.Title = update.Title
+	topic.Description = update.Description
+	if update.MoveCategory {
+		topic.CategoryID = copyInt(update.CategoryID)
+	}
+	switch {
+	case update.Archived == nil:
+	case !*update.Archived:
+		topic.ArchivedAt = nil
+	case topic.ArchivedAt == nil:
+		archivedAt := now()
+		topic.ArchivedAt = &archivedAt
+	}
+	s.topics[id] = topic
+	s.topicTitles[update.Title] = id
+	s.index.Put(search.TopicDocument(topic))
+
+	return topic, nil
+}
+
+// DeleteTopic removes a topic, cascading like the foreign keys on posts and
+// topic_moderators
+func (s *Store) DeleteTopic(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	topic, ok := s.topics[id]
+	if !ok {
+		return store.ErrNotFound
+	}
+
+	for postID, post := range s.posts {
+		if post.TopicID == id {
+			s.deletePost(postID)
+		}
+	}
+	delete(s.moderators, id)
+	delete(s.topicTitles, topic.Title)
+	delete(s.topics, id)
+	s.index.Remove(store.SearchTopic, id)
+
+	return nil
+}
@@ -0,0 +1,119 @@
+package memory
+
+import (
+	"context"
+	"web-forum/internal/store"
+)
+
+func (s *Store) GetTOTP(ctx context.Context, userID int) (store.TOTP, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	t, ok := s.totp[userID]
+	if !ok {
+		return store.TOTP{}, store.ErrNotFound
+	}
+
+	for _, used := range s.recoveryCodes[userID] {
+		if !used {
+			t.RecoveryCodesLeft++
+		}
+	}
+	return t, nil
+}
+
+func (s *Store) StartTOTP(ctx context.Context, userID int, secret string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[userID]; !ok {
+		return store.ErrNotFound
+	}
+	if t, ok := s.totp[userID]; ok && t.Enabled() {
+		return store.ErrConflict
+	}
+
+	s.totp[userID] = store.TOTP{UserID: userID, Secret: secret, CreatedAt: now()}
+	return nil
+}
+
+func (s *Store) EnableTOTP(ctx context.Context, userID int, step int64, recoveryHashes []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.totp[userID]
+	if !ok || t.Enabled() {
+		return store.ErrNotFound
+	}
+
+	enabledAt := now()
+	t.EnabledAt = &enabledAt
+	t.LastStep = step
+	s.totp[userID] = t
+	s.replaceRecoveryCodes(userID, recoveryHashes)
+	return nil
+}
+
+func (s *Store) UseTOTPStep(ctx context.Context, userID int, step int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.totp[userID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	if step <= t.LastStep {
+		return store.ErrCodeReused
+	}
+
+	t.LastStep = step
+	s.totp[userID] = t
+	return nil
+}
+
+func (s *Store) UseRecoveryCode(ctx context.Context, userID int, hash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	used, ok := s.recoveryCodes[userID][hash]
+	if !ok || used {
+		return store.ErrNotFound
+	}
+
+	s.recoveryCodes[userID][hash] = true
+	return nil
+}
+
+func (s *Store) ReplaceRecoveryCodes(ctx context.Context, userID int, hashes []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.totp[userID]; !ok {
+		return store.ErrNotFound
+	}
+
+	s.replaceRecoveryCodes(userID, hashes)
+	return nil
+}
+
+func (s *Store) DisableTOTP(ctx context.Context, userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.totp[userID]; !ok {
+		return store.ErrNotFound
+	}
+
+	delete(s.totp, userID)
+	delete(s.recoveryCodes, userID)
+	return nil
+}
+
+// replaceRecoveryCodes swaps in new recovery codes. Callers must hold mu.
+func (s *Store) replaceRecoveryCodes(userID int, hashes []string) {
+	codes := make(map[string]bool, len(hashes))
+	for _, hash := range hashes {
+		codes[hash] = false
+	}
+	s.recoveryCodes[userID] = codes
+}
@@ -0,0 +1,126 @@
+package memory
+
+import (
+	"context"
+	"web-forum/internal/store"
+)
+
+func (s *Store) CreateUser(ctx context.Context, username, passwordHash string) (store.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.usernames[username]; ok {
+		return store.User{}, store.ErrConflict
+	}
+
+	user := store.User{
+		ID:        s.nextID("users"),
+		Username:  username,
+		Password:  passwordHash,
+		Role:      store.RoleMember,
+		CreatedAt: now(),
+	}
+	s.users[user.ID] = user
+	s.usernames[username] = user.ID
+
+	return user, nil
+}
+
+func (s *Store) GetUserByID(ctx context.Context, id int) (store.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	user, ok := s.users[id]
+	if !ok {
+		return store.User{}, store.ErrNotFound
+	}
+	return user, nil
+}
+
+func (s *Store) GetUserByUsername(ctx context.Context, username string) (store.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	id, ok := s.usernames[username]
+	if !ok {
+		return store.User{}, store.ErrNotFound
+	}
+	return s.users[id], nil
+}
+
+func (s *Store) GetUserByEmail(ctx context.Context, email string) (store.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	id, ok := s.emails[email]
+	if !ok {
+		return store.User{}, store.ErrNotFound
+	}
+	return s.users[id], nil
+}
+
+func (s *Store) UpdatePassword(ctx context.Context, id int, passwordHash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[id]
+	if !ok {
+		return store.ErrNotFound
+	}
+
+	user.Password = passwordHash
+	s.users[id] = user
+	return nil
+}
+
+func (s *Store) SetRole(ctx context.Context, id int, role store.Role) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[id]
+	if !ok {
+		return store.ErrNotFound
+	}
+
+	user.Role = role
+	s.users[id] = user
+	return nil
+}
+
+func (s *Store) SetEmail(ctx context.Context, id int, email string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[id]
+	if !ok {
+		return store.ErrNotFound
+	}
+	if other, ok := s.emails[email]; ok && other != id {
+		return store.ErrConflict
+	}
+
+	delete(s.emails, user.Email)
+	if email != "" {
+		s.emails[email] = id
+	}
+
+	user.Email = email
+	user.EmailVerifiedAt = nil
+	s.users[id] = user
+	return nil
+}
+
+func (s *Store) MarkEmailVerified(ctx context.Context, id int, email string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[id]
+	if !ok || email == "" || user.Email != email {
+		return store.ErrNotFound
+	}
+
+	verifiedAt := now()
+	user.EmailVerifiedAt = &verifiedAt
+	s.users[id] = user
+	return nil
+}
@@ -0,0 +1,69 @@
+package store
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// ErrInvalidCursor is returned when a cursor cannot be decoded
+var ErrInvalidCursor = errors.New("store: invalid cursor")
+
+// Cursor marks the last row of a page. The next page starts right after it
+// in the listing order, so rows inserted or deleted while paging never make
+// another row repeat or go missing.
+type Cursor struct {
+	Sort Sort `json:"s"`
+	// Score is the sort key of the score based orders, CreatedAt of the time based ones
+	Score     float64   `json:"n,omitzero"`
+	CreatedAt time.Time `json:"t,omitzero"`
+	// Kind is the type of a search result, which needs it as a tie-breaker
+	// because ids are only unique per type
+	Kind SearchType `json:"k,omitempty"`
+	// Pinned is set for pinned posts, which are listed before all others
+	Pinned bool `json:"p,omitempty"`
+	ID     int  `json:"id"`
+}
+
+// Encode turns the cursor into the opaque string handed to clients
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeCursor(s string) (Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == 0 {
+		return Cursor{}, ErrInvalidCursor
+	}
+	if _, err := ParseSort(string(c.Sort)); (err != nil || c.Sort == "") && c.Sort != SortRelevance {
+		return Cursor{}, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// ListOptions selects one page of a listing
+type ListOptions struct {
+	Sort  Sort
+	Limit int
+	// After is the cursor of the previous page, nil for the first page.
+	// Its Sort must match Sort.
+	After *Cursor
+}
+
+// Page is one page of a listing. Next is nil on the last page.
+type Page[T any] struct {
+	Items []T
+	Next  *Cursor
+}
@@ -0,0 +1,65 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"strconv"
+)
+
+// Profile is what anyone signed in can see about a user. Reputation is the
+// likes minus dislikes others gave the user's posts and comments.
+type Profile struct {
+	User
+	PostCount    int `json:"post_count"`
+	CommentCount int `json:"comment_count"`
+	Reputation   int `json:"reputation"`
+}
+
+// ProfileUpdate changes the fields that are not nil
+type ProfileUpdate struct {
+	DisplayName *string
+	Bio         *string
+	AvatarURL   *string
+}
+
+// DeletionPolicy decides what happens to a deleted user's posts, comments
+// and reactions
+type DeletionPolicy string
+
+const (
+	// DeleteAnonymise keeps the content, credited to the scrubbed account
+	DeleteAnonymise DeletionPolicy = "anonymise"
+	// DeleteContent removes the content too, with the replies to it, like
+	// deleting each post and comment would
+	DeleteContent DeletionPolicy = "delete"
+)
+
+var ErrInvalidDeletionPolicy = errors.New("store: invalid deletion policy")
+
+func ParseDeletionPolicy(s string) (DeletionPolicy, error) {
+	switch DeletionPolicy(s) {
+	case DeleteAnonymise, DeleteContent:
+		return DeletionPolicy(s), nil
+	}
+	return "", ErrInvalidDeletionPolicy
+}
+
+// DeletedUsername is the username a deleted account is left with. Sign up
+// refuses names like it.
+func DeletedUsername(id int) string {
+	return DeletedUsernamePrefix + strconv.Itoa(id)
+}
+
+const DeletedUsernamePrefix = "deleted-"
+
+type ProfileStore interface {
+	// GetProfile returns ErrNotFound for deleted users too
+	GetProfile(ctx context.Context, id int) (Profile, error)
+	UpdateProfile(ctx context.Context, id int, update ProfileUpdate) (User, error)
+	// SetUsername returns ErrConflict if another user has the username
+	SetUsername(ctx context.Context, id int, username string) error
+	// DeleteUser scrubs the account, signs it out everywhere and deals with
+	// its content according to policy. It returns ErrNotFound if the user
+	// does not exist or is already deleted.
+	DeleteUser(ctx context.Context, id int, policy DeletionPolicy) error
+}
@@ -0,0 +1,68 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// SearchType is the kind of row a search result points at
+type SearchType string
+
+const (
+	SearchTopic   SearchType = "topic"
+	SearchPost    SearchType = "post"
+	SearchComment SearchType = "comment"
+)
+
+var ErrInvalidSearchType = errors.New("store: invalid search type")
+
+func ParseSearchType(s string) (SearchType, error) {
+	switch SearchType(s) {
+	case SearchTopic, SearchPost, SearchComment:
+		return SearchType(s), nil
+	}
+	return "", ErrInvalidSearchType
+}
+
+// SearchQuery is a full-text query and its filters. Zero filters match everything.
+type SearchQuery struct {
+	Text  string
+	Types []SearchType
+	// TopicID keeps results inside one topic, including the comments of its posts
+	TopicID int
+	// Author is a username
+	Author string
+	From   time.Time
+	To     time.Time
+	Limit  int
+	// After is the cursor of the previous page, nil for the first page
+	After *Cursor
+}
+
+// SearchHit is one ranked search result. Title is the topic or post title,
+// for comments the title of the post they belong to. Snippet is HTML with
+// the matched words wrapped in <mark> tags and everything else escaped.
+type SearchHit struct {
+	Type      SearchType `json:"type"`
+	ID        int        `json:"id"`
+	TopicID   int        `json:"topic_id"`
+	PostID    int        `json:"post_id,omitempty"`
+	Title     string     `json:"title"`
+	Snippet   string     `json:"snippet"`
+	CreatedBy int        `json:"created_by"`
+	Username  string     `json:"username"`
+	CreatedAt time.Time  `json:"created_at"`
+	Rank      float64    `json:"rank"`
+}
+
+// Cursor returns the cursor that resumes a search right after this hit
+func (h SearchHit) Cursor(Sort) Cursor {
+	return Cursor{Sort: SortRelevance, Score: h.Rank, Kind: h.Type, ID: h.ID}
+}
+
+type SearchStore interface {
+	// Search returns the topics, posts and comments matching every word of
+	// the query, most relevant first
+	Search(ctx context.Context, query SearchQuery) (Page[SearchHit], error)
+}
@@ -0,0 +1,53 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrTokenReused is returned when a refresh token that was already exchanged
+// is presented again. Someone else may hold a copy, so its session is revoked.
+var ErrTokenReused = errors.New("store: refresh token reused")
+
+// Session is one login. It lives until it expires or is revoked, while its
+// refresh token is exchanged for a new one on every refresh. Current marks
+// the session of the request that listed it.
+type Session struct {
+	ID         int        `json:"id"`
+	UserID     int        `json:"user_id"`
+	UserAgent  string     `json:"user_agent"`
+	IPAddress  string     `json:"ip_address"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt time.Time  `json:"last_used_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"-"`
+	Current    bool       `json:"current"`
+}
+
+// Active reports whether the session can still be used at t
+func (s Session) Active(t time.Time) bool {
+	return s.RevokedAt == nil && t.Before(s.ExpiresAt)
+}
+
+// SessionStore keeps sessions and the hashes of their refresh tokens
+type SessionStore interface {
+	// CreateSession starts a session whose first refresh token has tokenHash
+	CreateSession(ctx context.Context, userID int, tokenHash, userAgent, ipAddress string, expiresAt time.Time) (Session, error)
+	GetSession(ctx context.Context, id int) (Session, error)
+	// GetSessionByToken finds the session a refresh token was issued for,
+	// whether or not the token was exchanged since
+	GetSessionByToken(ctx context.Context, tokenHash string) (Session, error)
+	// RotateRefreshToken exchanges the refresh token with oldHash for one with
+	// newHash. It returns ErrNotFound if the token is unknown or its session
+	// is no longer active, and ErrTokenReused if the token was exchanged
+	// before, revoking the session.
+	RotateRefreshToken(ctx context.Context, oldHash, newHash string) (Session, error)
+	// ListSessions returns the user's active sessions, most recently used first
+	ListSessions(ctx context.Context, userID int) ([]Session, error)
+	// RevokeSession returns ErrNotFound if the session is not active
+	RevokeSession(ctx context.Context, id int) error
+	// RevokeUserSessions revokes every active session of the user except
+	// the one with id except, which may be 0
+	RevokeUserSessions(ctx context.Context, userID, except int) error
+}
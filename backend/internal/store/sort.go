@@ -0,0 +1,146 @@
+package store
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// Sort is the order of a post or comment listing
+type Sort string
+
+const (
+	SortNewest  Sort = "newest"
+	SortOldest  Sort = "oldest"
+	SortLikes   Sort = "likes"
+	SortPopular Sort = "popular" // likes minus dislikes
+	SortHot     Sort = "hot"     // popular, decayed by age
+
+	// SortRelevance orders search results. It is not a valid listing order.
+	SortRelevance Sort = "relevance"
+)
+
+var ErrInvalidSort = errors.New("store: invalid sort")
+
+// ParseSort validates a sort query parameter. An empty string means newest.
+func ParseSort(s string) (Sort, error) {
+	switch Sort(s) {
+	case "":
+		return SortNewest, nil
+	case SortNewest, SortOldest, SortLikes, SortPopular, SortHot:
+		return Sort(s), nil
+	}
+	return "", ErrInvalidSort
+}
+
+// TopicSort is the order of the topic listing
+type TopicSort string
+
+const (
+	TopicSortOldest   TopicSort = "oldest"
+	TopicSortNewest   TopicSort = "newest"
+	TopicSortActivity TopicSort = "activity" // latest post or comment first
+)
+
+// ParseTopicSort validates a topic sort query parameter. An empty string
+// means oldest, the order topics were always listed in.
+func ParseTopicSort(s string) (TopicSort, error) {
+	switch TopicSort(s) {
+	case "":
+		return TopicSortOldest, nil
+	case TopicSortOldest, TopicSortNewest, TopicSortActivity:
+		return TopicSort(s), nil
+	}
+	return "", ErrInvalidSort
+}
+
+// ByScore reports whether rows are ordered by Cursor.Score rather than Cursor.CreatedAt
+func (s Sort) ByScore() bool {
+	return s == SortLikes || s == SortPopular || s == SortHot || s == SortRelevance
+}
+
+// Before reports whether the row at a is listed before the row at b. Pinned
+// rows come first. Ties on the sort key are broken by id so the order is
+// total and pages are stable.
+func (s Sort) Before(a, b Cursor) bool {
+	if a.Pinned != b.Pinned {
+		return a.Pinned
+	}
+
+	switch s {
+	case SortOldest:
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.ID < b.ID
+	case SortLikes, SortPopular, SortHot:
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		return a.ID > b.ID
+	case SortRelevance:
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Kind != b.Kind {
+			return a.Kind > b.Kind
+		}
+		return a.ID > b.ID
+	default:
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+		return a.ID > b.ID
+	}
+}
+
+func (s Sort) cursor(likes, netScore int, createdAt time.Time, id int) Cursor {
+	c := Cursor{Sort: s, ID: id}
+	switch s {
+	case SortLikes:
+		c.Score = float64(likes)
+	case SortPopular:
+		c.Score = float64(netScore)
+	case SortHot:
+		c.Score = HotScore(netScore, createdAt)
+	default:
+		c.CreatedAt = createdAt
+	}
+	return c
+}
+
+// Cursor returns the cursor that resumes a listing in the given order right after this post
+func (p Post) Cursor(s Sort) Cursor {
+	c := s.cursor(p.LikeCount, p.NetScore, p.CreatedAt, p.ID)
+	c.Pinned = p.Pinned()
+	return c
+}
+
+// Cursor returns the cursor that resumes a listing in the given order right after this comment
+func (c Comment) Cursor(s Sort) Cursor {
+	return s.cursor(c.LikeCount, c.NetScore, c.CreatedAt, c.ID)
+}
+
+const (
+	// HotEpoch and HotDecay shape HotScore. Every HotDecay seconds a row needs
+	// ten times the net score to stay level with newer rows.
+	HotEpoch = 1704067200 // 2024-01-01 UTC
+	HotDecay = 45000
+)
+
+// HotScore ranks by net score on a log scale plus a bonus that grows with the
+// creation time. It only depends on the row, not on the current time, so a
+// hot listing keeps a fixed order while it is paged through. The SQL backends
+// compute the same formula in the database.
+func HotScore(netScore int, createdAt time.Time) float64 {
+	sign := 0.0
+	if netScore > 0 {
+		sign = 1
+	} else if netScore < 0 {
+		sign = -1
+	}
+
+	magnitude := math.Log10(math.Max(math.Abs(float64(netScore)), 1))
+	age := float64(createdAt.UnixNano())/1e9 - HotEpoch
+	return sign*magnitude + age/HotDecay
+}
@@ -0,0 +1,43 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"web-forum/internal/store"
+)
+
+func (s *Store) RecordAudit(ctx context.Context, entry store.AuditEntry) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO audit_log (action, user_id, subject, ip_address, detail, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		entry.Action, entry.UserID, entry.Subject, entry.IPAddress, entry.Detail, now())
+	return mapError(err)
+}
+
+func (s *Store) ListAudit(ctx context.Context, before, limit int) ([]store.AuditEntry, error) {
+	var a args
+	query := `SELECT id, action, user_id, subject, ip_address, detail, created_at FROM audit_log`
+	if before != 0 {
+		query += ` WHERE id < ` + a.add(before)
+	}
+	query += ` ORDER BY id DESC LIMIT ` + a.add(limit)
+
+	rows, err := s.db.QueryContext(ctx, query, a...)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	entries := make([]store.AuditEntry, 0)
+	for rows.Next() {
+		var entry store.AuditEntry
+		var userID sql.NullInt64
+		err := rows.Scan(&entry.ID, &entry.Action, &userID, &entry.Subject, &entry.IPAddress, &entry.Detail, &entry.CreatedAt)
+		if err != nil {
+			return nil, mapError(err)
+		}
+		entry.UserID = nullableInt(userID)
+		entries = append(entries, entry)
+	}
+	return entries, mapError(rows.Err())
+}
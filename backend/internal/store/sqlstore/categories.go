@@ -0,0 +1,126 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"web-forum/internal/store"
+)
+
+const categoryColumns = `id, name, description, parent_id, position, created_at`
+
+func scanCategory(row scanner) (store.Category, error) {
+	var category store.Category
+	var parentID sql.NullInt64
+	err := row.Scan(&category.ID, &category.Name, &category.Description, &parentID, &category.Position, &category.CreatedAt)
+	if err != nil {
+		return store.Category{}, mapError(err)
+	}
+
+	category.ParentID = nullableInt(parentID)
+	return category, nil
+}
+
+func (s *Store) ListCategories(ctx context.Context) ([]store.Category, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+categoryColumns+` FROM categories ORDER BY position, id`)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	categories := make([]store.Category, 0)
+	for rows.Next() {
+		category, err := scanCategory(rows)
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+	return categories, mapError(rows.Err())
+}
+
+func (s *Store) GetCategory(ctx context.Context, id int) (store.Category, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+categoryColumns+` FROM categories WHERE id = $1`, id)
+	return scanCategory(row)
+}
+
+func (s *Store) CreateCategory(ctx context.Context, name, description string, parentID *int, position int) (store.Category, error) {
+	var category store.Category
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		// Checked up front, as the foreign key would let a category name the
+		// id it is about to get as its own parent
+		if err := checkCategory(ctx, tx, parentID); err != nil {
+			return err
+		}
+
+		var err error
+		category, err = scanCategory(tx.QueryRowContext(ctx,
+			`INSERT INTO categories (name, description, parent_id, position, created_at) VALUES ($1, $2, $3, $4, $5) RETURNING `+categoryColumns,
+			name, description, parentID, position, now()))
+		return err
+	})
+	return category, err
+}
+
+func (s *Store) UpdateCategory(ctx context.Context, id int, name, description string, parentID *int, position int) (store.Category, error) {
+	var category store.Category
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		if parentID != nil {
+			// The new parent must not be the category or any category below it
+			var cycle bool
+			err := tx.QueryRowContext(ctx, `WITH RECURSIVE subtree AS (
+				SELECT id FROM categories WHERE id = $1
+				UNION
+				SELECT c.id FROM categories c JOIN subtree ON c.parent_id = subtree.id
+			) SELECT EXISTS (SELECT 1 FROM subtree WHERE id = $2)`, id, *parentID).Scan(&cycle)
+			if err != nil {
+				return mapError(err)
+			}
+			if cycle {
+				return store.ErrCategoryCycle
+			}
+		}
+
+		var err error
+		category, err = scanCategory(tx.QueryRowContext(ctx,
+			`UPDATE categories SET name = $1, description = $2, parent_id = $3, position = $4 WHERE id = $5 RETURNING `+categoryColumns,
+			name, description, parentID, position, id))
+		return err
+	})
+	return category, err
+}
+
+func (s *Store) DeleteCategory(ctx context.Context, id int) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		var parentID sql.NullInt64
+		err := tx.QueryRowContext(ctx, `SELECT parent_id FROM categories WHERE id = $1`, id).Scan(&parentID)
+		if err != nil {
+			return mapError(err)
+		}
+
+		// Move the subcategories and topics up a level. A subcategory may then
+		// share a name with a sibling, or with the category itself, which is
+		// still there, and the unique index turns it down.
+		_, err = tx.ExecContext(ctx, `UPDATE categories SET parent_id = $1 WHERE parent_id = $2`, parentID, id)
+		if err != nil {
+			return mapError(err)
+		}
+		_, err = tx.ExecContext(ctx, `UPDATE topics SET category_id = $1 WHERE category_id = $2`, parentID, id)
+		if err != nil {
+			return mapError(err)
+		}
+
+		return expectRow(tx.ExecContext(ctx, `DELETE FROM categories WHERE id = $1`, id))
+	})
+}
+
+// checkCategory returns ErrNotFound unless categoryID is nil or names a
+// category. SQLite has no foreign key on topics.category_id to do it.
+func checkCategory(ctx context.Context, tx *sql.Tx, categoryID *int) error {
+	if categoryID == nil {
+		return nil
+	}
+
+	var id int
+	err := tx.QueryRowContext(ctx, `SELECT id FROM categories WHERE id = $1`, *categoryID).Scan(&id)
+	return mapError(err)
+}
@@ -0,0 +1,222 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"web-forum/internal/search"
+	"web-forum/internal/store"
+)
+
+// commentSelect mirrors postSelect. $1 is always the viewer.
+const commentSelect = `
+SELECT c.id, c.post_id, p.topic_id, c.parent_id, c.content, c.created_by, c.created_at, c.updated_at,
+	COALESCE(u.username, '') AS username,
+	COALESCE(SUM(CASE WHEN r.reaction = 1 THEN 1 ELSE 0 END), 0) AS like_count,
+	COALESCE(SUM(CASE WHEN r.reaction = -1 THEN 1 ELSE 0 END), 0) AS dislike_count,
+	MAX(CASE WHEN r.user_id = $1 THEN r.reaction END) AS user_reaction
+FROM comments c
+JOIN posts p ON p.id = c.post_id
+LEFT JOIN users u ON u.id = c.created_by
+LEFT JOIN comment_reactions r ON r.comment_id = c.id`
+
+const commentGroupBy = `
+GROUP BY c.id, p.topic_id, u.username`
+
+// commentColumns are the columns of commentSelect, for selecting from it as a subquery
+const commentColumns = `id, post_id, topic_id, parent_id, content, created_by, created_at, updated_at,
+	username, like_count, dislike_count, user_reaction`
+
+func scanComment(row scanner, extra ...any) (store.Comment, error) {
+	var comment store.Comment
+	var parentID, userReaction sql.NullInt64
+
+	dest := []any{&comment.ID, &comment.PostID, &comment.TopicID, &parentID, &comment.Content, &comment.CreatedBy,
+		&comment.CreatedAt, &comment.UpdatedAt, &comment.Username, &comment.LikeCount, &comment.DislikeCount, &userReaction}
+	err := row.Scan(append(dest, extra...)...)
+	if err != nil {
+		return store.Comment{}, mapError(err)
+	}
+
+	comment.ParentID = nullableInt(parentID)
+	comment.NetScore = comment.LikeCount - comment.DislikeCount
+	comment.UserReaction = nullableInt(userReaction)
+	return comment, nil
+}
+
+func (s *Store) ListComments(ctx context.Context, postID, viewerID int, opts store.ListOptions) (store.Page[store.Comment], error) {
+	var a args
+	a.add(viewerID)
+	inner := commentSelect + ` WHERE c.post_id = ` + a.add(postID) + commentGroupBy
+
+	return s.listComments(ctx, inner, &a, opts)
+}
+
+func (s *Store) ListThread(ctx context.Context, postID, viewerID int, opts store.ListOptions) (store.Page[store.ThreadComment], error) {
+	var a args
+	a.add(viewerID)
+	inner := commentSelect + ` WHERE c.post_id = ` + a.add(postID) + ` AND c.parent_id IS NULL` + commentGroupBy
+
+	roots, err := s.listComments(ctx, inner, &a, opts)
+	if err != nil {
+		return store.Page[store.ThreadComment]{}, err
+	}
+	if len(roots.Items) == 0 {
+		return store.Page[store.ThreadComment]{Items: []store.ThreadComment{}}, nil
+	}
+
+	// Walk down from the roots on this page to every reply below them
+	var b args
+	b.add(viewerID)
+	rootIDs := make([]string, len(roots.Items))
+	for i, root := range roots.Items {
+		rootIDs[i] = b.add(root.ID)
+	}
+	query := `WITH RECURSIVE branch (id) AS (
+		SELECT id FROM comments WHERE parent_id IN (` + strings.Join(rootIDs, `, `) + `)
+		UNION ALL
+		SELECT c.id FROM comments c JOIN branch ON c.parent_id = branch.id
+	)` + commentSelect + ` WHERE c.id IN (SELECT id FROM branch)` + commentGroupBy
+
+	rows, err := s.db.QueryContext(ctx, query, b...)
+	if err != nil {
+		return store.Page[store.ThreadComment]{}, mapError(err)
+	}
+	defer rows.Close()
+
+	replies := make([]store.Comment, 0)
+	for rows.Next() {
+		reply, err := scanComment(rows)
+		if err != nil {
+			return store.Page[store.ThreadComment]{}, err
+		}
+		replies = append(replies, reply)
+	}
+	if err := rows.Err(); err != nil {
+		return store.Page[store.ThreadComment]{}, mapError(err)
+	}
+
+	return store.Page[store.ThreadComment]{Items: store.Thread(roots.Items, replies), Next: roots.Next}, nil
+}
+
+// listComments runs one page of a listing over inner, a commentSelect with
+// its WHERE clause and commentGroupBy
+func (s *Store) listComments(ctx context.Context, inner string, a *args, opts store.ListOptions) (store.Page[store.Comment], error) {
+	rows, err := s.db.QueryContext(ctx, s.listQuery(inner, commentColumns, a, opts, false), *a...)
+	if err != nil {
+		return store.Page[store.Comment]{}, mapError(err)
+	}
+	defer rows.Close()
+
+	comments := make([]listed[store.Comment], 0)
+	for rows.Next() {
+		var score float64
+		var extra []any
+		if opts.Sort.ByScore() {
+			extra = append(extra, &score)
+		}
+
+		comment, err := scanComment(rows, extra...)
+		if err != nil {
+			return store.Page[store.Comment]{}, err
+		}
+
+		cursor := comment.Cursor(opts.Sort)
+		if opts.Sort.ByScore() {
+			// Resume from the key as the database computed it, not a Go recomputation
+			cursor.Score = score
+		}
+		comments = append(comments, listed[store.Comment]{comment, cursor})
+	}
+	if err := rows.Err(); err != nil {
+		return store.Page[store.Comment]{}, mapError(err)
+	}
+
+	return pageOf(comments, opts.Limit), nil
+}
+
+func (s *Store) GetComment(ctx context.Context, id, viewerID int) (store.Comment, error) {
+	row := s.db.QueryRowContext(ctx, commentSelect+` WHERE c.id = $2`+commentGroupBy, viewerID, id)
+	return scanComment(row)
+}
+
+func (s *Store) CreateComment(ctx context.Context, postID int, parentID *int, content string, createdBy int) (store.Comment, error) {
+	createdAt := now()
+
+	var id int
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		if parentID != nil {
+			var parentPostID int
+			err := tx.QueryRowContext(ctx, `SELECT post_id FROM comments WHERE id = $1`, *parentID).Scan(&parentPostID)
+			if errors.Is(err, sql.ErrNoRows) || err == nil && parentPostID != postID {
+				return store.ErrInvalidParent
+			}
+			if err != nil {
+				return mapError(err)
+			}
+		}
+
+		err := tx.QueryRowContext(ctx,
+			`INSERT INTO comments (post_id, parent_id, content, created_by, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $5) RETURNING id`,
+			postID, parentID, content, createdBy, createdAt).Scan(&id)
+		if err != nil {
+			return mapError(err)
+		}
+		return addCommentRevision(ctx, tx, id, content, createdBy, createdAt)
+	})
+	if err != nil {
+		return store.Comment{}, err
+	}
+
+	s.indexComment(ctx, id)
+	return s.GetComment(ctx, id, createdBy)
+}
+
+func (s *Store) UpdateComment(ctx context.Context, id int, content string, editedBy int) error {
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		editedAt := now()
+		err := expectRow(tx.ExecContext(ctx,
+			`UPDATE comments SET content = $1, updated_at = $2 WHERE id = $3`,
+			content, editedAt, id))
+		if err != nil {
+			return err
+		}
+		return addCommentRevision(ctx, tx, id, content, editedBy, editedAt)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.indexComment(ctx, id)
+	return nil
+}
+
+// DeleteComment moves the replies to the comment up to its parent, as
+// DeleteCategory does with subcategories, so other users' replies survive it
+func (s *Store) DeleteComment(ctx context.Context, id int) error {
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		return deleteComment(ctx, tx, id)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.updateIndex(ctx, func(index *search.Index) error {
+		index.Remove(store.SearchComment, id)
+		return nil
+	})
+	return nil
+}
+
+// deleteComment deletes one comment after moving its replies up a level.
+// Reactions and revisions go through ON DELETE CASCADE.
+func deleteComment(ctx context.Context, tx *sql.Tx, id int) error {
+	_, err := tx.ExecContext(ctx,
+		`UPDATE comments SET parent_id = (SELECT parent_id FROM comments WHERE id = $1) WHERE parent_id = $1`, id)
+	if err != nil {
+		return mapError(err)
+	}
+	return expectRow(tx.ExecContext(ctx, `DELETE FROM comments WHERE id = $1`, id))
+}
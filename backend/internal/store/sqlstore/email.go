@@ -0,0 +1,40 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"web-forum/internal/store"
+)
+
+func (s *Store) CreateEmailToken(ctx context.Context, tokenHash string, token store.EmailToken) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx,
+			`DELETE FROM email_tokens WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL`,
+			token.UserID, string(token.Purpose))
+		if err != nil {
+			return mapError(err)
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO email_tokens (token_hash, user_id, purpose, email, created_at, expires_at)
+			VALUES ($1, $2, $3, $4, $5, $6)`,
+			tokenHash, token.UserID, string(token.Purpose), token.Email, now(), token.ExpiresAt.UTC())
+		return mapError(err)
+	})
+}
+
+func (s *Store) UseEmailToken(ctx context.Context, tokenHash string, purpose store.EmailPurpose) (store.EmailToken, error) {
+	var token store.EmailToken
+	// Marking the token used in the same statement that reads it means two
+	// requests with the same link cannot both win
+	err := s.db.QueryRowContext(ctx,
+		`UPDATE email_tokens SET used_at = $1
+		WHERE token_hash = $2 AND purpose = $3 AND used_at IS NULL AND expires_at > $1
+		RETURNING user_id, purpose, email, created_at, expires_at`,
+		now(), tokenHash, string(purpose)).
+		Scan(&token.UserID, &token.Purpose, &token.Email, &token.CreatedAt, &token.ExpiresAt)
+	if err != nil {
+		return store.EmailToken{}, mapError(err)
+	}
+	return token, nil
+}
@@ -0,0 +1,25 @@
+package sqlstore
+
+import (
+	"context"
+	"web-forum/internal/store"
+)
+
+func (s *Store) GetIdentity(ctx context.Context, issuer, subject string) (store.Identity, error) {
+	var identity store.Identity
+	err := s.db.QueryRowContext(ctx,
+		`SELECT issuer, subject, user_id, created_at FROM user_identities WHERE issuer = $1 AND subject = $2`,
+		issuer, subject).
+		Scan(&identity.Issuer, &identity.Subject, &identity.UserID, &identity.CreatedAt)
+	if err != nil {
+		return store.Identity{}, mapError(err)
+	}
+	return identity, nil
+}
+
+func (s *Store) LinkIdentity(ctx context.Context, identity store.Identity) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO user_identities (issuer, subject, user_id, created_at) VALUES ($1, $2, $3, $4)`,
+		identity.Issuer, identity.Subject, identity.UserID, now())
+	return mapError(err)
+}
@@ -0,0 +1,101 @@
+package sqlstore
+
+import (
+	"fmt"
+	"web-forum/internal/store"
+)
+
+// sortKey returns the SQL expression a listing is ordered by and whether it
+// runs descending. Expressions refer to the columns of the listed subquery.
+func (s *Store) sortKey(sort store.Sort) (string, bool) {
+	switch sort {
+	case store.SortOldest:
+		return `created_at`, false
+	case store.SortLikes:
+		return `like_count`, true
+	case store.SortPopular:
+		return `(like_count - dislike_count)`, true
+	case store.SortHot:
+		return s.hotScore(`(like_count - dislike_count)`, `created_at`), true
+	}
+	return `created_at`, true
+}
+
+// hotScore is store.HotScore written in SQL
+func (s *Store) hotScore(net, createdAt string) string {
+	if s.dialect == "sqlite" {
+		return fmt.Sprintf(`(SIGN(%[1]s) * log10(MAX(ABS(%[1]s), 1)) + ((julianday(%[2]s) - 2440587.5) * 86400.0 - %[3]d) / %[4]d.0)`,
+			net, createdAt, store.HotEpoch, store.HotDecay)
+	}
+	return fmt.Sprintf(`(SIGN(%[1]s)::float8 * LOG(GREATEST(ABS(%[1]s), 1)::float8) + (EXTRACT(EPOCH FROM %[2]s)::float8 - %[3]d) / %[4]d)`,
+		net, createdAt, store.HotEpoch, store.HotDecay)
+}
+
+// listQuery wraps inner, an aggregate select producing columns along with
+// like_count, dislike_count and created_at, so one page of it can be ordered
+// and resumed on the computed counts. Score based sorts append their sort key
+// as an extra last column, to be scanned into the cursor. With pinnedFirst,
+// rows with a pinned_at come before the rest, each group in the sort order.
+func (s *Store) listQuery(inner, columns string, a *args, opts store.ListOptions, pinnedFirst bool) string {
+	key, desc := s.sortKey(opts.Sort)
+
+	query := `SELECT ` + columns
+	if opts.Sort.ByScore() {
+		query += `, ` + key
+	}
+	query += ` FROM (` + inner + `) AS listed`
+
+	if opts.After != nil {
+		var after any = opts.After.CreatedAt
+		if opts.Sort.ByScore() {
+			after = opts.After.Score
+		}
+
+		op := `>`
+		if desc {
+			op = `<`
+		}
+		where := `(` + key + `, id) ` + op + ` (` + a.add(after) + `, ` + a.add(opts.After.ID) + `)`
+		if pinnedFirst {
+			// Past a pinned row come the later pinned rows and every other one
+			if opts.After.Pinned {
+				where = `(pinned_at IS NULL OR ` + where + `)`
+			} else {
+				where = `pinned_at IS NULL AND ` + where
+			}
+		}
+		query += ` WHERE ` + where
+	}
+
+	dir := ` ASC`
+	if desc {
+		dir = ` DESC`
+	}
+	query += ` ORDER BY `
+	if pinnedFirst {
+		query += `(pinned_at IS NULL), `
+	}
+	return query + key + dir + `, id` + dir + ` LIMIT ` + a.add(opts.Limit+1)
+}
+
+// listed is one row of a listing with the cursor that resumes after it
+type listed[T any] struct {
+	item   T
+	cursor store.Cursor
+}
+
+// pageOf trims a result fetched with limit+1 rows down to one page, using the
+// extra row only to tell whether another page follows
+func pageOf[T any](rows []listed[T], limit int) store.Page[T] {
+	items := make([]T, 0, len(rows))
+	for i := 0; i < len(rows) && i < limit; i++ {
+		items = append(items, rows[i].item)
+	}
+
+	page := store.Page[T]{Items: items}
+	if len(rows) > limit {
+		next := rows[limit-1].cursor
+		page.Next = &next
+	}
+	return page
+}
@@ -0,0 +1,6 @@
+DROP TABLE comment_reactions;
+DROP TABLE post_reactions;
+DROP TABLE comments;
+DROP TABLE posts;
+DROP TABLE topics;
+DROP TABLE users;
@@ -0,0 +1,53 @@
+-- IF NOT EXISTS lets this baseline run against databases that were created
+-- by hand in the Supabase dashboard before migrations existed
+
+CREATE TABLE IF NOT EXISTS users (
+	id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+	username TEXT NOT NULL UNIQUE,
+	password TEXT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS topics (
+	id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+	title TEXT NOT NULL UNIQUE,
+	created_by BIGINT NOT NULL REFERENCES users (id),
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS posts (
+	id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+	topic_id BIGINT NOT NULL REFERENCES topics (id) ON DELETE CASCADE,
+	title TEXT NOT NULL,
+	content TEXT NOT NULL,
+	created_by BIGINT NOT NULL REFERENCES users (id),
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS posts_topic_id_idx ON posts (topic_id);
+
+CREATE TABLE IF NOT EXISTS comments (
+	id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+	post_id BIGINT NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+	content TEXT NOT NULL,
+	created_by BIGINT NOT NULL REFERENCES users (id),
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS comments_post_id_idx ON comments (post_id);
+
+CREATE TABLE IF NOT EXISTS post_reactions (
+	post_id BIGINT NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+	user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	reaction SMALLINT NOT NULL CHECK (reaction IN (1, -1)),
+	UNIQUE (post_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS comment_reactions (
+	comment_id BIGINT NOT NULL REFERENCES comments (id) ON DELETE CASCADE,
+	user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	reaction SMALLINT NOT NULL CHECK (reaction IN (1, -1)),
+	UNIQUE (comment_id, user_id)
+);
@@ -0,0 +1,3 @@
+ALTER TABLE comments DROP COLUMN search;
+ALTER TABLE posts DROP COLUMN search;
+ALTER TABLE topics DROP COLUMN search;
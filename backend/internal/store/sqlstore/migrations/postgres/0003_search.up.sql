@@ -0,0 +1,15 @@
+-- Full-text search vectors, kept up to date by Postgres itself. Titles weigh
+-- more than bodies when ranking.
+ALTER TABLE topics ADD COLUMN search tsvector
+	GENERATED ALWAYS AS (setweight(to_tsvector('english', title), 'A')) STORED;
+
+ALTER TABLE posts ADD COLUMN search tsvector
+	GENERATED ALWAYS AS (setweight(to_tsvector('english', title), 'A') ||
+		setweight(to_tsvector('english', content), 'B')) STORED;
+
+ALTER TABLE comments ADD COLUMN search tsvector
+	GENERATED ALWAYS AS (setweight(to_tsvector('english', content), 'B')) STORED;
+
+CREATE INDEX topics_search_idx ON topics USING GIN (search);
+CREATE INDEX posts_search_idx ON posts USING GIN (search);
+CREATE INDEX comments_search_idx ON comments USING GIN (search);
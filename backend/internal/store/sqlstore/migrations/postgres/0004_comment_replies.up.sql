@@ -0,0 +1,4 @@
+-- Replies point at the comment they answer and go when it is deleted
+ALTER TABLE comments ADD COLUMN parent_id BIGINT REFERENCES comments (id) ON DELETE CASCADE;
+
+CREATE INDEX comments_parent_id_idx ON comments (parent_id);
@@ -0,0 +1,11 @@
+ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT 'member'
+	CHECK (role IN ('member', 'moderator', 'admin'));
+
+CREATE TABLE topic_moderators (
+	topic_id BIGINT NOT NULL REFERENCES topics (id) ON DELETE CASCADE,
+	user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	PRIMARY KEY (topic_id, user_id)
+);
+
+-- Permissions are loaded by user on every request
+CREATE INDEX topic_moderators_user_id_idx ON topic_moderators (user_id);
@@ -0,0 +1,23 @@
+CREATE TABLE sessions (
+	id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+	user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	user_agent TEXT NOT NULL DEFAULT '',
+	ip_address TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMPTZ NOT NULL,
+	last_used_at TIMESTAMPTZ NOT NULL,
+	expires_at TIMESTAMPTZ NOT NULL,
+	revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX sessions_user_id_idx ON sessions (user_id);
+
+-- Every refresh token a session was ever given, so a token presented after
+-- it was exchanged can be recognised as reuse
+CREATE TABLE refresh_tokens (
+	token_hash TEXT PRIMARY KEY,
+	session_id BIGINT NOT NULL REFERENCES sessions (id) ON DELETE CASCADE,
+	created_at TIMESTAMPTZ NOT NULL,
+	used_at TIMESTAMPTZ
+);
+
+CREATE INDEX refresh_tokens_session_id_idx ON refresh_tokens (session_id);
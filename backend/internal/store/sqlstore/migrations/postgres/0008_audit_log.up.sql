@@ -0,0 +1,12 @@
+-- Entries outlive the user they concern, only user_id is cleared
+CREATE TABLE audit_log (
+	id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+	action TEXT NOT NULL,
+	user_id BIGINT REFERENCES users (id) ON DELETE SET NULL,
+	subject TEXT NOT NULL DEFAULT '',
+	ip_address TEXT NOT NULL DEFAULT '',
+	detail TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX audit_log_user_id_idx ON audit_log (user_id);
@@ -0,0 +1,19 @@
+-- Emails are stored lowercased, so the unique index is case-insensitive
+ALTER TABLE users ADD COLUMN email TEXT;
+ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMPTZ;
+
+CREATE UNIQUE INDEX users_email_idx ON users (email);
+
+-- Single-use links sent by email. email is the address a token was sent to,
+-- so verifying an address that has since changed does nothing.
+CREATE TABLE email_tokens (
+	token_hash TEXT PRIMARY KEY,
+	user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	purpose TEXT NOT NULL CHECK (purpose IN ('verify', 'reset')),
+	email TEXT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL,
+	expires_at TIMESTAMPTZ NOT NULL,
+	used_at TIMESTAMPTZ
+);
+
+CREATE INDEX email_tokens_user_id_idx ON email_tokens (user_id);
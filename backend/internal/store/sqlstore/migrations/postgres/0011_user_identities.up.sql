@@ -0,0 +1,11 @@
+-- Accounts at OpenID Connect providers users sign in with. A subject is only
+-- unique within its issuer.
+CREATE TABLE user_identities (
+	issuer TEXT NOT NULL,
+	subject TEXT NOT NULL,
+	user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	created_at TIMESTAMPTZ NOT NULL,
+	PRIMARY KEY (issuer, subject)
+);
+
+CREATE INDEX user_identities_user_id_idx ON user_identities (user_id);
@@ -0,0 +1,4 @@
+ALTER TABLE topics ADD COLUMN description TEXT NOT NULL DEFAULT '';
+
+-- Set while the topic is archived and its posts are read-only
+ALTER TABLE topics ADD COLUMN archived_at TIMESTAMPTZ;
@@ -0,0 +1,3 @@
+DROP INDEX topics_category_id_idx;
+ALTER TABLE topics DROP COLUMN category_id;
+DROP TABLE categories;
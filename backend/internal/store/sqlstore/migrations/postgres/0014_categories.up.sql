@@ -0,0 +1,17 @@
+-- Categories group topics and nest under a parent category. DeleteCategory
+-- moves subcategories and topics up a level before deleting one.
+CREATE TABLE categories (
+	id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+	name TEXT NOT NULL,
+	description TEXT NOT NULL DEFAULT '',
+	parent_id BIGINT REFERENCES categories (id),
+	position INTEGER NOT NULL DEFAULT 0,
+	created_at TIMESTAMPTZ NOT NULL
+);
+
+-- Names are unique among siblings, top-level categories included
+CREATE UNIQUE INDEX categories_parent_id_name_idx ON categories (COALESCE(parent_id, 0), name);
+
+ALTER TABLE topics ADD COLUMN category_id BIGINT REFERENCES categories (id);
+
+CREATE INDEX topics_category_id_idx ON topics (category_id);
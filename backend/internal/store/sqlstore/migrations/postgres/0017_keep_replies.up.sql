@@ -0,0 +1,5 @@
+-- Deleting a comment moves its replies up to its parent instead of taking
+-- them with it, so the foreign key no longer cascades. A delete that missed a
+-- reply now fails rather than removing it.
+ALTER TABLE comments DROP CONSTRAINT comments_parent_id_fkey;
+ALTER TABLE comments ADD CONSTRAINT comments_parent_id_fkey FOREIGN KEY (parent_id) REFERENCES comments (id);
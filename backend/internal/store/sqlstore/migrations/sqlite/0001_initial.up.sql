@@ -0,0 +1,53 @@
+-- IF NOT EXISTS lets this baseline adopt database files created before
+-- migrations existed
+
+CREATE TABLE IF NOT EXISTS users (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	username TEXT NOT NULL UNIQUE,
+	password TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS topics (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	title TEXT NOT NULL UNIQUE,
+	created_by INTEGER NOT NULL REFERENCES users (id),
+	created_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS posts (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	topic_id INTEGER NOT NULL REFERENCES topics (id) ON DELETE CASCADE,
+	title TEXT NOT NULL,
+	content TEXT NOT NULL,
+	created_by INTEGER NOT NULL REFERENCES users (id),
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS posts_topic_id_idx ON posts (topic_id);
+
+CREATE TABLE IF NOT EXISTS comments (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	post_id INTEGER NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+	content TEXT NOT NULL,
+	created_by INTEGER NOT NULL REFERENCES users (id),
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS comments_post_id_idx ON comments (post_id);
+
+CREATE TABLE IF NOT EXISTS post_reactions (
+	post_id INTEGER NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+	user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	reaction INTEGER NOT NULL CHECK (reaction IN (1, -1)),
+	UNIQUE (post_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS comment_reactions (
+	comment_id INTEGER NOT NULL REFERENCES comments (id) ON DELETE CASCADE,
+	user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	reaction INTEGER NOT NULL CHECK (reaction IN (1, -1)),
+	UNIQUE (comment_id, user_id)
+);
@@ -0,0 +1,2 @@
+DROP INDEX comments_post_id_created_at_idx;
+DROP INDEX posts_topic_id_created_at_idx;
@@ -0,0 +1,3 @@
+-- Keyset pagination walks posts and comments newest first
+CREATE INDEX posts_topic_id_created_at_idx ON posts (topic_id, created_at DESC, id DESC);
+CREATE INDEX comments_post_id_created_at_idx ON comments (post_id, created_at DESC, id DESC);
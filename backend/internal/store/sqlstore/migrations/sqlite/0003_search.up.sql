@@ -0,0 +1,4 @@
+-- SQLite databases are searched through an in-process index built from the
+-- tables at startup, so there is nothing to store. This keeps the migration
+-- numbers in step with Postgres.
+SELECT 1;
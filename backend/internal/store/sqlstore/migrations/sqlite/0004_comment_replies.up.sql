@@ -0,0 +1,5 @@
+-- No foreign key here: SQLite cannot drop a column that is part of one, which
+-- would make this migration irreversible. DeleteComment removes replies itself.
+ALTER TABLE comments ADD COLUMN parent_id INTEGER;
+
+CREATE INDEX comments_parent_id_idx ON comments (parent_id);
@@ -0,0 +1,2 @@
+DROP TABLE topic_moderators;
+ALTER TABLE users DROP COLUMN role;
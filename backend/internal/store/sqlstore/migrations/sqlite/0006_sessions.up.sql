@@ -0,0 +1,23 @@
+CREATE TABLE sessions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	user_agent TEXT NOT NULL DEFAULT '',
+	ip_address TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP NOT NULL,
+	last_used_at TIMESTAMP NOT NULL,
+	expires_at TIMESTAMP NOT NULL,
+	revoked_at TIMESTAMP
+);
+
+CREATE INDEX sessions_user_id_idx ON sessions (user_id);
+
+-- Every refresh token a session was ever given, so a token presented after
+-- it was exchanged can be recognised as reuse
+CREATE TABLE refresh_tokens (
+	token_hash TEXT PRIMARY KEY,
+	session_id INTEGER NOT NULL REFERENCES sessions (id) ON DELETE CASCADE,
+	created_at TIMESTAMP NOT NULL,
+	used_at TIMESTAMP
+);
+
+CREATE INDEX refresh_tokens_session_id_idx ON refresh_tokens (session_id);
@@ -0,0 +1,13 @@
+CREATE TABLE personal_tokens (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	name TEXT NOT NULL,
+	scope TEXT NOT NULL CHECK (scope IN ('read', 'write')),
+	token_hash TEXT NOT NULL UNIQUE,
+	created_at TIMESTAMP NOT NULL,
+	last_used_at TIMESTAMP,
+	expires_at TIMESTAMP NOT NULL,
+	revoked_at TIMESTAMP
+);
+
+CREATE INDEX personal_tokens_user_id_idx ON personal_tokens (user_id);
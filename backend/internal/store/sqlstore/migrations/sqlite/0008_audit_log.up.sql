@@ -0,0 +1,12 @@
+-- Entries outlive the user they concern, only user_id is cleared
+CREATE TABLE audit_log (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	action TEXT NOT NULL,
+	user_id INTEGER REFERENCES users (id) ON DELETE SET NULL,
+	subject TEXT NOT NULL DEFAULT '',
+	ip_address TEXT NOT NULL DEFAULT '',
+	detail TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX audit_log_user_id_idx ON audit_log (user_id);
@@ -0,0 +1,15 @@
+-- enabled_at is NULL while the enrolment waits for its first code
+CREATE TABLE user_totp (
+	user_id INTEGER PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+	secret TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	enabled_at TIMESTAMP,
+	last_step BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE TABLE recovery_codes (
+	user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	code_hash TEXT NOT NULL,
+	used_at TIMESTAMP,
+	PRIMARY KEY (user_id, code_hash)
+);
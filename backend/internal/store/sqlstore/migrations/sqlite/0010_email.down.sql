@@ -0,0 +1,4 @@
+DROP TABLE email_tokens;
+DROP INDEX users_email_idx;
+ALTER TABLE users DROP COLUMN email_verified_at;
+ALTER TABLE users DROP COLUMN email;
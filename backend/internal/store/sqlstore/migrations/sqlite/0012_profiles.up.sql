@@ -0,0 +1,7 @@
+ALTER TABLE users ADD COLUMN display_name TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN bio TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN avatar_url TEXT NOT NULL DEFAULT '';
+
+-- Deleted accounts stay behind, scrubbed, as the author of what they wrote
+-- and of the topics they started
+ALTER TABLE users ADD COLUMN deleted_at TIMESTAMP;
@@ -0,0 +1,2 @@
+ALTER TABLE topics DROP COLUMN archived_at;
+ALTER TABLE topics DROP COLUMN description;
@@ -0,0 +1,19 @@
+-- Categories group topics and nest under a parent category. DeleteCategory
+-- moves subcategories and topics up a level before deleting one.
+CREATE TABLE categories (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL,
+	description TEXT NOT NULL DEFAULT '',
+	parent_id INTEGER REFERENCES categories (id),
+	position INTEGER NOT NULL DEFAULT 0,
+	created_at TIMESTAMP NOT NULL
+);
+
+-- Names are unique among siblings, top-level categories included
+CREATE UNIQUE INDEX categories_parent_id_name_idx ON categories (COALESCE(parent_id, 0), name);
+
+-- No foreign key here: SQLite cannot drop a column that is part of one, which
+-- would make this migration irreversible. The store checks the category.
+ALTER TABLE topics ADD COLUMN category_id INTEGER;
+
+CREATE INDEX topics_category_id_idx ON topics (category_id);
@@ -0,0 +1,5 @@
+-- Set while the post is pinned to the top of its topic
+ALTER TABLE posts ADD COLUMN pinned_at TIMESTAMP;
+
+-- Set while the post is locked and takes no new comments
+ALTER TABLE posts ADD COLUMN locked_at TIMESTAMP;
@@ -0,0 +1,2 @@
+DROP TABLE comment_revisions;
+DROP TABLE post_revisions;
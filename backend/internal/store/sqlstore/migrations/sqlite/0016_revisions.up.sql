@@ -0,0 +1,31 @@
+-- Every version of a post, numbered from 1, the original. The latest is the
+-- post as it reads now. edited_by wrote the version, at edited_at.
+CREATE TABLE post_revisions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	post_id INTEGER NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+	number INTEGER NOT NULL,
+	title TEXT NOT NULL,
+	content TEXT NOT NULL,
+	edited_by INTEGER NOT NULL REFERENCES users (id),
+	edited_at TIMESTAMP NOT NULL,
+	UNIQUE (post_id, number)
+);
+
+-- Every version of a comment, like post_revisions
+CREATE TABLE comment_revisions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	comment_id INTEGER NOT NULL REFERENCES comments (id) ON DELETE CASCADE,
+	number INTEGER NOT NULL,
+	content TEXT NOT NULL,
+	edited_by INTEGER NOT NULL REFERENCES users (id),
+	edited_at TIMESTAMP NOT NULL,
+	UNIQUE (comment_id, number)
+);
+
+-- Earlier edits were not kept, so existing posts and comments start out with
+-- their current text as the first version, credited to their author
+INSERT INTO post_revisions (post_id, number, title, content, edited_by, edited_at)
+SELECT id, 1, title, content, created_by, updated_at FROM posts;
+
+INSERT INTO comment_revisions (comment_id, number, content, edited_by, edited_at)
+SELECT id, 1, content, created_by, updated_at FROM comments;
@@ -0,0 +1,3 @@
+-- parent_id has no foreign key in SQLite, so there is nothing to change. This
+-- keeps the migration numbers in step with Postgres.
+SELECT 1;
@@ -0,0 +1,57 @@
+package sqlstore
+
+import (
+	"context"
+	"web-forum/internal/store"
+)
+
+func (s *Store) ModeratedTopics(ctx context.Context, userID int) ([]int, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT topic_id FROM topic_moderators WHERE user_id = $1 ORDER BY topic_id`, userID)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	topicIDs := make([]int, 0)
+	for rows.Next() {
+		var topicID int
+		if err := rows.Scan(&topicID); err != nil {
+			return nil, mapError(err)
+		}
+		topicIDs = append(topicIDs, topicID)
+	}
+	return topicIDs, mapError(rows.Err())
+}
+
+func (s *Store) ListModerators(ctx context.Context, topicID int) ([]store.User, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+userColumns+` FROM users
+		WHERE id IN (SELECT user_id FROM topic_moderators WHERE topic_id = $1) ORDER BY id`, topicID)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	users := make([]store.User, 0)
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, mapError(rows.Err())
+}
+
+func (s *Store) AddModerator(ctx context.Context, topicID, userID int) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO topic_moderators (topic_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`,
+		topicID, userID)
+	return mapError(err)
+}
+
+func (s *Store) RemoveModerator(ctx context.Context, topicID, userID int) error {
+	return expectRow(s.db.ExecContext(ctx,
+		`DELETE FROM topic_moderators WHERE topic_id = $1 AND user_id = $2`, topicID, userID))
+}
@@ -0,0 +1,76 @@
+package sqlstore_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"web-forum/internal/store"
+	"web-forum/internal/store/sqlstore"
+	"web-forum/internal/store/storetest"
+)
+
+// TestPostgres runs the store contract against the PostgreSQL database at
+// TEST_DATABASE_URL, every test in a schema of its own that is dropped after
+// it. Without the variable it is skipped, as there is no server to run on.
+func TestPostgres(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	admin, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("open TEST_DATABASE_URL: %v", err)
+	}
+	t.Cleanup(func() { admin.Close() })
+	if err := admin.Ping(); err != nil {
+		t.Fatalf("connect to TEST_DATABASE_URL: %v", err)
+	}
+
+	var schemas atomic.Int64
+	storetest.Run(t, func(t *testing.T) store.Store {
+		ctx := context.Background()
+
+		schema := fmt.Sprintf("storetest_%d_%d", os.Getpid(), schemas.Add(1))
+		if _, err := admin.ExecContext(ctx, `CREATE SCHEMA `+schema); err != nil {
+			t.Fatalf("create schema: %v", err)
+		}
+		t.Cleanup(func() {
+			if _, err := admin.ExecContext(ctx, `DROP SCHEMA `+schema+` CASCADE`); err != nil {
+				t.Errorf("drop schema: %v", err)
+			}
+		})
+
+		db, err := sqlstore.OpenPostgres(withSearchPath(dsn, schema))
+		if err != nil {
+			t.Fatalf("OpenPostgres: %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+
+		if _, err := db.MigrateUp(ctx); err != nil {
+			t.Fatalf("MigrateUp: %v", err)
+		}
+		return db
+	})
+}
+
+// withSearchPath points the connections of dsn, a URL or key=value
+// connection string, at schema. The driver passes search_path on to the
+// server as a run-time parameter.
+func withSearchPath(dsn, schema string) string {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		u, err := url.Parse(dsn)
+		if err == nil {
+			query := u.Query()
+			query.Set("search_path", schema)
+			u.RawQuery = query.Encode()
+			return u.String()
+		}
+	}
+	return dsn + " search_path=" + schema
+}
@@ -0,0 +1,169 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"web-forum/internal/search"
+	"web-forum/internal/store"
+)
+
+// postSelect joins each post with its author and tallies its reactions in one
+// query. $1 is always the viewer, whose own reaction becomes user_reaction.
+const postSelect = `
+SELECT p.id, p.topic_id, p.title, p.content, p.created_by, p.created_at, p.updated_at,
+	p.pinned_at, p.locked_at,
+	COALESCE(u.username, '') AS username,
+	COALESCE(SUM(CASE WHEN r.reaction = 1 THEN 1 ELSE 0 END), 0) AS like_count,
+	COALESCE(SUM(CASE WHEN r.reaction = -1 THEN 1 ELSE 0 END), 0) AS dislike_count,
+	MAX(CASE WHEN r.user_id = $1 THEN r.reaction END) AS user_reaction
+FROM posts p
+LEFT JOIN users u ON u.id = p.created_by
+LEFT JOIN post_reactions r ON r.post_id = p.id`
+
+const postGroupBy = `
+GROUP BY p.id, u.username`
+
+// postColumns are the columns of postSelect, for selecting from it as a subquery
+const postColumns = `id, topic_id, title, content, created_by, created_at, updated_at,
+	pinned_at, locked_at, username, like_count, dislike_count, user_reaction`
+
+func scanPost(row scanner, extra ...any) (store.Post, error) {
+	var post store.Post
+	var userReaction sql.NullInt64
+	var pinnedAt, lockedAt sql.NullTime
+
+	dest := []any{&post.ID, &post.TopicID, &post.Title, &post.Content, &post.CreatedBy,
+		&post.CreatedAt, &post.UpdatedAt, &pinnedAt, &lockedAt, &post.Username, &post.LikeCount, &post.DislikeCount, &userReaction}
+	err := row.Scan(append(dest, extra...)...)
+	if err != nil {
+		return store.Post{}, mapError(err)
+	}
+
+	post.NetScore = post.LikeCount - post.DislikeCount
+	post.UserReaction = nullableInt(userReaction)
+	if pinnedAt.Valid {
+		post.PinnedAt = &pinnedAt.Time
+	}
+	if lockedAt.Valid {
+		post.LockedAt = &lockedAt.Time
+	}
+	return post, nil
+}
+
+func (s *Store) ListPosts(ctx context.Context, topicID, viewerID int, opts store.ListOptions) (store.Page[store.Post], error) {
+	var a args
+	a.add(viewerID)
+	inner := postSelect + ` WHERE p.topic_id = ` + a.add(topicID) + postGroupBy
+
+	rows, err := s.db.QueryContext(ctx, s.listQuery(inner, postColumns, &a, opts, true), a...)
+	if err != nil {
+		return store.Page[store.Post]{}, mapError(err)
+	}
+	defer rows.Close()
+
+	posts := make([]listed[store.Post], 0)
+	for rows.Next() {
+		var score float64
+		var extra []any
+		if opts.Sort.ByScore() {
+			extra = append(extra, &score)
+		}
+
+		post, err := scanPost(rows, extra...)
+		if err != nil {
+			return store.Page[store.Post]{}, err
+		}
+
+		cursor := post.Cursor(opts.Sort)
+		if opts.Sort.ByScore() {
+			// Resume from the key as the database computed it, not a Go recomputation
+			cursor.Score = score
+		}
+		posts = append(posts, listed[store.Post]{post, cursor})
+	}
+	if err := rows.Err(); err != nil {
+		return store.Page[store.Post]{}, mapError(err)
+	}
+
+	return pageOf(posts, opts.Limit), nil
+}
+
+func (s *Store) GetPost(ctx context.Context, id, viewerID int) (store.Post, error) {
+	row := s.db.QueryRowContext(ctx, postSelect+` WHERE p.id = $2`+postGroupBy, viewerID, id)
+	return scanPost(row)
+}
+
+func (s *Store) CreatePost(ctx context.Context, topicID int, title, content string, createdBy int) (store.Post, error) {
+	createdAt := now()
+
+	var id int
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx,
+			`INSERT INTO posts (topic_id, title, content, created_by, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $5) RETURNING id`,
+			topicID, title, content, createdBy, createdAt).Scan(&id)
+		if err != nil {
+			return mapError(err)
+		}
+		return addPostRevision(ctx, tx, id, title, content, createdBy, createdAt)
+	})
+	if err != nil {
+		return store.Post{}, err
+	}
+
+	s.indexPost(ctx, id)
+	return s.GetPost(ctx, id, createdBy)
+}
+
+func (s *Store) UpdatePost(ctx context.Context, id int, title, content string, editedBy int) error {
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		editedAt := now()
+		err := expectRow(tx.ExecContext(ctx,
+			`UPDATE posts SET title = $1, content = $2, updated_at = $3 WHERE id = $4`,
+			title, content, editedAt, id))
+		if err != nil {
+			return err
+		}
+		return addPostRevision(ctx, tx, id, title, content, editedBy, editedAt)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.indexPost(ctx, id)
+	return nil
+}
+
+func (s *Store) SetPostPinned(ctx context.Context, id int, pinned bool) error {
+	return s.setPostState(ctx, id, "pinned_at", pinned)
+}
+
+func (s *Store) SetPostLocked(ctx context.Context, id int, locked bool) error {
+	return s.setPostState(ctx, id, "locked_at", locked)
+}
+
+// setPostState sets or clears one of the post's state timestamps, keeping it
+// if already set. column is pinned_at or locked_at, never user input.
+func (s *Store) setPostState(ctx context.Context, id int, column string, on bool) error {
+	if !on {
+		return expectRow(s.db.ExecContext(ctx, `UPDATE posts SET `+column+` = NULL WHERE id = $1`, id))
+	}
+	return expectRow(s.db.ExecContext(ctx,
+		`UPDATE posts SET `+column+` = COALESCE(`+column+`, $2) WHERE id = $1`, id, now()))
+}
+
+func (s *Store) DeletePost(ctx context.Context, id int) error {
+	if err := expectRow(s.db.ExecContext(ctx, `DELETE FROM posts WHERE id = $1`, id)); err != nil {
+		return err
+	}
+
+	s.updateIndex(ctx, func(index *search.Index) error {
+		// The comments went with the post through ON DELETE CASCADE
+		index.Remove(store.SearchPost, id)
+		index.RemoveFunc(func(doc search.Document) bool {
+			return doc.Kind == store.SearchComment && doc.PostID == id
+		})
+		return nil
+	})
+	return nil
+}
@@ -0,0 +1,148 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"web-forum/internal/store"
+)
+
+func (s *Store) GetProfile(ctx context.Context, id int) (store.Profile, error) {
+	user, err := s.GetUserByID(ctx, id)
+	if err != nil {
+		return store.Profile{}, err
+	}
+	if user.Deleted() {
+		return store.Profile{}, store.ErrNotFound
+	}
+
+	profile := store.Profile{User: user}
+	// Reactions the user gave their own content do not count
+	err = s.db.QueryRowContext(ctx, `SELECT
+		(SELECT COUNT(*) FROM posts WHERE created_by = $1),
+		(SELECT COUNT(*) FROM comments WHERE created_by = $1),
+		(SELECT COALESCE(SUM(r.reaction), 0) FROM post_reactions r
+			JOIN posts p ON p.id = r.post_id WHERE p.created_by = $1 AND r.user_id <> $1) +
+		(SELECT COALESCE(SUM(r.reaction), 0) FROM comment_reactions r
+			JOIN comments c ON c.id = r.comment_id WHERE c.created_by = $1 AND r.user_id <> $1)`, id).
+		Scan(&profile.PostCount, &profile.CommentCount, &profile.Reputation)
+	if err != nil {
+		return store.Profile{}, mapError(err)
+	}
+	return profile, nil
+}
+
+func (s *Store) UpdateProfile(ctx context.Context, id int, update store.ProfileUpdate) (store.User, error) {
+	var a args
+	var sets []string
+	if update.DisplayName != nil {
+		sets = append(sets, `display_name = `+a.add(*update.DisplayName))
+	}
+	if update.Bio != nil {
+		sets = append(sets, `bio = `+a.add(*update.Bio))
+	}
+	if update.AvatarURL != nil {
+		sets = append(sets, `avatar_url = `+a.add(*update.AvatarURL))
+	}
+
+	if len(sets) == 0 {
+		user, err := s.GetUserByID(ctx, id)
+		if err == nil && user.Deleted() {
+			return store.User{}, store.ErrNotFound
+		}
+		return user, err
+	}
+
+	row := s.db.QueryRowContext(ctx,
+		`UPDATE users SET `+strings.Join(sets, `, `)+` WHERE id = `+a.add(id)+` AND deleted_at IS NULL RETURNING `+userColumns, a...)
+	return scanUser(row)
+}
+
+func (s *Store) SetUsername(ctx context.Context, id int, username string) error {
+	return expectRow(s.db.ExecContext(ctx,
+		`UPDATE users SET username = $1 WHERE id = $2 AND deleted_at IS NULL`, username, id))
+}
+
+func (s *Store) DeleteUser(ctx context.Context, id int, policy store.DeletionPolicy) error {
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		err := expectRow(tx.ExecContext(ctx,
+			`UPDATE users SET username = $1, password = '', role = $2, display_name = '', bio = '', avatar_url = '',
+				email = NULL, email_verified_at = NULL, deleted_at = $3
+			WHERE id = $4 AND deleted_at IS NULL`,
+			store.DeletedUsername(id), string(store.RoleMember), now(), id))
+		if err != nil {
+			return err
+		}
+
+		statements := []string{
+			// Refresh tokens go with their sessions
+			`DELETE FROM sessions WHERE user_id = $1`,
+			`DELETE FROM personal_tokens WHERE user_id = $1`,
+			`DELETE FROM recovery_codes WHERE user_id = $1`,
+			`DELETE FROM user_totp WHERE user_id = $1`,
+			`DELETE FROM email_tokens WHERE user_id = $1`,
+			`DELETE FROM user_identities WHERE user_id = $1`,
+			`DELETE FROM topic_moderators WHERE user_id = $1`,
+		}
+		if policy == store.DeleteContent {
+			statements = append(statements,
+				`DELETE FROM post_reactions WHERE user_id = $1`,
+				`DELETE FROM comment_reactions WHERE user_id = $1`,
+				// Comments and reactions on the posts go through ON DELETE CASCADE
+				`DELETE FROM posts WHERE created_by = $1`,
+			)
+		}
+
+		for _, statement := range statements {
+			if _, err := tx.ExecContext(ctx, statement, id); err != nil {
+				return mapError(err)
+			}
+		}
+
+		if policy == store.DeleteContent {
+			// One at a time, so replies from other users move up past every
+			// comment of this user above them
+			return deleteUserComments(ctx, tx, id)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if policy == store.DeleteContent {
+		// Working out everything that went would take as long as reloading
+		s.dropIndex()
+	}
+	return nil
+}
+
+// deleteUserComments deletes every comment userID wrote, keeping the replies
+// to them like DeleteComment does
+func deleteUserComments(ctx context.Context, tx *sql.Tx, userID int) error {
+	rows, err := tx.QueryContext(ctx, `SELECT id FROM comments WHERE created_by = $1`, userID)
+	if err != nil {
+		return mapError(err)
+	}
+	defer rows.Close()
+
+	ids := make([]int, 0)
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return mapError(err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return mapError(err)
+	}
+	rows.Close()
+
+	for _, id := range ids {
+		if err := deleteComment(ctx, tx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
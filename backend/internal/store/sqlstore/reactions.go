@@ -0,0 +1,58 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"web-forum/internal/store"
+)
+
+func (s *Store) TogglePostReaction(ctx context.Context, postID, userID, reaction int) (store.ReactionChange, error) {
+	return s.toggleReaction(ctx, "post_reactions", "post_id", postID, userID, reaction)
+}
+
+func (s *Store) ToggleCommentReaction(ctx context.Context, commentID, userID, reaction int) (store.ReactionChange, error) {
+	return s.toggleReaction(ctx, "comment_reactions", "comment_id", commentID, userID, reaction)
+}
+
+// toggleReaction removes the reaction if it is a double click, flips it if it
+// exists with the other value and inserts it otherwise, all in one transaction.
+// table and column are constants from the callers above, never user input.
+func (s *Store) toggleReaction(ctx context.Context, table, column string, targetID, userID, reaction int) (store.ReactionChange, error) {
+	var change store.ReactionChange
+
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx,
+			fmt.Sprintf(`DELETE FROM %s WHERE %s = $1 AND user_id = $2 AND reaction = $3`, table, column),
+			targetID, userID, reaction)
+		if err != nil {
+			return err
+		}
+		if n, _ := result.RowsAffected(); n > 0 {
+			change = store.ReactionDeleted
+			return nil
+		}
+
+		result, err = tx.ExecContext(ctx,
+			fmt.Sprintf(`UPDATE %s SET reaction = $3 WHERE %s = $1 AND user_id = $2`, table, column),
+			targetID, userID, reaction)
+		if err != nil {
+			return err
+		}
+		if n, _ := result.RowsAffected(); n > 0 {
+			change = store.ReactionUpdated
+			return nil
+		}
+
+		// A concurrent click may have inserted in the meantime, let the unique
+		// constraint settle it rather than failing the request
+		_, err = tx.ExecContext(ctx,
+			fmt.Sprintf(`INSERT INTO %s (%s, user_id, reaction) VALUES ($1, $2, $3)
+			ON CONFLICT (%s, user_id) DO UPDATE SET reaction = excluded.reaction`, table, column, column),
+			targetID, userID, reaction)
+		change = store.ReactionCreated
+		return err
+	})
+
+	return change, mapError(err)
+}
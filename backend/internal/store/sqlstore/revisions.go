@@ -0,0 +1,71 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"time"
+	"web-forum/internal/store"
+)
+
+// addPostRevision records the version of a post just written as its latest
+// revision. The caller has updated or inserted the post in tx, and the row
+// lock that took keeps concurrent edits from taking the same number.
+func addPostRevision(ctx context.Context, tx *sql.Tx, postID int, title, content string, editedBy int, editedAt time.Time) error {
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO post_revisions (post_id, number, title, content, edited_by, edited_at)
+		VALUES ($1, (SELECT COALESCE(MAX(number), 0) + 1 FROM post_revisions WHERE post_id = $1), $2, $3, $4, $5)`,
+		postID, title, content, editedBy, editedAt)
+	return mapError(err)
+}
+
+// addCommentRevision is addPostRevision for comments
+func addCommentRevision(ctx context.Context, tx *sql.Tx, commentID int, content string, editedBy int, editedAt time.Time) error {
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO comment_revisions (comment_id, number, content, edited_by, edited_at)
+		VALUES ($1, (SELECT COALESCE(MAX(number), 0) + 1 FROM comment_revisions WHERE comment_id = $1), $2, $3, $4)`,
+		commentID, content, editedBy, editedAt)
+	return mapError(err)
+}
+
+func (s *Store) ListPostRevisions(ctx context.Context, postID int) ([]store.Revision, error) {
+	return s.listRevisions(ctx, `SELECT r.number, r.title, r.content, r.edited_by, COALESCE(u.username, ''), r.edited_at
+		FROM post_revisions r
+		LEFT JOIN users u ON u.id = r.edited_by
+		WHERE r.post_id = $1
+		ORDER BY r.number`, postID)
+}
+
+func (s *Store) ListCommentRevisions(ctx context.Context, commentID int) ([]store.Revision, error) {
+	return s.listRevisions(ctx, `SELECT r.number, '', r.content, r.edited_by, COALESCE(u.username, ''), r.edited_at
+		FROM comment_revisions r
+		LEFT JOIN users u ON u.id = r.edited_by
+		WHERE r.comment_id = $1
+		ORDER BY r.number`, commentID)
+}
+
+// listRevisions runs a revision query for one post or comment. Every post and
+// comment has at least its original revision, so none means it is missing.
+func (s *Store) listRevisions(ctx context.Context, query string, id int) ([]store.Revision, error) {
+	rows, err := s.db.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	revisions := make([]store.Revision, 0)
+	for rows.Next() {
+		var r store.Revision
+		if err := rows.Scan(&r.Number, &r.Title, &r.Content, &r.EditedBy, &r.Editor, &r.EditedAt); err != nil {
+			return nil, mapError(err)
+		}
+		revisions = append(revisions, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, mapError(err)
+	}
+
+	if len(revisions) == 0 {
+		return nil, store.ErrNotFound
+	}
+	return revisions, nil
+}
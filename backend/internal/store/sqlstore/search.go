@@ -0,0 +1,245 @@
+package sqlstore
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"web-forum/internal/search"
+	"web-forum/internal/store"
+)
+
+// Postgres searches the tsvector columns of migration 0003. SQLite has no
+// comparable built-in, so SQLite stores keep an in-process search.Index,
+// loaded from the tables on first use and updated after every write.
+
+// searchParts select the matches of one type. They refer to the tsquery as
+// q.query and produce the columns Search reads from h.
+var searchParts = map[store.SearchType]string{
+	store.SearchTopic: `
+	SELECT 'topic' AS type, t.id, t.id AS topic_id, 0 AS post_id, t.title, t.title AS body,
+		t.created_by, t.created_at, ts_rank(t.search, q.query)::float8 AS rank
+	FROM topics t, q WHERE t.search @@ q.query`,
+	store.SearchPost: `
+	SELECT 'post', p.id, p.topic_id, p.id, p.title, p.content,
+		p.created_by, p.created_at, ts_rank(p.search, q.query)::float8
+	FROM posts p, q WHERE p.search @@ q.query`,
+	store.SearchComment: `
+	SELECT 'comment', c.id, p.topic_id, c.post_id, p.title, c.content,
+		c.created_by, c.created_at, ts_rank(c.search, q.query)::float8
+	FROM comments c JOIN posts p ON p.id = c.post_id, q WHERE c.search @@ q.query`,
+}
+
+// headlineOptions make ts_headline mark matches the way search.Highlight expects
+const headlineOptions = `StartSel=` + search.MatchStart + `, StopSel=` + search.MatchStop + `, MinWords=15, MaxWords=35`
+
+func (s *Store) Search(ctx context.Context, query store.SearchQuery) (store.Page[store.SearchHit], error) {
+	if s.dialect == "sqlite" {
+		return s.searchIndexed(ctx, query)
+	}
+
+	types := query.Types
+	if len(types) == 0 {
+		types = []store.SearchType{store.SearchTopic, store.SearchPost, store.SearchComment}
+	}
+	parts := make([]string, 0, len(types))
+	for _, t := range types {
+		parts = append(parts, searchParts[t])
+	}
+
+	var a args
+	sql := `WITH q AS (SELECT websearch_to_tsquery('english', ` + a.add(query.Text) + `) AS query)
+	SELECT h.type, h.id, h.topic_id, h.post_id, h.title,
+		ts_headline('english', h.body, q.query, ` + a.add(headlineOptions) + `),
+		h.created_by, COALESCE(u.username, ''), h.created_at, h.rank
+	FROM (` + strings.Join(parts, ` UNION ALL `) + `) AS h
+	CROSS JOIN q
+	LEFT JOIN users u ON u.id = h.created_by
+	WHERE TRUE`
+
+	if query.TopicID != 0 {
+		sql += ` AND h.topic_id = ` + a.add(query.TopicID)
+	}
+	if query.Author != "" {
+		sql += ` AND u.username = ` + a.add(query.Author)
+	}
+	if !query.From.IsZero() {
+		sql += ` AND h.created_at >= ` + a.add(query.From)
+	}
+	if !query.To.IsZero() {
+		sql += ` AND h.created_at <= ` + a.add(query.To)
+	}
+	if query.After != nil {
+		sql += ` AND (h.rank, h.type, h.id) < (` + a.add(query.After.Score) + `, ` +
+			a.add(string(query.After.Kind)) + `, ` + a.add(query.After.ID) + `)`
+	}
+	sql += ` ORDER BY h.rank DESC, h.type DESC, h.id DESC LIMIT ` + a.add(query.Limit+1)
+
+	rows, err := s.db.QueryContext(ctx, sql, a...)
+	if err != nil {
+		return store.Page[store.SearchHit]{}, mapError(err)
+	}
+	defer rows.Close()
+
+	hits := make([]listed[store.SearchHit], 0)
+	for rows.Next() {
+		var hit store.SearchHit
+		err := rows.Scan(&hit.Type, &hit.ID, &hit.TopicID, &hit.PostID, &hit.Title, &hit.Snippet,
+			&hit.CreatedBy, &hit.Username, &hit.CreatedAt, &hit.Rank)
+		if err != nil {
+			return store.Page[store.SearchHit]{}, mapError(err)
+		}
+
+		hit.Snippet = search.Highlight(hit.Snippet)
+		hits = append(hits, listed[store.SearchHit]{hit, hit.Cursor(store.SortRelevance)})
+	}
+	if err := rows.Err(); err != nil {
+		return store.Page[store.SearchHit]{}, mapError(err)
+	}
+
+	return pageOf(hits, query.Limit), nil
+}
+
+func (s *Store) searchIndexed(ctx context.Context, query store.SearchQuery) (store.Page[store.SearchHit], error) {
+	index, err := s.searchIndex(ctx)
+	if err != nil {
+		return store.Page[store.SearchHit]{}, err
+	}
+
+	authorID := 0
+	if query.Author != "" {
+		author, err := s.GetUserByUsername(ctx, query.Author)
+		if errors.Is(err, store.ErrNotFound) {
+			return store.Page[store.SearchHit]{Items: []store.SearchHit{}}, nil
+		}
+		if err != nil {
+			return store.Page[store.SearchHit]{}, err
+		}
+		authorID = author.ID
+	}
+
+	page := index.Page(query, authorID)
+	for i, hit := range page.Items {
+		err := s.db.QueryRowContext(ctx, `SELECT COALESCE((SELECT username FROM users WHERE id = $1), '')`,
+			hit.CreatedBy).Scan(&page.Items[i].Username)
+		if err != nil {
+			return store.Page[store.SearchHit]{}, mapError(err)
+		}
+
+		if hit.Type == store.SearchComment {
+			err := s.db.QueryRowContext(ctx, `SELECT title FROM posts WHERE id = $1`,
+				hit.PostID).Scan(&page.Items[i].Title)
+			if err != nil {
+				return store.Page[store.SearchHit]{}, mapError(err)
+			}
+		}
+	}
+	return page, nil
+}
+
+// searchIndex returns the search index of a SQLite store, loading it the
+// first time. A failed load is retried on the next call.
+func (s *Store) searchIndex(ctx context.Context) (*search.Index, error) {
+	s.indexMu.Lock()
+	defer s.indexMu.Unlock()
+
+	if s.index != nil {
+		return s.index, nil
+	}
+
+	index := search.NewIndex()
+	topics, err := s.ListTopics(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, topic := range topics {
+		index.Put(search.TopicDocument(topic))
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT id, topic_id, title, content, created_by, created_at FROM posts`)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	for rows.Next() {
+		var post store.Post
+		if err := rows.Scan(&post.ID, &post.TopicID, &post.Title, &post.Content, &post.CreatedBy, &post.CreatedAt); err != nil {
+			rows.Close()
+			return nil, mapError(err)
+		}
+		index.Put(search.PostDocument(post))
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, mapError(err)
+	}
+
+	rows, err = s.db.QueryContext(ctx, `SELECT c.id, c.post_id, p.topic_id, c.content, c.created_by, c.created_at
+		FROM comments c JOIN posts p ON p.id = c.post_id`)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	for rows.Next() {
+		var comment store.Comment
+		if err := rows.Scan(&comment.ID, &comment.PostID, &comment.TopicID, &comment.Content, &comment.CreatedBy, &comment.CreatedAt); err != nil {
+			rows.Close()
+			return nil, mapError(err)
+		}
+		index.Put(search.CommentDocument(comment))
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, mapError(err)
+	}
+
+	s.index = index
+	return index, nil
+}
+
+// updateIndex applies a successful write to the search index of a SQLite
+// store. The write has already happened, so a failure is logged rather than
+// returned; the index is then reloaded on next use.
+func (s *Store) updateIndex(ctx context.Context, fn func(index *search.Index) error) {
+	if s.dialect != "sqlite" {
+		return
+	}
+
+	index, err := s.searchIndex(ctx)
+	if err == nil {
+		err = fn(index)
+	}
+	if err != nil {
+		log.Printf("Failed to update search index: %v", err)
+		s.dropIndex()
+	}
+}
+
+// dropIndex throws the search index away, to be reloaded on next use
+func (s *Store) dropIndex() {
+	s.indexMu.Lock()
+	s.index = nil
+	s.indexMu.Unlock()
+}
+
+// indexPost reads a post back after a write and puts it into the index
+func (s *Store) indexPost(ctx context.Context, id int) {
+	s.updateIndex(ctx, func(index *search.Index) error {
+		post, err := s.GetPost(ctx, id, 0)
+		if err != nil {
+			return err
+		}
+		index.Put(search.PostDocument(post))
+		return nil
+	})
+}
+
+// indexComment reads a comment back after a write and puts it into the index
+func (s *Store) indexComment(ctx context.Context, id int) {
+	s.updateIndex(ctx, func(index *search.Index) error {
+		comment, err := s.GetComment(ctx, id, 0)
+		if err != nil {
+			return err
+		}
+		index.Put(search.CommentDocument(comment))
+		return nil
+	})
+}
@@ -0,0 +1,149 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+	"web-forum/internal/store"
+)
+
+const sessionColumns = `id, user_id, user_agent, ip_address, created_at, last_used_at, expires_at, revoked_at`
+
+func scanSession(row scanner) (store.Session, error) {
+	var session store.Session
+	var revokedAt sql.NullTime
+	err := row.Scan(&session.ID, &session.UserID, &session.UserAgent, &session.IPAddress,
+		&session.CreatedAt, &session.LastUsedAt, &session.ExpiresAt, &revokedAt)
+	if err != nil {
+		return store.Session{}, mapError(err)
+	}
+
+	if revokedAt.Valid {
+		session.RevokedAt = &revokedAt.Time
+	}
+	return session, nil
+}
+
+func (s *Store) CreateSession(ctx context.Context, userID int, tokenHash, userAgent, ipAddress string, expiresAt time.Time) (store.Session, error) {
+	createdAt := now()
+
+	var session store.Session
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		session, err = scanSession(tx.QueryRowContext(ctx,
+			`INSERT INTO sessions (user_id, user_agent, ip_address, created_at, last_used_at, expires_at)
+			VALUES ($1, $2, $3, $4, $4, $5) RETURNING `+sessionColumns,
+			userID, userAgent, ipAddress, createdAt, expiresAt.UTC()))
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO refresh_tokens (token_hash, session_id, created_at) VALUES ($1, $2, $3)`,
+			tokenHash, session.ID, createdAt)
+		return mapError(err)
+	})
+	return session, err
+}
+
+func (s *Store) GetSession(ctx context.Context, id int) (store.Session, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+sessionColumns+` FROM sessions WHERE id = $1`, id)
+	return scanSession(row)
+}
+
+func (s *Store) GetSessionByToken(ctx context.Context, tokenHash string) (store.Session, error) {
+	row := s.db.QueryRowContext(ctx,
+		`SELECT `+sessionColumns+` FROM sessions
+		WHERE id = (SELECT session_id FROM refresh_tokens WHERE token_hash = $1)`, tokenHash)
+	return scanSession(row)
+}
+
+func (s *Store) RotateRefreshToken(ctx context.Context, oldHash, newHash string) (store.Session, error) {
+	rotatedAt := now()
+
+	var session store.Session
+	reused := false
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		session, err = scanSession(tx.QueryRowContext(ctx,
+			`SELECT `+sessionColumns+` FROM sessions
+			WHERE id = (SELECT session_id FROM refresh_tokens WHERE token_hash = $1)`, oldHash))
+		if err != nil {
+			return err
+		}
+
+		// Claim the token. Matching nothing means it was exchanged already,
+		// possibly by a concurrent request that got there first.
+		err = expectRow(tx.ExecContext(ctx,
+			`UPDATE refresh_tokens SET used_at = $1 WHERE token_hash = $2 AND used_at IS NULL`,
+			rotatedAt, oldHash))
+		if errors.Is(err, store.ErrNotFound) {
+			reused = true
+			_, err = tx.ExecContext(ctx,
+				`UPDATE sessions SET revoked_at = $1 WHERE id = $2 AND revoked_at IS NULL`,
+				rotatedAt, session.ID)
+			return mapError(err)
+		}
+		if err != nil {
+			return err
+		}
+
+		if !session.Active(rotatedAt) {
+			return store.ErrNotFound
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO refresh_tokens (token_hash, session_id, created_at) VALUES ($1, $2, $3)`,
+			newHash, session.ID, rotatedAt)
+		if err != nil {
+			return mapError(err)
+		}
+
+		session.LastUsedAt = rotatedAt
+		_, err = tx.ExecContext(ctx, `UPDATE sessions SET last_used_at = $1 WHERE id = $2`, rotatedAt, session.ID)
+		return mapError(err)
+	})
+	if err != nil {
+		return store.Session{}, err
+	}
+	// The revocation above has to be committed before reporting the reuse
+	if reused {
+		return store.Session{}, store.ErrTokenReused
+	}
+	return session, nil
+}
+
+func (s *Store) ListSessions(ctx context.Context, userID int) ([]store.Session, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+sessionColumns+` FROM sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > $2
+		ORDER BY last_used_at DESC, id DESC`, userID, now())
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	sessions := make([]store.Session, 0)
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, mapError(rows.Err())
+}
+
+func (s *Store) RevokeSession(ctx context.Context, id int) error {
+	return expectRow(s.db.ExecContext(ctx,
+		`UPDATE sessions SET revoked_at = $1 WHERE id = $2 AND revoked_at IS NULL AND expires_at > $1`,
+		now(), id))
+}
+
+func (s *Store) RevokeUserSessions(ctx context.Context, userID, except int) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE sessions SET revoked_at = $1 WHERE user_id = $2 AND id <> $3 AND revoked_at IS NULL`,
+		now(), userID, except)
+	return mapError(err)
+}
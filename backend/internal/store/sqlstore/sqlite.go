@@ -0,0 +1,29 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"net/url"
+
+	_ "modernc.org/sqlite"
+)
+
+// OpenSQLite opens (creating if needed) a single-file SQLite database
+func OpenSQLite(path string) (*Store, error) {
+	params := url.Values{}
+	params.Add("_pragma", "foreign_keys(1)")
+	params.Add("_pragma", "busy_timeout(5000)")
+	params.Add("_pragma", "journal_mode(WAL)")
+	// Store times as text SQLite's date functions understand, e.g. for the hot sort
+	params.Add("_time_format", "sqlite")
+
+	db, err := sql.Open("sqlite", "file:"+path+"?"+params.Encode())
+	if err != nil {
+		return nil, err
+	}
+
+	// SQLite allows a single writer anyway, sharing one connection avoids
+	// "database is locked" errors between concurrent requests
+	db.SetMaxOpenConns(1)
+
+	return &Store{db: db, dialect: "sqlite"}, nil
+}
@@ -0,0 +1,26 @@
+package sqlstore_test
+
+import (
+	"context"
+	"testing"
+	"web-forum/internal/store"
+	"web-forum/internal/store/sqlstore"
+	"web-forum/internal/store/storetest"
+)
+
+// TestSQLite runs the store contract against a private in-memory database for
+// every test, migrated the same way a database file is
+func TestSQLite(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) store.Store {
+		db, err := sqlstore.OpenSQLite(":memory:")
+		if err != nil {
+			t.Fatalf("OpenSQLite: %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+
+		if _, err := db.MigrateUp(context.Background()); err != nil {
+			t.Fatalf("MigrateUp: %v", err)
+		}
+		return db
+	})
+}
@@ -0,0 +1,148 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+	"web-forum/internal/search"
+	"web-forum/internal/store"
+
+	"github.com/lib/pq"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// Store implements store.Store with plain SQL over database/sql. The queries
+// stick to the subset of SQL that PostgreSQL and SQLite both understand.
+type Store struct {
+	db *sql.DB
+	// dialect is "postgres" or "sqlite" and picks the migrations to run
+	dialect string
+
+	// index is the search index of a SQLite store, nil until first used
+	indexMu sync.Mutex
+	index   *search.Index
+}
+
+var _ store.Store = (*Store)(nil)
+
+// OpenPostgres connects to a PostgreSQL database, e.g. a local container or
+// the connection string of a Supabase project
+func OpenPostgres(dsn string) (*Store, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("connect to postgres: %w", err)
+	}
+
+	return &Store{db: db, dialect: "postgres"}, nil
+}
+
+// Dialect returns "postgres" or "sqlite"
+func (s *Store) Dialect() string {
+	return s.dialect
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// mapError translates driver errors into the store sentinel errors
+func mapError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return store.ErrNotFound
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case "23505": // unique_violation
+			return store.ErrConflict
+		case "23503": // foreign_key_violation
+			return store.ErrNotFound
+		}
+	}
+
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		switch sqliteErr.Code() {
+		case sqlite3.SQLITE_CONSTRAINT_UNIQUE, sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY:
+			return store.ErrConflict
+		case sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY:
+			return store.ErrNotFound
+		}
+	}
+
+	return err
+}
+
+// withTx runs fn inside a transaction, rolling back if it returns an error
+func (s *Store) withTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// expectRow turns an UPDATE or DELETE that matched nothing into ErrNotFound
+func expectRow(result sql.Result, err error) error {
+	if err != nil {
+		return mapError(err)
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+// args collects the arguments of a query built up piece by piece. add
+// returns the placeholder to splice into the SQL for the value.
+type args []any
+
+func (a *args) add(v any) string {
+	*a = append(*a, v)
+	return "$" + strconv.Itoa(len(*a))
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func now() time.Time {
+	return time.Now().UTC()
+}
+
+func nullableInt(v sql.NullInt64) *int {
+	if !v.Valid {
+		return nil
+	}
+
+	i := int(v.Int64)
+	return &i
+}
@@ -0,0 +1,78 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"time"
+	"web-forum/internal/store"
+)
+
+const personalTokenColumns = `id, user_id, name, scope, created_at, last_used_at, expires_at, revoked_at`
+
+func scanPersonalToken(row scanner) (store.PersonalToken, error) {
+	var token store.PersonalToken
+	var lastUsedAt, revokedAt sql.NullTime
+	err := row.Scan(&token.ID, &token.UserID, &token.Name, &token.Scope,
+		&token.CreatedAt, &lastUsedAt, &token.ExpiresAt, &revokedAt)
+	if err != nil {
+		return store.PersonalToken{}, mapError(err)
+	}
+
+	if lastUsedAt.Valid {
+		token.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		token.RevokedAt = &revokedAt.Time
+	}
+	return token, nil
+}
+
+func (s *Store) CreatePersonalToken(ctx context.Context, userID int, name string, scope store.TokenScope, tokenHash string, expiresAt time.Time) (store.PersonalToken, error) {
+	row := s.db.QueryRowContext(ctx,
+		`INSERT INTO personal_tokens (user_id, name, scope, token_hash, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING `+personalTokenColumns,
+		userID, name, string(scope), tokenHash, now(), expiresAt.UTC())
+	return scanPersonalToken(row)
+}
+
+func (s *Store) GetPersonalToken(ctx context.Context, id int) (store.PersonalToken, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+personalTokenColumns+` FROM personal_tokens WHERE id = $1`, id)
+	return scanPersonalToken(row)
+}
+
+func (s *Store) GetPersonalTokenByHash(ctx context.Context, tokenHash string) (store.PersonalToken, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+personalTokenColumns+` FROM personal_tokens WHERE token_hash = $1`, tokenHash)
+	return scanPersonalToken(row)
+}
+
+func (s *Store) ListPersonalTokens(ctx context.Context, userID int) ([]store.PersonalToken, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+personalTokenColumns+` FROM personal_tokens
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > $2
+		ORDER BY id DESC`, userID, now())
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	tokens := make([]store.PersonalToken, 0)
+	for rows.Next() {
+		token, err := scanPersonalToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, mapError(rows.Err())
+}
+
+func (s *Store) RevokePersonalToken(ctx context.Context, id int) error {
+	return expectRow(s.db.ExecContext(ctx,
+		`UPDATE personal_tokens SET revoked_at = $1 WHERE id = $2 AND revoked_at IS NULL AND expires_at > $1`,
+		now(), id))
+}
+
+func (s *Store) TouchPersonalToken(ctx context.Context, id int) error {
+	return expectRow(s.db.ExecContext(ctx,
+		`UPDATE personal_tokens SET last_used_at = $1 WHERE id = $2`, now(), id))
+}
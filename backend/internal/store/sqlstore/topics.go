@@ -0,0 +1,203 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"web-forum/internal/search"
+	"web-forum/internal/store"
+)
+
+const topicColumns = `id, title, description, category_id, created_by, created_at, archived_at`
+
+// scanTopic scans topicColumns, then any extra columns into extra
+func scanTopic(row scanner, extra ...any) (store.Topic, error) {
+	var topic store.Topic
+	var categoryID sql.NullInt64
+	var archivedAt sql.NullTime
+	dest := append([]any{&topic.ID, &topic.Title, &topic.Description, &categoryID, &topic.CreatedBy, &topic.CreatedAt, &archivedAt}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return store.Topic{}, mapError(err)
+	}
+
+	topic.CategoryID = nullableInt(categoryID)
+	if archivedAt.Valid {
+		topic.ArchivedAt = &archivedAt.Time
+	}
+	return topic, nil
+}
+
+func (s *Store) ListTopics(ctx context.Context) ([]store.Topic, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+topicColumns+` FROM topics ORDER BY id`)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	topics := make([]store.Topic, 0)
+	for rows.Next() {
+		topic, err := scanTopic(rows)
+		if err != nil {
+			return nil, err
+		}
+		topics = append(topics, topic)
+	}
+	return topics, mapError(rows.Err())
+}
+
+// topicStats counts the posts and comments of every topic and finds the
+// latest of them, in one pass over each table rather than a query per topic
+const topicStats = `post_counts AS (
+	SELECT topic_id, COUNT(*) AS n FROM posts GROUP BY topic_id
+), comment_counts AS (
+	SELECT p.topic_id, COUNT(*) AS n FROM comments c JOIN posts p ON p.id = c.post_id GROUP BY p.topic_id
+), activity AS (
+	SELECT topic_id, id, 0 AS kind, created_by, created_at FROM posts
+	UNION ALL
+	SELECT p.topic_id, c.id, 1, c.created_by, c.created_at FROM comments c JOIN posts p ON p.id = c.post_id
+), latest AS (
+	SELECT topic_id, created_by, created_at,
+		ROW_NUMBER() OVER (PARTITION BY topic_id ORDER BY created_at DESC, kind DESC, id DESC) AS n
+	FROM activity
+)
+SELECT t.id, t.title, t.description, t.category_id, t.created_by, t.created_at, t.archived_at,
+	COALESCE(pc.n, 0), COALESCE(cc.n, 0), l.created_at, l.created_by, u.username
+FROM topics t
+LEFT JOIN post_counts pc ON pc.topic_id = t.id
+LEFT JOIN comment_counts cc ON cc.topic_id = t.id
+LEFT JOIN latest l ON l.topic_id = t.id AND l.n = 1
+LEFT JOIN users u ON u.id = l.created_by`
+
+func (s *Store) ListTopicStats(ctx context.Context, categoryID int, sort store.TopicSort) ([]store.TopicStats, error) {
+	var a args
+	query := `WITH ` + topicStats
+	if categoryID != 0 {
+		query = `WITH RECURSIVE subtree AS (
+			SELECT id FROM categories WHERE id = ` + a.add(categoryID) + `
+			UNION
+			SELECT c.id FROM categories c JOIN subtree ON c.parent_id = subtree.id
+		), ` + topicStats + ` WHERE t.category_id IN (SELECT id FROM subtree)`
+	}
+
+	order := `t.id`
+	switch sort {
+	case store.TopicSortNewest:
+		order = `t.created_at DESC, t.id DESC`
+	case store.TopicSortActivity:
+		order = `COALESCE(l.created_at, t.created_at) DESC, t.id DESC`
+	}
+
+	rows, err := s.db.QueryContext(ctx, query+` ORDER BY `+order, a...)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	topics := make([]store.TopicStats, 0)
+	for rows.Next() {
+		var topic store.TopicStats
+		var lastActivityAt sql.NullTime
+		var lastPosterID sql.NullInt64
+		var lastPoster sql.NullString
+		topic.Topic, err = scanTopic(rows, &topic.PostCount, &topic.CommentCount, &lastActivityAt, &lastPosterID, &lastPoster)
+		if err != nil {
+			return nil, err
+		}
+
+		topic.LastActivityAt = topic.CreatedAt
+		if lastActivityAt.Valid {
+			topic.LastActivityAt = lastActivityAt.Time
+		}
+		topic.LastPosterID = nullableInt(lastPosterID)
+		if lastPoster.Valid {
+			topic.LastPoster = &lastPoster.String
+		}
+		topics = append(topics, topic)
+	}
+	return topics, mapError(rows.Err())
+}
+
+func (s *Store) GetTopic(ctx context.Context, id int) (store.Topic, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+topicColumns+` FROM topics WHERE id = $1`, id)
+	return scanTopic(row)
+}
+
+func (s *Store) CreateTopic(ctx context.Context, title, description string, categoryID *int, createdBy int) (store.Topic, error) {
+	var topic store.Topic
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		if err := checkCategory(ctx, tx, categoryID); err != nil {
+			return err
+		}
+
+		var err error
+		topic, err = scanTopic(tx.QueryRowContext(ctx,
+			`INSERT INTO topics (title, description, category_id, created_by, created_at) VALUES ($1, $2, $3, $4, $5) RETURNING `+topicColumns,
+			title, description, categoryID, createdBy, now()))
+		return err
+	})
+	if err != nil {
+		return store.Topic{}, err
+	}
+
+	s.indexTopic(ctx, topic)
+	return topic, nil
+}
+
+func (s *Store) UpdateTopic(ctx context.Context, id int, update store.TopicUpdate) (store.Topic, error) {
+	var a args
+	sets := []string{`title = ` + a.add(update.Title), `description = ` + a.add(update.Description)}
+	if update.MoveCategory {
+		sets = append(sets, `category_id = `+a.add(update.CategoryID))
+	}
+	if update.Archived != nil {
+		if *update.Archived {
+			sets = append(sets, `archived_at = COALESCE(archived_at, `+a.add(now())+`)`)
+		} else {
+			sets = append(sets, `archived_at = NULL`)
+		}
+	}
+
+	var topic store.Topic
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		if update.MoveCategory {
+			if err := checkCategory(ctx, tx, update.CategoryID); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		topic, err = scanTopic(tx.QueryRowContext(ctx,
+			`UPDATE topics SET `+strings.Join(sets, `, `)+` WHERE id = `+a.add(id)+` RETURNING `+topicColumns, a...))
+		return err
+	})
+	if err != nil {
+		return store.Topic{}, err
+	}
+
+	s.indexTopic(ctx, topic)
+	return topic, nil
+}
+
+func (s *Store) DeleteTopic(ctx context.Context, id int) error {
+	// Posts and moderator assignments go through ON DELETE CASCADE, and the
+	// comments and reactions with the posts
+	if err := expectRow(s.db.ExecContext(ctx, `DELETE FROM topics WHERE id = $1`, id)); err != nil {
+		return err
+	}
+
+	s.updateIndex(ctx, func(index *search.Index) error {
+		index.RemoveFunc(func(doc search.Document) bool {
+			return doc.TopicID == id
+		})
+		return nil
+	})
+	return nil
+}
+
+// indexTopic puts a topic into the search index after a write
+func (s *Store) indexTopic(ctx context.Context, topic store.Topic) {
+	s.updateIndex(ctx, func(index *search.Index) error {
+		index.Put(search.TopicDocument(topic))
+		return nil
+	})
+}
@@ -0,0 +1,117 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"web-forum/internal/store"
+)
+
+func (s *Store) GetTOTP(ctx context.Context, userID int) (store.TOTP, error) {
+	var t store.TOTP
+	var enabledAt sql.NullTime
+	err := s.db.QueryRowContext(ctx,
+		`SELECT user_id, secret, created_at, enabled_at, last_step,
+			(SELECT COUNT(*) FROM recovery_codes r WHERE r.user_id = t.user_id AND r.used_at IS NULL)
+		FROM user_totp t WHERE user_id = $1`, userID).
+		Scan(&t.UserID, &t.Secret, &t.CreatedAt, &enabledAt, &t.LastStep, &t.RecoveryCodesLeft)
+	if err != nil {
+		return store.TOTP{}, mapError(err)
+	}
+
+	if enabledAt.Valid {
+		t.EnabledAt = &enabledAt.Time
+	}
+	return t, nil
+}
+
+func (s *Store) StartTOTP(ctx context.Context, userID int, secret string) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		var enabledAt sql.NullTime
+		err := tx.QueryRowContext(ctx, `SELECT enabled_at FROM user_totp WHERE user_id = $1`, userID).Scan(&enabledAt)
+		if err == nil && enabledAt.Valid {
+			return store.ErrConflict
+		}
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return mapError(err)
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM user_totp WHERE user_id = $1`, userID); err != nil {
+			return mapError(err)
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO user_totp (user_id, secret, created_at) VALUES ($1, $2, $3)`,
+			userID, secret, now())
+		return mapError(err)
+	})
+}
+
+func (s *Store) EnableTOTP(ctx context.Context, userID int, step int64, recoveryHashes []string) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		err := expectRow(tx.ExecContext(ctx,
+			`UPDATE user_totp SET enabled_at = $1, last_step = $2 WHERE user_id = $3 AND enabled_at IS NULL`,
+			now(), step, userID))
+		if err != nil {
+			return err
+		}
+
+		return replaceRecoveryCodes(ctx, tx, userID, recoveryHashes)
+	})
+}
+
+func (s *Store) UseTOTPStep(ctx context.Context, userID int, step int64) error {
+	// Only moving forward means two requests with the same code cannot both win
+	err := expectRow(s.db.ExecContext(ctx,
+		`UPDATE user_totp SET last_step = $1 WHERE user_id = $2 AND last_step < $1`, step, userID))
+	if !errors.Is(err, store.ErrNotFound) {
+		return err
+	}
+
+	if _, err := s.GetTOTP(ctx, userID); err != nil {
+		return err
+	}
+	return store.ErrCodeReused
+}
+
+func (s *Store) UseRecoveryCode(ctx context.Context, userID int, hash string) error {
+	return expectRow(s.db.ExecContext(ctx,
+		`UPDATE recovery_codes SET used_at = $1 WHERE user_id = $2 AND code_hash = $3 AND used_at IS NULL`,
+		now(), userID, hash))
+}
+
+func (s *Store) ReplaceRecoveryCodes(ctx context.Context, userID int, hashes []string) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		var exists int
+		err := tx.QueryRowContext(ctx, `SELECT 1 FROM user_totp WHERE user_id = $1`, userID).Scan(&exists)
+		if err != nil {
+			return mapError(err)
+		}
+
+		return replaceRecoveryCodes(ctx, tx, userID, hashes)
+	})
+}
+
+func (s *Store) DisableTOTP(ctx context.Context, userID int) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM recovery_codes WHERE user_id = $1`, userID); err != nil {
+			return mapError(err)
+		}
+		return expectRow(tx.ExecContext(ctx, `DELETE FROM user_totp WHERE user_id = $1`, userID))
+	})
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx *sql.Tx, userID int, hashes []string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return mapError(err)
+	}
+
+	for _, hash := range hashes {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO recovery_codes (user_id, code_hash) VALUES ($1, $2)`, userID, hash)
+		if err != nil {
+			return mapError(err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"web-forum/internal/store"
+)
+
+const userColumns = `id, username, password, role, display_name, bio, avatar_url,
+	email, email_verified_at, deleted_at, created_at`
+
+func scanUser(row scanner) (store.User, error) {
+	var user store.User
+	var email sql.NullString
+	var emailVerifiedAt, deletedAt sql.NullTime
+	err := row.Scan(&user.ID, &user.Username, &user.Password, &user.Role, &user.DisplayName, &user.Bio, &user.AvatarURL,
+		&email, &emailVerifiedAt, &deletedAt, &user.CreatedAt)
+	if err != nil {
+		return store.User{}, mapError(err)
+	}
+
+	user.Email = email.String
+	if emailVerifiedAt.Valid {
+		user.EmailVerifiedAt = &emailVerifiedAt.Time
+	}
+	if deletedAt.Valid {
+		user.DeletedAt = &deletedAt.Time
+	}
+	return user, nil
+}
+
+func (s *Store) CreateUser(ctx context.Context, username, passwordHash string) (store.User, error) {
+	row := s.db.QueryRowContext(ctx,
+		`INSERT INTO users (username, password, created_at) VALUES ($1, $2, $3) RETURNING `+userColumns,
+		username, passwordHash, now())
+	return scanUser(row)
+}
+
+func (s *Store) GetUserByID(ctx context.Context, id int) (store.User, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE id = $1`, id)
+	return scanUser(row)
+}
+
+func (s *Store) GetUserByUsername(ctx context.Context, username string) (store.User, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE username = $1`, username)
+	return scanUser(row)
+}
+
+func (s *Store) GetUserByEmail(ctx context.Context, email string) (store.User, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE email = $1`, email)
+	return scanUser(row)
+}
+
+func (s *Store) UpdatePassword(ctx context.Context, id int, passwordHash string) error {
+	return expectRow(s.db.ExecContext(ctx, `UPDATE users SET password = $1 WHERE id = $2`, passwordHash, id))
+}
+
+func (s *Store) SetRole(ctx context.Context, id int, role store.Role) error {
+	return expectRow(s.db.ExecContext(ctx, `UPDATE users SET role = $1 WHERE id = $2`, string(role), id))
+}
+
+func (s *Store) SetEmail(ctx context.Context, id int, email string) error {
+	// NULL rather than "" so the unique index only covers real addresses
+	var value sql.NullString
+	if email != "" {
+		value = sql.NullString{String: email, Valid: true}
+	}
+
+	return expectRow(s.db.ExecContext(ctx,
+		`UPDATE users SET email = $1, email_verified_at = NULL WHERE id = $2`, value, id))
+}
+
+func (s *Store) MarkEmailVerified(ctx context.Context, id int, email string) error {
+	return expectRow(s.db.ExecContext(ctx,
+		`UPDATE users SET email_verified_at = $1 WHERE id = $2 AND email = $3`, now(), id, email))
+}
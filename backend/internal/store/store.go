@@ -0,0 +1,289 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrNotFound is returned when the requested row does not exist
+	ErrNotFound = errors.New("store: not found")
+	// ErrConflict is returned when a write would violate a unique constraint
+	ErrConflict = errors.New("store: conflict")
+	// ErrInvalidParent is returned when a reply's parent comment does not
+	// exist or belongs to another post
+	ErrInvalidParent = errors.New("store: invalid parent comment")
+)
+
+// Role decides what a user may do beyond managing their own content
+type Role string
+
+const (
+	RoleMember Role = "member"
+	// RoleModerator may edit and delete anything in the topics they are
+	// assigned to moderate
+	RoleModerator Role = "moderator"
+	// RoleAdmin may edit and delete anything and manage roles and moderators
+	RoleAdmin Role = "admin"
+)
+
+var ErrInvalidRole = errors.New("store: invalid role")
+
+func ParseRole(s string) (Role, error) {
+	switch Role(s) {
+	case RoleMember, RoleModerator, RoleAdmin:
+		return Role(s), nil
+	}
+	return "", ErrInvalidRole
+}
+
+// User is an account. Email is empty if the user has not given one; it and
+// EmailVerifiedAt are private to the user and never serialised with them.
+// DeletedAt is set once the account is deleted, after which only the row is
+// left, scrubbed, for the content and topics that still point at it.
+type User struct {
+	ID              int        `json:"id"`
+	Username        string     `json:"username"`
+	Password        string     `json:"-"`
+	Role            Role       `json:"role"`
+	DisplayName     string     `json:"display_name"`
+	Bio             string     `json:"bio"`
+	AvatarURL       string     `json:"avatar_url"`
+	Email           string     `json:"-"`
+	EmailVerifiedAt *time.Time `json:"-"`
+	DeletedAt       *time.Time `json:"-"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// EmailVerified reports whether the user proved they own their email address
+func (u User) EmailVerified() bool {
+	return u.Email != "" && u.EmailVerifiedAt != nil
+}
+
+// Deleted reports whether the account was deleted
+func (u User) Deleted() bool {
+	return u.DeletedAt != nil
+}
+
+// Topic groups posts. CategoryID is nil for topics outside any category.
+// ArchivedAt is set while the topic is archived, which makes its posts and
+// comments read-only.
+type Topic struct {
+	ID          int        `json:"id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	CategoryID  *int       `json:"category_id"`
+	CreatedBy   int        `json:"created_by"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ArchivedAt  *time.Time `json:"archived_at"`
+}
+
+// Archived reports whether the topic is archived
+func (t Topic) Archived() bool {
+	return t.ArchivedAt != nil
+}
+
+// TopicUpdate is what UpdateTopic changes. MoveCategory says whether to set
+// CategoryID, where nil takes the topic out of any category. Archived, if
+// set, archives or unarchives it; archiving an archived topic keeps the time
+// it was first archived.
+type TopicUpdate struct {
+	Title        string
+	Description  string
+	MoveCategory bool
+	CategoryID   *int
+	Archived     *bool
+}
+
+// TopicStats is a topic with how busy it is. LastActivityAt is when the
+// latest post or comment in it was written, or when the topic was created if
+// it has none; LastPosterID and LastPoster say who wrote it.
+type TopicStats struct {
+	Topic
+	PostCount      int       `json:"post_count"`
+	CommentCount   int       `json:"comment_count"`
+	LastActivityAt time.Time `json:"last_activity_at"`
+	LastPosterID   *int      `json:"last_poster_id"`
+	LastPoster     *string   `json:"last_poster"`
+}
+
+// Post is a post joined with its author's username and the tallied reactions.
+// UserReaction is the reaction of the user the post was fetched for, if any.
+// PinnedAt is set while the post is pinned to the top of its topic, LockedAt
+// while it takes no new comments.
+type Post struct {
+	ID           int        `json:"id"`
+	TopicID      int        `json:"topic_id"`
+	Title        string     `json:"title"`
+	Content      string     `json:"content"`
+	CreatedBy    int        `json:"created_by"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	Username     string     `json:"username"`
+	LikeCount    int        `json:"like_count"`
+	DislikeCount int        `json:"dislike_count"`
+	NetScore     int        `json:"net_score"`
+	UserReaction *int       `json:"user_reaction"`
+	PinnedAt     *time.Time `json:"pinned_at"`
+	LockedAt     *time.Time `json:"locked_at"`
+}
+
+// Pinned reports whether the post is pinned
+func (p Post) Pinned() bool {
+	return p.PinnedAt != nil
+}
+
+// Locked reports whether the post is locked
+func (p Post) Locked() bool {
+	return p.LockedAt != nil
+}
+
+// Comment is a comment joined with its author's username and the tallied reactions.
+// ParentID is the comment it replies to, nil for a top-level comment.
+// TopicID is the topic of the post.
+type Comment struct {
+	ID           int       `json:"id"`
+	PostID       int       `json:"post_id"`
+	TopicID      int       `json:"topic_id"`
+	ParentID     *int      `json:"parent_id"`
+	Content      string    `json:"content"`
+	CreatedBy    int       `json:"created_by"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	Username     string    `json:"username"`
+	LikeCount    int       `json:"like_count"`
+	DislikeCount int       `json:"dislike_count"`
+	NetScore     int       `json:"net_score"`
+	UserReaction *int      `json:"user_reaction"`
+}
+
+// Revision is one version of a post or comment, numbered from 1, the
+// original. EditedBy wrote it at EditedAt; Editor is their username. Title is
+// empty for comments.
+type Revision struct {
+	Number   int       `json:"number"`
+	Title    string    `json:"title,omitempty"`
+	Content  string    `json:"content"`
+	EditedBy int       `json:"edited_by"`
+	Editor   string    `json:"editor"`
+	EditedAt time.Time `json:"edited_at"`
+}
+
+// ReactionChange describes what a toggle did to the user's existing reaction
+type ReactionChange int
+
+const (
+	ReactionCreated ReactionChange = iota
+	ReactionUpdated
+	ReactionDeleted
+)
+
+type UserStore interface {
+	// CreateUser returns ErrConflict if the username is taken
+	CreateUser(ctx context.Context, username, passwordHash string) (User, error)
+	GetUserByID(ctx context.Context, id int) (User, error)
+	GetUserByUsername(ctx context.Context, username string) (User, error)
+	// GetUserByEmail looks a user up by their lowercased email address
+	GetUserByEmail(ctx context.Context, email string) (User, error)
+	UpdatePassword(ctx context.Context, id int, passwordHash string) error
+	SetRole(ctx context.Context, id int, role Role) error
+	// SetEmail changes the user's email address, which is then unverified.
+	// An empty email removes it. It returns ErrConflict if another user has it.
+	SetEmail(ctx context.Context, id int, email string) error
+	// MarkEmailVerified verifies the user's email address if it is still
+	// email, and returns ErrNotFound if it has changed
+	MarkEmailVerified(ctx context.Context, id int, email string) error
+}
+
+type TopicStore interface {
+	ListTopics(ctx context.Context) ([]Topic, error)
+	// ListTopicStats lists the topics with their statistics, computed for all
+	// of them at once. If categoryID is not 0, only the topics in that
+	// category and its subcategories are listed.
+	ListTopicStats(ctx context.Context, categoryID int, sort TopicSort) ([]TopicStats, error)
+	GetTopic(ctx context.Context, id int) (Topic, error)
+	// CreateTopic returns ErrConflict if a topic with the same title exists
+	// and ErrNotFound if the category does not
+	CreateTopic(ctx context.Context, title, description string, categoryID *int, createdBy int) (Topic, error)
+	// UpdateTopic returns ErrConflict if another topic has the title and
+	// ErrNotFound if the topic or the category does not exist
+	UpdateTopic(ctx context.Context, id int, update TopicUpdate) (Topic, error)
+	// DeleteTopic deletes the topic with its posts, their comments and
+	// reactions, and its moderator assignments
+	DeleteTopic(ctx context.Context, id int) error
+}
+
+// ModeratorStore assigns moderators to topics
+type ModeratorStore interface {
+	// ModeratedTopics returns the ids of the topics the user is assigned to
+	ModeratedTopics(ctx context.Context, userID int) ([]int, error)
+	ListModerators(ctx context.Context, topicID int) ([]User, error)
+	// AddModerator returns ErrNotFound if the topic or user does not exist.
+	// Adding an existing moderator again is not an error.
+	AddModerator(ctx context.Context, topicID, userID int) error
+	// RemoveModerator returns ErrNotFound if the user does not moderate the topic
+	RemoveModerator(ctx context.Context, topicID, userID int) error
+}
+
+// PostStore reads and writes posts. viewerID is used to fill in UserReaction.
+type PostStore interface {
+	ListPosts(ctx context.Context, topicID, viewerID int, opts ListOptions) (Page[Post], error)
+	GetPost(ctx context.Context, id, viewerID int) (Post, error)
+	CreatePost(ctx context.Context, topicID int, title, content string, createdBy int) (Post, error)
+	// UpdatePost keeps what the post said before as an earlier revision
+	UpdatePost(ctx context.Context, id int, title, content string, editedBy int) error
+	DeletePost(ctx context.Context, id int) error
+	// SetPostPinned and SetPostLocked keep the time a post was first pinned or
+	// locked until it is unpinned or unlocked
+	SetPostPinned(ctx context.Context, id int, pinned bool) error
+	SetPostLocked(ctx context.Context, id int, locked bool) error
+	// ListPostRevisions returns every version of the post, oldest first, the
+	// last being the current one. It returns ErrNotFound for a missing post.
+	ListPostRevisions(ctx context.Context, postID int) ([]Revision, error)
+}
+
+// CommentStore reads and writes comments. viewerID is used to fill in UserReaction.
+type CommentStore interface {
+	ListComments(ctx context.Context, postID, viewerID int, opts ListOptions) (Page[Comment], error)
+	// ListThread pages through the top-level comments of a post in opts.Sort
+	// order, each followed by its replies as laid out by Thread
+	ListThread(ctx context.Context, postID, viewerID int, opts ListOptions) (Page[ThreadComment], error)
+	GetComment(ctx context.Context, id, viewerID int) (Comment, error)
+	// CreateComment returns ErrInvalidParent if parentID is set and is not a
+	// comment on the same post
+	CreateComment(ctx context.Context, postID int, parentID *int, content string, createdBy int) (Comment, error)
+	// UpdateComment keeps what the comment said before as an earlier revision
+	UpdateComment(ctx context.Context, id int, content string, editedBy int) error
+	// DeleteComment deletes the comment and moves the replies to it up to its
+	// parent, so they outlive it
+	DeleteComment(ctx context.Context, id int) error
+	// ListCommentRevisions is ListPostRevisions for comments
+	ListCommentRevisions(ctx context.Context, commentID int) ([]Revision, error)
+}
+
+// ReactionStore toggles likes (1) and dislikes (-1). Reacting with the same
+// value twice removes the reaction, reacting with the opposite value flips it.
+type ReactionStore interface {
+	TogglePostReaction(ctx context.Context, postID, userID, reaction int) (ReactionChange, error)
+	ToggleCommentReaction(ctx context.Context, commentID, userID, reaction int) (ReactionChange, error)
+}
+
+// Store is everything the handlers need from a backend
+type Store interface {
+	UserStore
+	SessionStore
+	PersonalTokenStore
+	TwoFactorStore
+	EmailTokenStore
+	IdentityStore
+	ProfileStore
+	CategoryStore
+	TopicStore
+	ModeratorStore
+	PostStore
+	CommentStore
+	ReactionStore
+	SearchStore
+	AuditStore
+}
@@ -0,0 +1,689 @@
+// Package storetest checks that a store.Store keeps the promises the
+// handlers rely on, so every backend can be held to the same tests.
+package storetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"web-forum/internal/store"
+)
+
+// Run runs the contract tests against the stores newStore opens, a fresh and
+// empty one for every test
+func Run(t *testing.T, newStore func(t *testing.T) store.Store) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, s store.Store)
+	}{
+		{"UsernameConflict", testUsernameConflict},
+		{"EmailConflict", testEmailConflict},
+		{"PostReactionToggle", testPostReactionToggle},
+		{"CommentReactionToggle", testCommentReactionToggle},
+		{"PostPagesWithEqualKeys", testPostPagesWithEqualKeys},
+		{"CommentPagesWithEqualKeys", testCommentPagesWithEqualKeys},
+		{"Thread", testThread},
+		{"ThreadPages", testThreadPages},
+		{"ReplyToOtherPost", testReplyToOtherPost},
+		{"DeleteCommentKeepsReplies", testDeleteCommentKeepsReplies},
+		{"UpdateTopic", testUpdateTopic},
+		{"SearchDeletedContent", testSearchDeletedContent},
+		{"DeleteUserKeepsReplies", testDeleteUserKeepsReplies},
+		{"TOTPStepReuse", testTOTPStepReuse},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newStore(t))
+		})
+	}
+}
+
+func testUsernameConflict(t *testing.T, s store.Store) {
+	ctx := context.Background()
+
+	alice := createUser(t, s, "alice")
+	if _, err := s.CreateUser(ctx, "alice", "other-hash"); !errors.Is(err, store.ErrConflict) {
+		t.Fatalf("CreateUser with a taken username: got %v, want ErrConflict", err)
+	}
+
+	// The failed attempt must not have touched the existing user
+	got, err := s.GetUserByUsername(ctx, "alice")
+	if err != nil {
+		t.Fatalf("GetUserByUsername: %v", err)
+	}
+	if got.ID != alice.ID || got.Password != "hash" {
+		t.Errorf("GetUserByUsername = %+v, want the original alice", got)
+	}
+}
+
+func testEmailConflict(t *testing.T, s store.Store) {
+	ctx := context.Background()
+
+	alice := createUser(t, s, "alice")
+	bob := createUser(t, s, "bob")
+
+	if err := s.SetEmail(ctx, alice.ID, "shared@example.com"); err != nil {
+		t.Fatalf("SetEmail: %v", err)
+	}
+	if err := s.SetEmail(ctx, bob.ID, "shared@example.com"); !errors.Is(err, store.ErrConflict) {
+		t.Fatalf("SetEmail with a taken email: got %v, want ErrConflict", err)
+	}
+
+	got, err := s.GetUserByEmail(ctx, "shared@example.com")
+	if err != nil {
+		t.Fatalf("GetUserByEmail: %v", err)
+	}
+	if got.ID != alice.ID {
+		t.Errorf("GetUserByEmail found user %d, want %d", got.ID, alice.ID)
+	}
+
+	// Giving up an address frees it for someone else
+	if err := s.SetEmail(ctx, alice.ID, ""); err != nil {
+		t.Fatalf("SetEmail to remove the email: %v", err)
+	}
+	if err := s.SetEmail(ctx, bob.ID, "shared@example.com"); err != nil {
+		t.Errorf("SetEmail after the email was freed: %v", err)
+	}
+}
+
+func testPostReactionToggle(t *testing.T, s store.Store) {
+	ctx := context.Background()
+
+	alice := createUser(t, s, "alice")
+	post := createPost(t, s, createTopic(t, s, alice).ID, alice, "post")
+
+	steps := []struct {
+		reaction        int
+		change          store.ReactionChange
+		likes, dislikes int
+		userReaction    *int
+		description     string
+	}{
+		{1, store.ReactionCreated, 1, 0, ptr(1), "like"},
+		{1, store.ReactionDeleted, 0, 0, nil, "like again"},
+		{-1, store.ReactionCreated, 0, 1, ptr(-1), "dislike"},
+		{1, store.ReactionUpdated, 1, 0, ptr(1), "flip to like"},
+	}
+	for _, step := range steps {
+		change, err := s.TogglePostReaction(ctx, post.ID, alice.ID, step.reaction)
+		if err != nil {
+			t.Fatalf("%s: %v", step.description, err)
+		}
+		if change != step.change {
+			t.Errorf("%s: change = %v, want %v", step.description, change, step.change)
+		}
+
+		got, err := s.GetPost(ctx, post.ID, alice.ID)
+		if err != nil {
+			t.Fatalf("%s: GetPost: %v", step.description, err)
+		}
+		checkTally(t, step.description, got.LikeCount, got.DislikeCount, got.NetScore, got.UserReaction, step.likes, step.dislikes, step.userReaction)
+	}
+}
+
+func testCommentReactionToggle(t *testing.T, s store.Store) {
+	ctx := context.Background()
+
+	alice := createUser(t, s, "alice")
+	bob := createUser(t, s, "bob")
+	post := createPost(t, s, createTopic(t, s, alice).ID, alice, "post")
+	comment := createComment(t, s, post.ID, nil, alice, "comment")
+
+	if _, err := s.ToggleCommentReaction(ctx, comment.ID, alice.ID, 1); err != nil {
+		t.Fatalf("ToggleCommentReaction: %v", err)
+	}
+	if _, err := s.ToggleCommentReaction(ctx, comment.ID, bob.ID, -1); err != nil {
+		t.Fatalf("ToggleCommentReaction: %v", err)
+	}
+
+	// Each viewer sees their own reaction in the same tally
+	for _, viewer := range []struct {
+		user     store.User
+		reaction *int
+	}{{alice, ptr(1)}, {bob, ptr(-1)}} {
+		got, err := s.GetComment(ctx, comment.ID, viewer.user.ID)
+		if err != nil {
+			t.Fatalf("GetComment: %v", err)
+		}
+		checkTally(t, "viewed by "+viewer.user.Username, got.LikeCount, got.DislikeCount, got.NetScore, got.UserReaction, 1, 1, viewer.reaction)
+	}
+
+	change, err := s.ToggleCommentReaction(ctx, comment.ID, bob.ID, -1)
+	if err != nil {
+		t.Fatalf("ToggleCommentReaction: %v", err)
+	}
+	if change != store.ReactionDeleted {
+		t.Errorf("disliking twice: change = %v, want ReactionDeleted", change)
+	}
+}
+
+// testPostPagesWithEqualKeys pages through posts whose sort keys tie, which
+// only the id can order
+func testPostPagesWithEqualKeys(t *testing.T, s store.Store) {
+	ctx := context.Background()
+
+	alice := createUser(t, s, "alice")
+	topic := createTopic(t, s, alice)
+	var ids []int
+	for _, title := range []string{"a", "b", "c", "d", "e", "f", "g"} {
+		ids = append(ids, createPost(t, s, topic.ID, alice, title).ID)
+	}
+	// Two posts with one like each; the other five tie at none
+	for _, i := range []int{1, 4} {
+		if _, err := s.TogglePostReaction(ctx, ids[i], alice.ID, 1); err != nil {
+			t.Fatalf("TogglePostReaction: %v", err)
+		}
+	}
+
+	for _, sort := range []store.Sort{store.SortNewest, store.SortOldest, store.SortLikes, store.SortPopular, store.SortHot} {
+		got := pageThrough(t, sort, func(opts store.ListOptions) (store.Page[store.Post], error) {
+			return s.ListPosts(ctx, topic.ID, alice.ID, opts)
+		})
+		checkPages(t, sort, got, ids)
+	}
+
+	// Within equal scores the newer post, with the higher id, comes first
+	likes := pageThrough(t, store.SortLikes, func(opts store.ListOptions) (store.Page[store.Post], error) {
+		return s.ListPosts(ctx, topic.ID, alice.ID, opts)
+	})
+	want := []int{ids[4], ids[1], ids[6], ids[5], ids[3], ids[2], ids[0]}
+	if gotIDs := idsOf(likes); !slices.Equal(gotIDs, want) {
+		t.Errorf("likes order = %v, want %v", gotIDs, want)
+	}
+}
+
+func testCommentPagesWithEqualKeys(t *testing.T, s store.Store) {
+	ctx := context.Background()
+
+	alice := createUser(t, s, "alice")
+	post := createPost(t, s, createTopic(t, s, alice).ID, alice, "post")
+	var ids []int
+	for _, content := range []string{"a", "b", "c", "d", "e"} {
+		ids = append(ids, createComment(t, s, post.ID, nil, alice, content).ID)
+	}
+
+	for _, sort := range []store.Sort{store.SortNewest, store.SortOldest, store.SortLikes, store.SortPopular, store.SortHot} {
+		got := pageThrough(t, sort, func(opts store.ListOptions) (store.Page[store.Comment], error) {
+			return s.ListComments(ctx, post.ID, alice.ID, opts)
+		})
+		checkPages(t, sort, got, ids)
+	}
+}
+
+func testThread(t *testing.T, s store.Store) {
+	ctx := context.Background()
+
+	alice := createUser(t, s, "alice")
+	post := createPost(t, s, createTopic(t, s, alice).ID, alice, "post")
+
+	first := createComment(t, s, post.ID, nil, alice, "first")
+	second := createComment(t, s, post.ID, nil, alice, "second")
+	reply := createComment(t, s, post.ID, &first.ID, alice, "reply to first")
+	createComment(t, s, post.ID, &reply.ID, alice, "reply to reply")
+	createComment(t, s, post.ID, &second.ID, alice, "reply to second")
+	createComment(t, s, post.ID, &first.ID, alice, "second reply to first")
+
+	page, err := s.ListThread(ctx, post.ID, alice.ID, store.ListOptions{Sort: store.SortOldest, Limit: 10})
+	if err != nil {
+		t.Fatalf("ListThread: %v", err)
+	}
+
+	want := []struct {
+		content    string
+		depth      int
+		replyCount int
+	}{
+		{"first", 0, 3},
+		{"reply to first", 1, 1},
+		{"reply to reply", 2, 0},
+		{"second reply to first", 1, 0},
+		{"second", 0, 1},
+		{"reply to second", 1, 0},
+	}
+	if len(page.Items) != len(want) {
+		t.Fatalf("ListThread returned %d comments, want %d", len(page.Items), len(want))
+	}
+	for i, w := range want {
+		got := page.Items[i]
+		if got.Content != w.content || got.Depth != w.depth || got.ReplyCount != w.replyCount {
+			t.Errorf("comment %d = %q at depth %d with %d replies, want %q at depth %d with %d replies",
+				i, got.Content, got.Depth, got.ReplyCount, w.content, w.depth, w.replyCount)
+		}
+	}
+	if page.Next != nil {
+		t.Errorf("ListThread returned a next cursor on the only page")
+	}
+}
+
+// testThreadPages checks that the limit counts top-level comments, each
+// page carrying whole branches
+func testThreadPages(t *testing.T, s store.Store) {
+	ctx := context.Background()
+
+	alice := createUser(t, s, "alice")
+	post := createPost(t, s, createTopic(t, s, alice).ID, alice, "post")
+
+	var roots []int
+	for _, content := range []string{"a", "b", "c"} {
+		root := createComment(t, s, post.ID, nil, alice, content)
+		createComment(t, s, post.ID, &root.ID, alice, "reply to "+content)
+		roots = append(roots, root.ID)
+	}
+
+	var got []string
+	opts := store.ListOptions{Sort: store.SortNewest, Limit: 2}
+	for pages := 0; ; pages++ {
+		if pages > len(roots) {
+			t.Fatalf("ListThread did not run out of pages")
+		}
+		page, err := s.ListThread(ctx, post.ID, alice.ID, opts)
+		if err != nil {
+			t.Fatalf("ListThread: %v", err)
+		}
+		for _, comment := range page.Items {
+			got = append(got, comment.Content)
+		}
+		if page.Next == nil {
+			break
+		}
+		opts.After = page.Next
+	}
+
+	want := []string{"c", "reply to c", "b", "reply to b", "a", "reply to a"}
+	if !slices.Equal(got, want) {
+		t.Errorf("thread pages = %q, want %q", got, want)
+	}
+}
+
+func testReplyToOtherPost(t *testing.T, s store.Store) {
+	ctx := context.Background()
+
+	alice := createUser(t, s, "alice")
+	topic := createTopic(t, s, alice)
+	post := createPost(t, s, topic.ID, alice, "post")
+	other := createPost(t, s, topic.ID, alice, "other")
+	comment := createComment(t, s, other.ID, nil, alice, "on the other post")
+
+	if _, err := s.CreateComment(ctx, post.ID, &comment.ID, "reply", alice.ID); !errors.Is(err, store.ErrInvalidParent) {
+		t.Errorf("reply to a comment on another post: got %v, want ErrInvalidParent", err)
+	}
+	missing := comment.ID + 100
+	if _, err := s.CreateComment(ctx, post.ID, &missing, "reply", alice.ID); !errors.Is(err, store.ErrInvalidParent) {
+		t.Errorf("reply to a missing comment: got %v, want ErrInvalidParent", err)
+	}
+}
+
+// testUpdateTopic checks that an update only moves or archives the topic
+// when asked to
+func testUpdateTopic(t *testing.T, s store.Store) {
+	ctx := context.Background()
+
+	alice := createUser(t, s, "alice")
+	category, err := s.CreateCategory(ctx, "category", "", nil, 0)
+	if err != nil {
+		t.Fatalf("CreateCategory: %v", err)
+	}
+	topic, err := s.CreateTopic(ctx, "topic", "", &category.ID, alice.ID)
+	if err != nil {
+		t.Fatalf("CreateTopic: %v", err)
+	}
+
+	archived := true
+	topic, err = s.UpdateTopic(ctx, topic.ID, store.TopicUpdate{Title: "renamed", Archived: &archived})
+	if err != nil {
+		t.Fatalf("UpdateTopic: %v", err)
+	}
+	if topic.Title != "renamed" || topic.CategoryID == nil || *topic.CategoryID != category.ID || !topic.Archived() {
+		t.Errorf("topic after renaming and archiving = %+v, want it renamed, archived and in its category", topic)
+	}
+	archivedAt := *topic.ArchivedAt
+
+	topic, err = s.UpdateTopic(ctx, topic.ID, store.TopicUpdate{Title: "renamed", MoveCategory: true, Archived: &archived})
+	if err != nil {
+		t.Fatalf("UpdateTopic: %v", err)
+	}
+	if topic.CategoryID != nil || !topic.ArchivedAt.Equal(archivedAt) {
+		t.Errorf("topic after moving it out = %+v, want no category and archived at %s", topic, archivedAt)
+	}
+
+	missing := category.ID + 100
+	_, err = s.UpdateTopic(ctx, topic.ID, store.TopicUpdate{Title: "renamed", MoveCategory: true, CategoryID: &missing})
+	if !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("moving into a missing category: got %v, want ErrNotFound", err)
+	}
+
+	archived = false
+	topic, err = s.UpdateTopic(ctx, topic.ID, store.TopicUpdate{Title: "renamed", Archived: &archived})
+	if err != nil {
+		t.Fatalf("UpdateTopic: %v", err)
+	}
+	if topic.Archived() {
+		t.Errorf("topic after unarchiving = %+v, want it unarchived", topic)
+	}
+
+	got, err := s.GetTopic(ctx, topic.ID)
+	if err != nil || got.Title != "renamed" || got.CategoryID != nil || got.Archived() {
+		t.Errorf("GetTopic = %+v, %v, want what UpdateTopic returned", got, err)
+	}
+}
+
+// searchFor returns the type and id of every hit for text, and the usernames
+// they were credited to
+func searchFor(t *testing.T, s store.Store, query store.SearchQuery) ([]string, []string) {
+	t.Helper()
+
+	query.Limit = 100
+	page, err := s.Search(context.Background(), query)
+	if err != nil {
+		t.Fatalf("Search(%q): %v", query.Text, err)
+	}
+	hits := make([]string, len(page.Items))
+	usernames := make([]string, len(page.Items))
+	for i, hit := range page.Items {
+		hits[i] = fmt.Sprintf("%s %d", hit.Type, hit.ID)
+		usernames[i] = hit.Username
+	}
+	slices.Sort(hits)
+	return hits, usernames
+}
+
+// testSearchDeletedContent checks that search keeps up with deletions and
+// still finds what archiving and anonymising keep readable
+func testSearchDeletedContent(t *testing.T, s store.Store) {
+	ctx := context.Background()
+
+	alice := createUser(t, s, "alice")
+	bob := createUser(t, s, "bob")
+	carol := createUser(t, s, "carol")
+	topic := createTopic(t, s, bob)
+	walrus := createPost(t, s, topic.ID, alice, "walrus")
+	bobReply := createComment(t, s, walrus.ID, nil, bob, "a walrus reply")
+	narwhal := createPost(t, s, topic.ID, bob, "narwhal")
+	aliceReply := createComment(t, s, narwhal.ID, nil, alice, "walrus and narwhal")
+	carolReply := createComment(t, s, narwhal.ID, nil, carol, "octopus")
+
+	check := func(name string, query store.SearchQuery, want ...string) {
+		t.Helper()
+
+		got, _ := searchFor(t, s, query)
+		slices.Sort(want)
+		if !slices.Equal(got, want) {
+			t.Errorf("%s: Search(%q) = %q, want %q", name, query.Text, got, want)
+		}
+	}
+	post := func(p store.Post) string { return fmt.Sprintf("%s %d", store.SearchPost, p.ID) }
+	comment := func(c store.Comment) string { return fmt.Sprintf("%s %d", store.SearchComment, c.ID) }
+
+	check("before any deletion", store.SearchQuery{Text: "walrus"}, post(walrus), comment(bobReply), comment(aliceReply))
+
+	archived := true
+	if _, err := s.UpdateTopic(ctx, topic.ID, store.TopicUpdate{Title: topic.Title, Archived: &archived}); err != nil {
+		t.Fatalf("UpdateTopic: %v", err)
+	}
+	check("in an archived topic", store.SearchQuery{Text: "walrus"}, post(walrus), comment(bobReply), comment(aliceReply))
+
+	if err := s.DeleteComment(ctx, bobReply.ID); err != nil {
+		t.Fatalf("DeleteComment: %v", err)
+	}
+	check("after deleting a comment", store.SearchQuery{Text: "walrus"}, post(walrus), comment(aliceReply))
+
+	if err := s.DeleteUser(ctx, alice.ID, store.DeleteContent); err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+	check("after deleting its author with the content", store.SearchQuery{Text: "walrus"})
+	check("other users' content", store.SearchQuery{Text: "narwhal"}, post(narwhal))
+
+	if err := s.DeleteUser(ctx, carol.ID, store.DeleteAnonymise); err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+	deleted := store.DeletedUsername(carol.ID)
+	check("after anonymising its author", store.SearchQuery{Text: "octopus", Author: deleted}, comment(carolReply))
+	check("under the old name", store.SearchQuery{Text: "octopus", Author: "carol"})
+	if _, usernames := searchFor(t, s, store.SearchQuery{Text: "octopus"}); !slices.Equal(usernames, []string{deleted}) {
+		t.Errorf("anonymised hit credited to %q, want %q", usernames, deleted)
+	}
+
+	if err := s.DeletePost(ctx, narwhal.ID); err != nil {
+		t.Fatalf("DeletePost: %v", err)
+	}
+	check("after deleting the post", store.SearchQuery{Text: "narwhal"})
+	check("comments of the deleted post", store.SearchQuery{Text: "octopus"})
+}
+
+// threadOf returns the whole thread of a post as content and depth, one
+// "depth content" string per comment
+func threadOf(t *testing.T, s store.Store, postID, viewerID int) []string {
+	t.Helper()
+
+	page, err := s.ListThread(context.Background(), postID, viewerID, store.ListOptions{Sort: store.SortOldest, Limit: 100})
+	if err != nil {
+		t.Fatalf("ListThread: %v", err)
+	}
+	thread := make([]string, len(page.Items))
+	for i, comment := range page.Items {
+		thread[i] = fmt.Sprintf("%d %s", comment.Depth, comment.Content)
+	}
+	return thread
+}
+
+// testDeleteCommentKeepsReplies checks that replies move up to the parent
+// of a deleted comment, or to the top level
+func testDeleteCommentKeepsReplies(t *testing.T, s store.Store) {
+	ctx := context.Background()
+
+	alice := createUser(t, s, "alice")
+	bob := createUser(t, s, "bob")
+	post := createPost(t, s, createTopic(t, s, alice).ID, alice, "post")
+
+	root := createComment(t, s, post.ID, nil, alice, "root")
+	middle := createComment(t, s, post.ID, &root.ID, alice, "middle")
+	createComment(t, s, post.ID, &middle.ID, bob, "reply to middle")
+	createComment(t, s, post.ID, &middle.ID, bob, "second reply to middle")
+
+	if err := s.DeleteComment(ctx, middle.ID); err != nil {
+		t.Fatalf("DeleteComment: %v", err)
+	}
+	want := []string{"0 root", "1 reply to middle", "1 second reply to middle"}
+	if got := threadOf(t, s, post.ID, alice.ID); !slices.Equal(got, want) {
+		t.Errorf("thread after deleting a reply = %q, want %q", got, want)
+	}
+
+	if err := s.DeleteComment(ctx, root.ID); err != nil {
+		t.Fatalf("DeleteComment: %v", err)
+	}
+	want = []string{"0 reply to middle", "0 second reply to middle"}
+	if got := threadOf(t, s, post.ID, alice.ID); !slices.Equal(got, want) {
+		t.Errorf("thread after deleting the root = %q, want %q", got, want)
+	}
+
+	if err := s.DeleteComment(ctx, root.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("deleting a deleted comment: got %v, want ErrNotFound", err)
+	}
+}
+
+// testDeleteUserKeepsReplies checks that deleting an account with its content
+// keeps other users' replies to it, even below a chain of its comments
+func testDeleteUserKeepsReplies(t *testing.T, s store.Store) {
+	ctx := context.Background()
+
+	alice := createUser(t, s, "alice")
+	bob := createUser(t, s, "bob")
+	post := createPost(t, s, createTopic(t, s, bob).ID, bob, "post")
+
+	first := createComment(t, s, post.ID, nil, alice, "alice")
+	second := createComment(t, s, post.ID, &first.ID, alice, "alice again")
+	reply := createComment(t, s, post.ID, &second.ID, bob, "bob to alice")
+	createComment(t, s, post.ID, &reply.ID, alice, "alice to bob")
+	other := createComment(t, s, post.ID, nil, bob, "bob")
+	createComment(t, s, post.ID, &other.ID, alice, "alice to bob again")
+
+	if err := s.DeleteUser(ctx, alice.ID, store.DeleteContent); err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+	want := []string{"0 bob to alice", "0 bob"}
+	if got := threadOf(t, s, post.ID, bob.ID); !slices.Equal(got, want) {
+		t.Errorf("thread after deleting alice = %q, want %q", got, want)
+	}
+}
+
+// testTOTPStepReuse checks that a code's step is only accepted once, and
+// never after a later one
+func testTOTPStepReuse(t *testing.T, s store.Store) {
+	ctx := context.Background()
+
+	alice := createUser(t, s, "alice")
+	if err := s.StartTOTP(ctx, alice.ID, "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"); err != nil {
+		t.Fatalf("StartTOTP: %v", err)
+	}
+	// Confirming spends the step of the code it was confirmed with
+	if err := s.EnableTOTP(ctx, alice.ID, 100, nil); err != nil {
+		t.Fatalf("EnableTOTP: %v", err)
+	}
+
+	steps := []struct {
+		step int64
+		want error
+	}{
+		{100, store.ErrCodeReused},
+		{99, store.ErrCodeReused},
+		{101, nil},
+		{101, store.ErrCodeReused},
+		{103, nil},
+		{102, store.ErrCodeReused},
+	}
+	for _, step := range steps {
+		if err := s.UseTOTPStep(ctx, alice.ID, step.step); !errors.Is(err, step.want) {
+			t.Errorf("UseTOTPStep(%d) = %v, want %v", step.step, err, step.want)
+		}
+	}
+
+	got, err := s.GetTOTP(ctx, alice.ID)
+	if err != nil {
+		t.Fatalf("GetTOTP: %v", err)
+	}
+	if got.LastStep != 103 {
+		t.Errorf("LastStep = %d, want 103", got.LastStep)
+	}
+}
+
+// pageThrough lists everything two rows at a time, following the cursors
+func pageThrough[T any](t *testing.T, sort store.Sort, list func(store.ListOptions) (store.Page[T], error)) []T {
+	t.Helper()
+
+	var items []T
+	opts := store.ListOptions{Sort: sort, Limit: 2}
+	for pages := 0; ; pages++ {
+		if pages > 100 {
+			t.Fatalf("%s: listing did not run out of pages", sort)
+		}
+		page, err := list(opts)
+		if err != nil {
+			t.Fatalf("%s: %v", sort, err)
+		}
+		if len(page.Items) > opts.Limit {
+			t.Fatalf("%s: page of %d rows, limit %d", sort, len(page.Items), opts.Limit)
+		}
+		items = append(items, page.Items...)
+		if page.Next == nil {
+			return items
+		}
+
+		// The cursor has to survive the trip through the client
+		next, err := store.DecodeCursor(page.Next.Encode())
+		if err != nil {
+			t.Fatalf("%s: DecodeCursor: %v", sort, err)
+		}
+		opts.After = &next
+	}
+}
+
+// checkPages checks that paging listed every row in want exactly once, in
+// the order sort prescribes
+func checkPages[T interface{ Cursor(store.Sort) store.Cursor }](t *testing.T, sort store.Sort, got []T, want []int) {
+	t.Helper()
+
+	gotIDs := idsOf(got)
+	if sorted := slices.Sorted(slices.Values(gotIDs)); !slices.Equal(sorted, want) {
+		t.Errorf("%s: pages listed %v, want each of %v once", sort, gotIDs, want)
+		return
+	}
+	for i := 1; i < len(got); i++ {
+		if !sort.Before(got[i-1].Cursor(sort), got[i].Cursor(sort)) {
+			t.Errorf("%s: %d listed before %d out of order", sort, gotIDs[i-1], gotIDs[i])
+		}
+	}
+}
+
+func idsOf[T interface{ Cursor(store.Sort) store.Cursor }](items []T) []int {
+	ids := make([]int, len(items))
+	for i, item := range items {
+		ids[i] = item.Cursor(store.SortNewest).ID
+	}
+	return ids
+}
+
+func checkTally(t *testing.T, description string, likes, dislikes, netScore int, userReaction *int, wantLikes, wantDislikes int, wantReaction *int) {
+	t.Helper()
+
+	if likes != wantLikes || dislikes != wantDislikes || netScore != wantLikes-wantDislikes {
+		t.Errorf("%s: %d likes, %d dislikes, net %d, want %d, %d, %d",
+			description, likes, dislikes, netScore, wantLikes, wantDislikes, wantLikes-wantDislikes)
+	}
+	if (userReaction == nil) != (wantReaction == nil) || userReaction != nil && *userReaction != *wantReaction {
+		t.Errorf("%s: user reaction %v, want %v", description, deref(userReaction), deref(wantReaction))
+	}
+}
+
+func createUser(t *testing.T, s store.Store, username string) store.User {
+	t.Helper()
+
+	user, err := s.CreateUser(context.Background(), username, "hash")
+	if err != nil {
+		t.Fatalf("CreateUser(%q): %v", username, err)
+	}
+	return user
+}
+
+func createTopic(t *testing.T, s store.Store, by store.User) store.Topic {
+	t.Helper()
+
+	topic, err := s.CreateTopic(context.Background(), "topic", "", nil, by.ID)
+	if err != nil {
+		t.Fatalf("CreateTopic: %v", err)
+	}
+	return topic
+}
+
+func createPost(t *testing.T, s store.Store, topicID int, by store.User, title string) store.Post {
+	t.Helper()
+
+	post, err := s.CreatePost(context.Background(), topicID, title, "content of "+title, by.ID)
+	if err != nil {
+		t.Fatalf("CreatePost(%q): %v", title, err)
+	}
+	return post
+}
+
+func createComment(t *testing.T, s store.Store, postID int, parentID *int, by store.User, content string) store.Comment {
+	t.Helper()
+
+	comment, err := s.CreateComment(context.Background(), postID, parentID, content, by.ID)
+	if err != nil {
+		t.Fatalf("CreateComment(%q): %v", content, err)
+	}
+	return comment
+}
+
+func ptr(v int) *int {
+	return &v
+}
+
+func deref(p *int) any {
+	if p == nil {
+		return nil
+	}
+	return *p
+}
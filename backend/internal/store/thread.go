@@ -0,0 +1,48 @@
+package store
+
+import "sort"
+
+// ThreadComment is a comment placed in a thread. Depth is 0 for top-level
+// comments and ReplyCount counts every reply in the branch below it.
+type ThreadComment struct {
+	Comment
+	Depth      int `json:"depth"`
+	ReplyCount int `json:"reply_count"`
+}
+
+// Thread lays roots and their replies out depth first: every comment is
+// followed by its branch, replies oldest first so conversations read in
+// order. replies must hold every comment below the roots and may hold
+// others, which are left out.
+func Thread(roots, replies []Comment) []ThreadComment {
+	children := make(map[int][]Comment)
+	for _, reply := range replies {
+		if reply.ParentID != nil {
+			children[*reply.ParentID] = append(children[*reply.ParentID], reply)
+		}
+	}
+	for _, siblings := range children {
+		sort.Slice(siblings, func(i, j int) bool {
+			return SortOldest.Before(siblings[i].Cursor(SortOldest), siblings[j].Cursor(SortOldest))
+		})
+	}
+
+	thread := make([]ThreadComment, 0, len(roots)+len(replies))
+	var walk func(comment Comment, depth int) int
+	walk = func(comment Comment, depth int) int {
+		i := len(thread)
+		thread = append(thread, ThreadComment{Comment: comment, Depth: depth})
+
+		count := 0
+		for _, child := range children[comment.ID] {
+			count += 1 + walk(child, depth+1)
+		}
+		thread[i].ReplyCount = count
+		return count
+	}
+
+	for _, root := range roots {
+		walk(root, 0)
+	}
+	return thread
+}
@@ -0,0 +1,62 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// TokenScope limits what a personal access token may do
+type TokenScope string
+
+const (
+	// ScopeRead tokens may only make GET requests
+	ScopeRead TokenScope = "read"
+	// ScopeWrite tokens may also create, edit and delete
+	ScopeWrite TokenScope = "write"
+)
+
+var ErrInvalidScope = errors.New("store: invalid token scope")
+
+func ParseTokenScope(s string) (TokenScope, error) {
+	switch TokenScope(s) {
+	case ScopeRead, ScopeWrite:
+		return TokenScope(s), nil
+	}
+	return "", ErrInvalidScope
+}
+
+// CanWrite reports whether the scope allows requests that change anything
+func (s TokenScope) CanWrite() bool {
+	return s == ScopeWrite
+}
+
+// PersonalToken is a long-lived token a user creates for scripts and bots. The
+// token itself is only known to the user, the store keeps its hash.
+type PersonalToken struct {
+	ID         int        `json:"id"`
+	UserID     int        `json:"user_id"`
+	Name       string     `json:"name"`
+	Scope      TokenScope `json:"scope"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"-"`
+}
+
+// Active reports whether the token can still be used at t
+func (t PersonalToken) Active(at time.Time) bool {
+	return t.RevokedAt == nil && at.Before(t.ExpiresAt)
+}
+
+type PersonalTokenStore interface {
+	CreatePersonalToken(ctx context.Context, userID int, name string, scope TokenScope, tokenHash string, expiresAt time.Time) (PersonalToken, error)
+	GetPersonalToken(ctx context.Context, id int) (PersonalToken, error)
+	GetPersonalTokenByHash(ctx context.Context, tokenHash string) (PersonalToken, error)
+	// ListPersonalTokens returns the user's active tokens, newest first
+	ListPersonalTokens(ctx context.Context, userID int) ([]PersonalToken, error)
+	// RevokePersonalToken returns ErrNotFound if the token is not active
+	RevokePersonalToken(ctx context.Context, id int) error
+	// TouchPersonalToken records that the token was just used
+	TouchPersonalToken(ctx context.Context, id int) error
+}
@@ -0,0 +1,50 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrCodeReused is returned when a TOTP code is presented for a time step at
+// or before the last one used, so a code that was seen cannot be replayed
+var ErrCodeReused = errors.New("store: code already used")
+
+// TOTP is a user's authenticator app enrolment. It only guards logins once
+// confirmed, which EnabledAt records.
+type TOTP struct {
+	UserID    int
+	Secret    string
+	CreatedAt time.Time
+	EnabledAt *time.Time
+	// LastStep is the time step of the last code accepted
+	LastStep int64
+	// RecoveryCodesLeft counts the unused recovery codes
+	RecoveryCodesLeft int
+}
+
+func (t TOTP) Enabled() bool {
+	return t.EnabledAt != nil
+}
+
+// TwoFactorStore keeps TOTP secrets and the hashes of recovery codes
+type TwoFactorStore interface {
+	// GetTOTP returns ErrNotFound if the user has not started enrolling
+	GetTOTP(ctx context.Context, userID int) (TOTP, error)
+	// StartTOTP stores a new secret waiting for confirmation, replacing an
+	// unconfirmed one. It returns ErrConflict if two-factor is already enabled.
+	StartTOTP(ctx context.Context, userID int, secret string) error
+	// EnableTOTP confirms the enrolment at step and stores the recovery codes.
+	// It returns ErrNotFound if there is no enrolment waiting.
+	EnableTOTP(ctx context.Context, userID int, step int64, recoveryHashes []string) error
+	// UseTOTPStep records that the code of step was used. It returns
+	// ErrCodeReused if step is not after the last one used.
+	UseTOTPStep(ctx context.Context, userID int, step int64) error
+	// UseRecoveryCode spends a recovery code. It returns ErrNotFound if the
+	// user has no unused code with that hash.
+	UseRecoveryCode(ctx context.Context, userID int, hash string) error
+	// ReplaceRecoveryCodes throws away the user's recovery codes for new ones
+	ReplaceRecoveryCodes(ctx context.Context, userID int, hashes []string) error
+	// DisableTOTP removes the enrolment and the recovery codes
+	DisableTOTP(ctx context.Context, userID int) error
+}
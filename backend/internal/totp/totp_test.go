@@ -0,0 +1,147 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA-1 seed of RFC 6238 Appendix B, "12345678901234567890"
+var rfcSecret = encoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestCodeRFC6238(t *testing.T) {
+	// The RFC lists 8 digit codes; 6 digit codes are their last 6 digits
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+
+	for _, tt := range tests {
+		got, err := Code(rfcSecret, Step(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("Code at %d: %v", tt.unix, err)
+		}
+		if want := tt.code[len(tt.code)-Digits:]; got != want {
+			t.Errorf("Code at %d = %s, want %s", tt.unix, got, want)
+		}
+	}
+}
+
+func TestCodeLowerCaseSecret(t *testing.T) {
+	upper, err := Code(rfcSecret, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lower, err := Code("gezdgnbvgy3tqojqgezdgnbvgy3tqojq", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if upper != lower {
+		t.Errorf("lower case secret gave %s, want %s", lower, upper)
+	}
+}
+
+func TestVerifyWindow(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	step := Step(now)
+
+	for offset := int64(-3); offset <= 3; offset++ {
+		code, err := Code(rfcSecret, step+offset)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, ok := Verify(rfcSecret, code, now)
+		if want := offset >= -Skew && offset <= Skew; ok != want {
+			t.Errorf("code of step %+d accepted = %v, want %v", offset, ok, want)
+			continue
+		}
+		if ok && got != step+offset {
+			t.Errorf("code of step %+d matched step %d, want %d", offset, got, step+offset)
+		}
+	}
+}
+
+func TestVerifyWindowEdges(t *testing.T) {
+	// The first and last second of a step accept the same codes
+	start := time.Unix(Step(time.Unix(1234567890, 0))*int64(Period.Seconds()), 0)
+	end := start.Add(Period - time.Second)
+
+	code, err := Code(rfcSecret, Step(start)+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, at := range []time.Time{start, end} {
+		if _, ok := Verify(rfcSecret, code, at); !ok {
+			t.Errorf("code of the next step rejected at %s", at.UTC())
+		}
+	}
+	if _, ok := Verify(rfcSecret, code, end.Add(-Period)); ok {
+		t.Errorf("code two steps ahead accepted")
+	}
+}
+
+func TestVerifyInput(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+
+	tests := []struct {
+		code string
+		ok   bool
+	}{
+		{"081804", true},
+		{" 081 804 ", true},
+		{"081805", false},
+		{"81804", false},
+		{"0081804", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if _, ok := Verify(rfcSecret, tt.code, now); ok != tt.ok {
+			t.Errorf("Verify(%q) = %v, want %v", tt.code, ok, tt.ok)
+		}
+	}
+
+	if _, ok := Verify("not base32!", "081804", now); ok {
+		t.Errorf("Verify accepted a code for an invalid secret")
+	}
+}
+
+// TestVerifyReplay checks that a code reports the step it belongs to however
+// late in the window it is entered, which is what lets callers refuse a step
+// at or before the last one used
+func TestVerifyReplay(t *testing.T) {
+	first := time.Unix(1234567890, 0)
+	code, err := Code(rfcSecret, Step(first))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	used, ok := Verify(rfcSecret, code, first)
+	if !ok {
+		t.Fatalf("code rejected at its own step")
+	}
+
+	// Replayed a step later, the code is still in the window but names the same step
+	replayed, ok := Verify(rfcSecret, code, first.Add(Period))
+	if !ok {
+		t.Fatalf("code rejected one step later")
+	}
+	if replayed > used {
+		t.Errorf("replayed code matched step %d, after the used step %d", replayed, used)
+	}
+
+	// The next code is for a later step and may be used
+	next, err := Code(rfcSecret, Step(first)+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if step, ok := Verify(rfcSecret, next, first.Add(Period)); !ok || step <= used {
+		t.Errorf("next code matched step %d (%v), want one after %d", step, ok, used)
+	}
+}
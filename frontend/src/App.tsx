@@ -11,8 +11,17 @@ import CreatePostPage from "./pages/CreatePostPage";
 import CommentsPage from "./pages/CommentsPage";
 import CreateCommentPage from "./pages/CreateCommentPage";
 import EditPostPage from "./pages/EditPostPage";
+import EditTopicPage from "./pages/EditTopicPage";
 import EditCommentPage from "./pages/EditCommentPage";
 import ResetPasswordPage from "./pages/ResetPasswordPage";
+import ForgotPasswordPage from "./pages/ForgotPasswordPage";
+import NewPasswordPage from "./pages/NewPasswordPage";
+import VerifyEmailPage from "./pages/VerifyEmailPage";
+import EmailPage from "./pages/EmailPage";
+import ProfilePage from "./pages/ProfilePage";
+import AccountPage from "./pages/AccountPage";
+import CategoriesPage from "./pages/CategoriesPage";
+import RevisionsPage from "./pages/RevisionsPage";
 
 function App() {
   return (
@@ -21,12 +30,16 @@ function App() {
         <Route path="/" element={<LoginPage />}></Route>
         <Route path="/login" element={<LoginPage />}></Route>
         <Route path="/signup" element={<SignUpPage />}></Route>
+        <Route path="/forgot-password" element={<ForgotPasswordPage />} />
+        <Route path="/reset-password" element={<NewPasswordPage />} />
+        <Route path="/verify-email" element={<VerifyEmailPage />} />
         {/* Protected - need user authentication */}
         <Route element={<ProtectedRoute />}>
           <Route path="/topics" element={<TopicsPage />} />
           <Route path="/topics/create" element={<CreateTopicPage />} />
           <Route path="/topics/:topic_id" element={<PostsPage />} />
           <Route path="/topics/:topic_id/create" element={<CreatePostPage />} />
+          <Route path="/topics/:topic_id/edit" element={<EditTopicPage />} />
           <Route
             path="/topics/:topic_id/:post_id/edit"
             element={<EditPostPage />}
@@ -40,7 +53,19 @@ function App() {
             path="/topics/:topic_id/:post_id/comments/:comment_id/edit"
             element={<EditCommentPage />}
           />
+          <Route
+            path="/topics/:topic_id/:post_id/revisions"
+            element={<RevisionsPage />}
+          />
+          <Route
+            path="/topics/:topic_id/:post_id/comments/:comment_id/revisions"
+            element={<RevisionsPage />}
+          />
           <Route path="/changepassword" element={<ResetPasswordPage />} />
+          <Route path="/email" element={<EmailPage />} />
+          <Route path="/account" element={<AccountPage />} />
+          <Route path="/users/:user_id" element={<ProfilePage />} />
+          <Route path="/categories" element={<CategoriesPage />} />
         </Route>
         {/* Invalid Routes */}
         <Route path="*" element={<ErrorPage />} />
@@ -19,6 +19,7 @@ function CommentCard({
   id,
   content,
   created_by,
+  created_at,
   updated_at,
   username,
   like_count,
@@ -166,7 +167,9 @@ function CommentCard({
             color: "#006f80",
             paddingBottom: 1,
             textDecoration: "underline",
+            cursor: "pointer",
           }}
+          onClick={() => navigate(`/users/${created_by}`)}
         >
           {username}
         </Typography>
@@ -178,6 +181,15 @@ function CommentCard({
             dateStyle: "medium",
             timeStyle: "short",
           })}
+          {updated_at !== created_at && (
+            <Box
+              component="span"
+              sx={{ ml: 0.5, textDecoration: "underline", cursor: "pointer" }}
+              onClick={() => navigate(`comments/${id}/revisions`)}
+            >
+              (edited)
+            </Box>
+          )}
         </Typography>
       </CardContent>
       <CardContent>
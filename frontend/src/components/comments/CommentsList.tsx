@@ -1,11 +1,18 @@
 import { useEffect, useState } from "react";
 import CommentCard from "./CommentCard";
-import type { Comment } from "../../types";
+import type { Comment, Sort } from "../../types";
 import { fetchComments } from "../../services/api";
 import getCurrentUserId, { handleApiError } from "../common/Functions";
 import { useNavigate } from "react-router-dom";
 import ErrorMessage from "../common/ErrorMessage";
-import { Box, CircularProgress, MenuItem, Select, TextField } from "@mui/material";
+import {
+  Box,
+  Button,
+  CircularProgress,
+  MenuItem,
+  Select,
+  TextField,
+} from "@mui/material";
 
 interface CommentsListProp {
   post_id: number;
@@ -15,16 +22,20 @@ function CommentsList({ post_id }: CommentsListProp) {
   const [comments, setComments] = useState<Comment[]>([]);
   const [error, setError] = useState("");
   const [loading, setLoading] = useState(false);
-  const [sortBy, setSortBy] = useState<
-    "likes" | "net_score" | "newest" | "oldest"
-  >("newest");
+  const [nextCursor, setNextCursor] = useState<string | null>(null);
+  const [loadingMore, setLoadingMore] = useState(false);
+  const [sortBy, setSortBy] = useState<Sort>("newest");
   const [searchQuery, setSearchQuery] = useState("");
   const navigate = useNavigate();
 
   useEffect(() => {
     setLoading(true);
-    fetchComments(post_id)
-      .then((data) => setComments(data))
+    // The order comes from the server, so a new one starts from the first page
+    fetchComments(post_id, sortBy)
+      .then((page) => {
+        setComments(page.items);
+        setNextCursor(page.next_cursor);
+      })
       .catch((err) => {
         const errorMessage = handleApiError(err, navigate);
         if (errorMessage) {
@@ -32,30 +43,31 @@ function CommentsList({ post_id }: CommentsListProp) {
         }
       })
       .finally(() => setLoading(false));
-  }, [post_id]);
+  }, [post_id, sortBy]);
 
-  const sortedComments = [...comments].sort((a, b) => {
-    switch (sortBy) {
-      case "likes":
-        return b.like_count - a.like_count;
-      case "newest":
-        return (
-          new Date(b.updated_at).getTime() - new Date(a.updated_at).getTime()
-        );
-      case "oldest":
-        return (
-          new Date(a.updated_at).getTime() - new Date(b.updated_at).getTime()
-        );
-      case "net_score":
-        return b.net_score - a.net_score;
-      default:
-        return (
-          new Date(b.updated_at).getTime() - new Date(a.updated_at).getTime()
-        );
-    }
-  });
+  function handleLoadMore() {
+    setLoadingMore(true);
+    fetchComments(post_id, sortBy, nextCursor)
+      .then((page) => {
+        // A comment that moved since the last page can turn up again
+        setComments((prev) => [
+          ...prev,
+          ...page.items.filter(
+            (comment) => !prev.some((c) => c.id === comment.id)
+          ),
+        ]);
+        setNextCursor(page.next_cursor);
+      })
+      .catch((err) => {
+        const errorMessage = handleApiError(err, navigate);
+        if (errorMessage) {
+          setError(errorMessage);
+        }
+      })
+      .finally(() => setLoadingMore(false));
+  }
 
-  const filteredComments = [...sortedComments].filter((comment) => {
+  const filteredComments = comments.filter((comment) => {
     return comment.content.toLowerCase().includes(searchQuery.toLowerCase());
   });
 
@@ -156,7 +168,8 @@ function CommentsList({ post_id }: CommentsListProp) {
                 },
               }}
             >
-              <MenuItem value="net_score">Popular</MenuItem>
+              <MenuItem value="hot">Hot</MenuItem>
+              <MenuItem value="popular">Popular</MenuItem>
               <MenuItem value="likes">Most Liked</MenuItem>
               <MenuItem value="newest">Newest First</MenuItem>
               <MenuItem value="oldest">Oldest First</MenuItem>
@@ -177,6 +190,21 @@ function CommentsList({ post_id }: CommentsListProp) {
               />
             ))
           )}
+          {nextCursor && (
+            <Button
+              variant="contained"
+              size="small"
+              onClick={handleLoadMore}
+              disabled={loadingMore}
+              sx={{
+                margin: 1,
+                backgroundColor: "#006f80",
+                "&:hover": { backgroundColor: "#005f6e" },
+              }}
+            >
+              {loadingMore ? "Loading..." : "Load more"}
+            </Button>
+          )}
         </div>
       )}
     </>
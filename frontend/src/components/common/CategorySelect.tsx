@@ -0,0 +1,66 @@
+import { MenuItem, TextField, type TextFieldProps } from "@mui/material";
+import type { CategoryNode } from "../../types";
+import { flattenCategories, withoutCategory } from "../../utils/Categories";
+
+interface CategorySelectProps {
+  label: string;
+  categories: CategoryNode[];
+  value: number | null;
+  onChange: (categoryId: number | null) => void;
+  // Shown for no category, e.g. "None" or "All categories"
+  emptyLabel: string;
+  // Left out along with its subcategories, e.g. a category's own subtree
+  // when picking its parent
+  exclude?: number;
+  className?: string;
+  size?: "small" | "medium";
+  fullWidth?: boolean;
+  sx?: TextFieldProps["sx"];
+}
+
+// Picks a category from the tree, subcategories indented under their parent
+function CategorySelect({
+  label,
+  categories,
+  value,
+  onChange,
+  emptyLabel,
+  exclude,
+  className,
+  size,
+  fullWidth = true,
+  sx,
+}: CategorySelectProps) {
+  const options = flattenCategories(
+    exclude === undefined ? categories : withoutCategory(categories, exclude),
+  );
+
+  return (
+    <TextField
+      select
+      label={label}
+      variant="outlined"
+      fullWidth={fullWidth}
+      size={size}
+      value={value ?? ""}
+      onChange={(e) =>
+        onChange(e.target.value === "" ? null : Number(e.target.value))
+      }
+      className={className}
+      sx={sx}
+    >
+      <MenuItem value="">{emptyLabel}</MenuItem>
+      {options.map(({ category, depth }) => (
+        <MenuItem
+          key={category.id}
+          value={category.id}
+          sx={{ pl: 2 + depth * 2 }}
+        >
+          {category.name}
+        </MenuItem>
+      ))}
+    </TextField>
+  );
+}
+
+export default CategorySelect;
@@ -19,6 +19,23 @@ export function getCurrentUsername(): string {
   return createdBy;
 }
 
+// isCurrentUserAdmin only decides what to show; the backend checks the role
+export function isCurrentUserAdmin(): boolean {
+  const userStr = localStorage.getItem("user");
+  const user = userStr ? JSON.parse(userStr) : null;
+
+  return user?.role === "admin";
+}
+
+// isCurrentUserModerator is true for moderators and admins. Moderators are
+// only assigned some topics, which the backend checks.
+export function isCurrentUserModerator(): boolean {
+  const userStr = localStorage.getItem("user");
+  const user = userStr ? JSON.parse(userStr) : null;
+
+  return user?.role === "moderator" || user?.role === "admin";
+}
+
 export function handleApiError(
   err: unknown,
   navigate: NavigateFunction
@@ -3,7 +3,11 @@ import Box from "@mui/material/Box";
 import Toolbar from "@mui/material/Toolbar";
 import Typography from "@mui/material/Typography";
 import { useNavigate } from "react-router-dom";
-import { getCurrentUsername } from "./Functions";
+import {
+  getCurrentUserId,
+  getCurrentUsername,
+  isCurrentUserAdmin,
+} from "./Functions";
 import AdbIcon from "@mui/icons-material/Adb";
 import { logOut } from "../../services/api";
 import { Menu, MenuItem } from "@mui/material";
@@ -98,6 +102,22 @@ export default function Navbar() {
             open={Boolean(anchorEl)}
             onClose={handleClose}
           >
+            <MenuItem
+              onClick={() => {
+                handleClose();
+                navigate(`/users/${getCurrentUserId()}`);
+              }}
+            >
+              Profile
+            </MenuItem>
+            <MenuItem
+              onClick={() => {
+                handleClose();
+                navigate("/account");
+              }}
+            >
+              Account
+            </MenuItem>
             <MenuItem
               onClick={() => {
                 handleClose();
@@ -106,6 +126,24 @@ export default function Navbar() {
             >
               Reset Password
             </MenuItem>
+            <MenuItem
+              onClick={() => {
+                handleClose();
+                navigate("/email");
+              }}
+            >
+              Email Address
+            </MenuItem>
+            {isCurrentUserAdmin() && (
+              <MenuItem
+                onClick={() => {
+                  handleClose();
+                  navigate("/categories");
+                }}
+              >
+                Categories
+              </MenuItem>
+            )}
             <MenuItem
               onClick={() => {
                 handleClose();
@@ -1,6 +1,11 @@
 import { useNavigate } from "react-router-dom";
 import type { Post } from "../../types";
-import { createPostReaction, deletePost } from "../../services/api";
+import {
+  createPostReaction,
+  deletePost,
+  setPostLocked,
+  setPostPinned,
+} from "../../services/api";
 import { useState } from "react";
 import ErrorMessage from "../common/ErrorMessage";
 import {
@@ -11,8 +16,9 @@ import {
   Button,
   Box,
   IconButton,
+  Chip,
 } from "@mui/material";
-import { handleApiError } from "../common/Functions";
+import { handleApiError, isCurrentUserModerator } from "../common/Functions";
 import { ThumbDown, ThumbUp } from "@mui/icons-material";
 
 function PostCard({
@@ -21,18 +27,24 @@ function PostCard({
   title,
   content,
   created_by,
+  created_at,
   updated_at,
   username,
   like_count,
   dislike_count,
   currentUserId,
   user_reaction,
+  pinned_at,
+  locked_at,
   onReactionUpdate,
   onDelete,
+  onStateChange,
   disableButtons,
 }: Post & {
   currentUserId: number;
   onDelete: (() => void) | null;
+  // Called with the post once a moderator pinned or locked it, or undid that
+  onStateChange?: (post: Post) => void;
   disableButtons: boolean;
   onReactionUpdate?: (
     postId: number,
@@ -74,6 +86,19 @@ function PostCard({
     }
   }
 
+  async function handleState(update: () => Promise<{ post: Post }>) {
+    setError("");
+    try {
+      const data = await update();
+      onStateChange?.(data.post);
+    } catch (err) {
+      const errorMessage = handleApiError(err, navigate);
+      if (errorMessage) {
+        setError(errorMessage);
+      }
+    }
+  }
+
   async function handleLike() {
     try {
       await createPostReaction(id, 1);
@@ -207,7 +232,9 @@ function PostCard({
               color: "#006f80",
               paddingBottom: 1,
               textDecoration: "underline",
+              cursor: "pointer",
             }}
+            onClick={() => navigate(`/users/${created_by}`)}
           >
             {username}
           </Typography>
@@ -219,11 +246,36 @@ function PostCard({
               dateStyle: "medium",
               timeStyle: "short",
             })}
+            {updated_at !== created_at && (
+              <Box
+                component="span"
+                sx={{ ml: 0.5, textDecoration: "underline", cursor: "pointer" }}
+                onClick={() =>
+                  navigate(`/topics/${topic_id}/${id}/revisions`)
+                }
+              >
+                (edited)
+              </Box>
+            )}
           </Typography>
         </Box>
 
         <Typography variant="h5" sx={{ textAlign: "left" }}>
           {title}
+          {pinned_at && (
+            <Chip
+              label="Pinned"
+              size="small"
+              sx={{ ml: 1, color: "inherit" }}
+            />
+          )}
+          {locked_at && (
+            <Chip
+              label="Locked"
+              size="small"
+              sx={{ ml: 1, color: "inherit" }}
+            />
+          )}
         </Typography>
       </CardContent>
       <CardContent>
@@ -288,34 +340,60 @@ function PostCard({
             </Box>
           </Box>
 
-          {created_by === currentUserId && (
-            <Box>
-              <Button
-                variant="contained"
-                onClick={handleEdit}
-                size="small"
-                sx={{
-                  margin: 1,
-                  backgroundColor: "#006f80",
-                  "&:hover": { backgroundColor: "#005f6e" },
-                }}
-              >
-                Edit
-              </Button>
-              <Button
-                variant="contained"
-                size="small"
-                onClick={handleDelete}
-                sx={{
-                  margin: 1,
-                  backgroundColor: "#006f80",
-                  "&:hover": { backgroundColor: "#005f6e" },
-                }}
-              >
-                Delete
-              </Button>
-            </Box>
-          )}
+          <Box>
+            {isCurrentUserModerator() && (
+              <>
+                <Button
+                  variant="text"
+                  size="small"
+                  onClick={() =>
+                    handleState(() => setPostPinned(id, !pinned_at))
+                  }
+                  sx={{ margin: 1, color: "#006f80" }}
+                >
+                  {pinned_at ? "Unpin" : "Pin"}
+                </Button>
+                <Button
+                  variant="text"
+                  size="small"
+                  onClick={() =>
+                    handleState(() => setPostLocked(id, !locked_at))
+                  }
+                  sx={{ margin: 1, color: "#006f80" }}
+                >
+                  {locked_at ? "Unlock" : "Lock"}
+                </Button>
+              </>
+            )}
+            {created_by === currentUserId && (
+              <>
+                <Button
+                  variant="contained"
+                  onClick={handleEdit}
+                  size="small"
+                  sx={{
+                    margin: 1,
+                    backgroundColor: "#006f80",
+                    "&:hover": { backgroundColor: "#005f6e" },
+                  }}
+                >
+                  Edit
+                </Button>
+                <Button
+                  variant="contained"
+                  size="small"
+                  onClick={handleDelete}
+                  sx={{
+                    margin: 1,
+                    backgroundColor: "#006f80",
+                    "&:hover": { backgroundColor: "#005f6e" },
+                  }}
+                >
+                  Delete
+                </Button>
+              </>
+            )}
+          </Box>
           {error && <ErrorMessage error={error} />}
         </CardActions>
       )}
@@ -1,4 +1,4 @@
-import type { Post } from "../../types";
+import type { Post, Sort } from "../../types";
 import { useEffect, useState } from "react";
 import PostCard from "./PostCard";
 import { fetchPosts } from "../../services/api";
@@ -7,6 +7,7 @@ import { useNavigate } from "react-router-dom";
 import ErrorMessage from "../common/ErrorMessage";
 import {
   Box,
+  Button,
   CircularProgress,
   MenuItem,
   Select,
@@ -21,16 +22,20 @@ function PostsList({ topic_id }: PostsListProp) {
   const [posts, setPosts] = useState<Post[]>([]);
   const [error, setError] = useState("");
   const [loading, setLoading] = useState(false);
-  const [sortBy, setSortBy] = useState<
-    "likes" | "net_score" | "newest" | "oldest"
-  >("newest");
+  const [nextCursor, setNextCursor] = useState<string | null>(null);
+  const [loadingMore, setLoadingMore] = useState(false);
+  const [sortBy, setSortBy] = useState<Sort>("newest");
   const [searchQuery, setSearchQuery] = useState("");
   const navigate = useNavigate();
 
   useEffect(() => {
     setLoading(true);
-    fetchPosts(topic_id)
-      .then((data) => setPosts(data))
+    // The order comes from the server, so a new one starts from the first page
+    fetchPosts(topic_id, sortBy)
+      .then((page) => {
+        setPosts(page.items);
+        setNextCursor(page.next_cursor);
+      })
       .catch((err) => {
         const errorMessage = handleApiError(err, navigate);
         if (errorMessage) {
@@ -38,30 +43,29 @@ function PostsList({ topic_id }: PostsListProp) {
         }
       })
       .finally(() => setLoading(false));
-  }, []);
+  }, [topic_id, sortBy]);
 
-  const sortedPosts = [...posts].sort((a, b) => {
-    switch (sortBy) {
-      case "likes":
-        return b.like_count - a.like_count;
-      case "newest":
-        return (
-          new Date(b.updated_at).getTime() - new Date(a.updated_at).getTime()
-        );
-      case "oldest":
-        return (
-          new Date(a.updated_at).getTime() - new Date(b.updated_at).getTime()
-        );
-      case "net_score":
-        return b.net_score - a.net_score;
-      default:
-        return (
-          new Date(b.updated_at).getTime() - new Date(a.updated_at).getTime()
-        );
-    }
-  });
+  function handleLoadMore() {
+    setLoadingMore(true);
+    fetchPosts(topic_id, sortBy, nextCursor)
+      .then((page) => {
+        // A post that moved since the last page can turn up again
+        setPosts((prev) => [
+          ...prev,
+          ...page.items.filter((post) => !prev.some((p) => p.id === post.id)),
+        ]);
+        setNextCursor(page.next_cursor);
+      })
+      .catch((err) => {
+        const errorMessage = handleApiError(err, navigate);
+        if (errorMessage) {
+          setError(errorMessage);
+        }
+      })
+      .finally(() => setLoadingMore(false));
+  }
 
-  const filteredPosts = [...sortedPosts].filter((post) => {
+  const filteredPosts = posts.filter((post) => {
     return (
       post.title.toLowerCase().includes(searchQuery.toLowerCase()) ||
       post.content.toLowerCase().includes(searchQuery.toLowerCase())
@@ -165,7 +169,8 @@ function PostsList({ topic_id }: PostsListProp) {
                 },
               }}
             >
-              <MenuItem value="net_score">Popular</MenuItem>
+              <MenuItem value="hot">Hot</MenuItem>
+              <MenuItem value="popular">Popular</MenuItem>
               <MenuItem value="likes">Most Liked</MenuItem>
               <MenuItem value="newest">Newest First</MenuItem>
               <MenuItem value="oldest">Oldest First</MenuItem>
@@ -183,10 +188,30 @@ function PostsList({ topic_id }: PostsListProp) {
                 onDelete={() =>
                   setPosts((prev) => prev.filter((p) => p.id !== post.id))
                 }
+                onStateChange={(updated) =>
+                  setPosts((prev) =>
+                    prev.map((p) => (p.id === updated.id ? updated : p)),
+                  )
+                }
                 disableButtons={false}
               />
             ))
           )}
+          {nextCursor && (
+            <Button
+              variant="contained"
+              size="small"
+              onClick={handleLoadMore}
+              disabled={loadingMore}
+              sx={{
+                margin: 1,
+                backgroundColor: "#006f80",
+                "&:hover": { backgroundColor: "#005f6e" },
+              }}
+            >
+              {loadingMore ? "Loading..." : "Load more"}
+            </Button>
+          )}
         </div>
       )}
     </>
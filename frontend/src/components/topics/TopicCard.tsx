@@ -1,14 +1,25 @@
 import { useNavigate } from "react-router-dom";
-import type { Topic } from "../../types";
+import type { TopicStats } from "../../types";
 import {
   Card,
   CardActions,
   CardContent,
   Typography,
   Button,
+  Chip,
 } from "@mui/material";
 
-function TopicCard({ id, title }: Topic) {
+function TopicCard({
+  id,
+  title,
+  description,
+  archived_at,
+  post_count,
+  comment_count,
+  last_activity_at,
+  last_poster_id,
+  last_poster,
+}: TopicStats) {
   const navigate = useNavigate();
 
   return (
@@ -33,6 +44,49 @@ function TopicCard({ id, title }: Topic) {
       <CardContent>
         <Typography variant="h5" sx={{ textAlign: "left" }}>
           {title}
+          {archived_at && (
+            <Chip
+              label="Archived"
+              size="small"
+              sx={{ ml: 1, color: "inherit" }}
+            />
+          )}
+        </Typography>
+        {description && (
+          <Typography
+            variant="body2"
+            sx={{ textAlign: "left", mt: 1, opacity: 0.8 }}
+          >
+            {description}
+          </Typography>
+        )}
+        <Typography
+          variant="caption"
+          component="p"
+          sx={{ textAlign: "left", mt: 1, opacity: 0.7 }}
+        >
+          {post_count} {post_count === 1 ? "post" : "posts"} ·{" "}
+          {comment_count} {comment_count === 1 ? "comment" : "comments"} ·{" "}
+          {last_poster ? "active " : "created "}
+          {new Date(last_activity_at).toLocaleString([], {
+            dateStyle: "medium",
+            timeStyle: "short",
+          })}
+          {last_poster && (
+            <>
+              {" by "}
+              <span
+                style={{
+                  color: "#006f80",
+                  textDecoration: "underline",
+                  cursor: "pointer",
+                }}
+                onClick={() => navigate(`/users/${last_poster_id}`)}
+              >
+                {last_poster}
+              </span>
+            </>
+          )}
         </Typography>
       </CardContent>
       <CardActions>
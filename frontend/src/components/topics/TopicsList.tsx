@@ -1,26 +1,69 @@
 import { useNavigate } from "react-router-dom";
-import { fetchTopics } from "../../services/api";
-import type { Topic } from "../../types";
+import { fetchCategories, fetchTopics } from "../../services/api";
+import type { CategoryNode, TopicSort, TopicStats } from "../../types";
 import ErrorMessage from "../common/ErrorMessage";
 import { handleApiError } from "../common/Functions";
+import CategorySelect from "../common/CategorySelect";
 import TopicCard from "./TopicCard";
 import { useEffect, useState } from "react";
-import { Box, CircularProgress, TextField } from "@mui/material";
+import {
+  Box,
+  CircularProgress,
+  MenuItem,
+  Select,
+  TextField,
+} from "@mui/material";
+
+// White text on the dark toolbar, outlined in the forum's teal
+const fieldSx = {
+  "& .MuiOutlinedInput-root": {
+    color: "white",
+  },
+  "& .MuiInputLabel-root": {
+    color: "white",
+  },
+  "& .MuiOutlinedInput-notchedOutline": {
+    borderColor: "#006f80",
+  },
+  "&:hover .MuiOutlinedInput-notchedOutline": {
+    borderColor: "#005f6e",
+  },
+  "& .MuiOutlinedInput-root.Mui-focused .MuiOutlinedInput-notchedOutline": {
+    borderColor: "#006f80",
+  },
+  "& .MuiInputLabel-root.Mui-focused": {
+    color: "white",
+  },
+};
 
 function TopicsList() {
-  const [topics, setTopics] = useState<Topic[]>([]);
+  const [topics, setTopics] = useState<TopicStats[]>([]);
   const [error, setError] = useState("");
   const [loading, setLoading] = useState(false);
   const [searchQuery, setSearchQuery] = useState("");
+  const [sortBy, setSortBy] = useState<TopicSort>("activity");
+  const [categories, setCategories] = useState<CategoryNode[]>([]);
+  const [categoryId, setCategoryId] = useState<number | null>(null);
   const navigate = useNavigate();
 
   const filteredTopics = [...topics].filter((topic) => {
     return topic.title.toLowerCase().includes(searchQuery.toLowerCase());
   });
 
+  useEffect(() => {
+    fetchCategories()
+      .then(setCategories)
+      .catch((err) => {
+        const errorMessage = handleApiError(err, navigate);
+        if (errorMessage) {
+          setError(errorMessage);
+        }
+      });
+  }, []);
+
   useEffect(() => {
     setLoading(true);
-    fetchTopics()
+    fetchTopics(sortBy, categoryId)
       .then((data) => setTopics(data))
       .catch((err) => {
         const errorMessage = handleApiError(err, navigate);
@@ -29,7 +72,7 @@ function TopicsList() {
         }
       })
       .finally(() => setLoading(false));
-  }, []);
+  }, [sortBy, categoryId]);
 
   return (
     <>
@@ -57,6 +100,7 @@ function TopicsList() {
               alignItems: "center",
               marginBottom: 2,
               paddingRight: 2,
+              gap: 2,
             }}
           >
             <TextField
@@ -66,39 +110,54 @@ function TopicsList() {
               value={searchQuery}
               onChange={(e) => setSearchQuery(e.target.value)}
               size="small"
+              sx={fieldSx}
+            />
+            <CategorySelect
+              label="Category"
+              categories={categories}
+              value={categoryId}
+              onChange={setCategoryId}
+              emptyLabel="All categories"
+              size="small"
+              fullWidth={false}
               sx={{
-                "& .MuiOutlinedInput-root": {
-                  color: "white",
-                },
-                "& .MuiInputLabel-root": {
+                ...fieldSx,
+                minWidth: 180,
+                "& .MuiSelect-icon": {
                   color: "white",
                 },
+              }}
+            />
+            <Select
+              value={sortBy}
+              onChange={(e) => setSortBy(e.target.value)}
+              size="small"
+              sx={{
+                color: "white",
                 "& .MuiOutlinedInput-notchedOutline": {
                   borderColor: "#006f80",
                 },
                 "&:hover .MuiOutlinedInput-notchedOutline": {
                   borderColor: "#005f6e",
                 },
-                "& .MuiOutlinedInput-root.Mui-focused .MuiOutlinedInput-notchedOutline": {
+                "&.Mui-focused .MuiOutlinedInput-notchedOutline": {
                   borderColor: "#006f80",
                 },
-                "& .MuiInputLabel-root.Mui-focused": {
+                "& .MuiSelect-icon": {
                   color: "white",
                 },
               }}
-            />
+            >
+              <MenuItem value="activity">Latest Activity</MenuItem>
+              <MenuItem value="newest">Newest First</MenuItem>
+              <MenuItem value="oldest">Oldest First</MenuItem>
+            </Select>
           </Box>
           {filteredTopics.length === 0 ? (
             <p>No topics yet</p>
           ) : (
             filteredTopics.map((topic) => (
-              <TopicCard
-                key={topic.id}
-                id={topic.id}
-                title={topic.title}
-                created_by={topic.created_by}
-                created_at={topic.created_at}
-              />
+              <TopicCard key={topic.id} {...topic} />
             ))
           )}
         </div>
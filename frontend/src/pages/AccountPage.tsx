@@ -0,0 +1,222 @@
+import { useEffect, useState } from "react";
+import {
+  changeUsername,
+  deleteAccount,
+  fetchProfile,
+  updateProfile,
+  validate,
+} from "../services/api";
+import "./Pages.css";
+import { useNavigate } from "react-router-dom";
+import ErrorMessage from "../components/common/ErrorMessage";
+import {
+  Alert,
+  Button,
+  FormControlLabel,
+  Radio,
+  RadioGroup,
+  TextField,
+} from "@mui/material";
+import { handleApiError } from "../components/common/Functions";
+import type { User } from "../types";
+
+// Edits the signed-in user's profile and username, and deletes the account
+function AccountPage() {
+  const [user, setUser] = useState<User | null>(null);
+  const [displayName, setDisplayName] = useState("");
+  const [bio, setBio] = useState("");
+  const [avatarURL, setAvatarURL] = useState("");
+  const [username, setUsername] = useState("");
+  const [policy, setPolicy] = useState<"anonymise" | "delete">("anonymise");
+  const [confirmation, setConfirmation] = useState("");
+  const [message, setMessage] = useState("");
+  const [error, setError] = useState("");
+  const navigate = useNavigate();
+
+  useEffect(() => {
+    validate()
+      .then((data) => fetchProfile(data.user.id))
+      .then((profile: User) => {
+        setUser(profile);
+        setDisplayName(profile.display_name ?? "");
+        setBio(profile.bio ?? "");
+        setAvatarURL(profile.avatar_url ?? "");
+        setUsername(profile.username);
+      })
+      .catch((err) => {
+        const message = handleApiError(err, navigate);
+        if (message) {
+          setError(message);
+        }
+      });
+  }, [navigate]);
+
+  async function run(action: () => Promise<{ message: string }>) {
+    setError("");
+    setMessage("");
+    try {
+      const data = await action();
+      setMessage(data.message);
+    } catch (err) {
+      const message = handleApiError(err, navigate);
+      if (message) {
+        setError(message);
+      }
+    }
+  }
+
+  async function handleProfile(e: React.FormEvent) {
+    e.preventDefault();
+    if (!user) return;
+    await run(() => updateProfile(user.id, displayName, bio, avatarURL));
+  }
+
+  async function handleUsername(e: React.FormEvent) {
+    e.preventDefault();
+    if (!user) return;
+    await run(async () => {
+      const data = await changeUsername(user.id, username);
+      // The navbar reads the username from the stored user
+      const stored = JSON.parse(localStorage.getItem("user") ?? "{}");
+      localStorage.setItem("user", JSON.stringify({ ...stored, username }));
+      setUser({ ...user, username });
+      return data;
+    });
+  }
+
+  async function handleDelete(e: React.FormEvent) {
+    e.preventDefault();
+    if (!user) return;
+    if (
+      !window.confirm(
+        "Delete your account? This cannot be undone and logs you out everywhere",
+      )
+    ) {
+      return;
+    }
+
+    setError("");
+    try {
+      await deleteAccount(user.id, policy, confirmation, confirmation);
+      localStorage.removeItem("user");
+      navigate("/login");
+    } catch (err) {
+      const message = handleApiError(err, navigate);
+      if (message) {
+        setError(message);
+      }
+    }
+  }
+
+  return (
+    <div className="authentication">
+      <form onSubmit={handleProfile}>
+        <h1>Account</h1>
+        {error && <ErrorMessage error={error} />}
+        {message && (
+          <Alert variant="outlined" severity="success" sx={{ mb: 1 }}>
+            {message}
+          </Alert>
+        )}
+
+        <TextField
+          label="Display Name"
+          variant="outlined"
+          fullWidth
+          value={displayName}
+          onChange={(e) => setDisplayName(e.target.value)}
+          className="auth-input"
+          slotProps={{ htmlInput: { maxLength: 50 } }}
+        />
+        <TextField
+          label="Bio"
+          variant="outlined"
+          fullWidth
+          multiline
+          minRows={3}
+          value={bio}
+          onChange={(e) => setBio(e.target.value)}
+          className="auth-input"
+          slotProps={{ htmlInput: { maxLength: 1000 } }}
+        />
+        <TextField
+          label="Avatar URL"
+          type="url"
+          variant="outlined"
+          fullWidth
+          value={avatarURL}
+          onChange={(e) => setAvatarURL(e.target.value)}
+          className="auth-input"
+        />
+        <div className="submissions">
+          <Button
+            type="button"
+            onClick={() => user && navigate(`/users/${user.id}`)}
+          >
+            Back
+          </Button>
+          <Button type="submit">Save Profile</Button>
+        </div>
+      </form>
+
+      <form onSubmit={handleUsername}>
+        <h1>Username</h1>
+        <TextField
+          label="Username"
+          variant="outlined"
+          fullWidth
+          value={username}
+          onChange={(e) => setUsername(e.target.value)}
+          className="auth-input"
+        />
+        <div className="submissions">
+          <Button
+            type="submit"
+            disabled={username.length < 3 || username === user?.username}
+          >
+            Change Username
+          </Button>
+        </div>
+      </form>
+
+      <form onSubmit={handleDelete}>
+        <h1>Delete Account</h1>
+        <RadioGroup
+          value={policy}
+          onChange={(e) => setPolicy(e.target.value as "anonymise" | "delete")}
+        >
+          <FormControlLabel
+            value="anonymise"
+            control={<Radio />}
+            label="Keep my posts and comments, anonymised"
+          />
+          <FormControlLabel
+            value="delete"
+            control={<Radio />}
+            label="Delete my posts, comments and reactions too"
+          />
+        </RadioGroup>
+        <TextField
+          label="Password (or username if you use single sign-on)"
+          type="password"
+          variant="outlined"
+          fullWidth
+          value={confirmation}
+          onChange={(e) => setConfirmation(e.target.value)}
+          className="auth-input"
+        />
+        <div className="submissions">
+          <Button
+            type="submit"
+            color="error"
+            disabled={confirmation.length === 0}
+          >
+            Delete Account
+          </Button>
+        </div>
+      </form>
+    </div>
+  );
+}
+
+export default AccountPage;
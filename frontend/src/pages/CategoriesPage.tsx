@@ -0,0 +1,183 @@
+import { useEffect, useState } from "react";
+import { useNavigate } from "react-router-dom";
+import {
+  createCategory,
+  deleteCategory,
+  fetchCategories,
+  updateCategory,
+} from "../services/api";
+import "./Pages.css";
+import ErrorMessage from "../components/common/ErrorMessage";
+import CategorySelect from "../components/common/CategorySelect";
+import { emptyFields, handleApiError } from "../components/common/Functions";
+import { flattenCategories } from "../utils/Categories";
+import { Button, TextField } from "@mui/material";
+import type { Category, CategoryNode } from "../types";
+
+// Lets admins create, rename, move and delete categories
+function CategoriesPage() {
+  const [categories, setCategories] = useState<CategoryNode[]>([]);
+  // The category being edited, or null when creating one
+  const [editing, setEditing] = useState<number | null>(null);
+  const [name, setName] = useState("");
+  const [description, setDescription] = useState("");
+  const [parentId, setParentId] = useState<number | null>(null);
+  const [position, setPosition] = useState(0);
+  const [error, setError] = useState("");
+  const navigate = useNavigate();
+
+  async function load() {
+    try {
+      setCategories(await fetchCategories());
+    } catch (err) {
+      const errorMessage = handleApiError(err, navigate);
+      if (errorMessage) {
+        setError(errorMessage);
+      }
+    }
+  }
+
+  useEffect(() => {
+    load();
+  }, []);
+
+  function edit(category: Category | null) {
+    setEditing(category?.id ?? null);
+    setName(category?.name ?? "");
+    setDescription(category?.description ?? "");
+    setParentId(category?.parent_id ?? null);
+    setPosition(category?.position ?? 0);
+  }
+
+  async function handleSubmit(e: React.FormEvent) {
+    e.preventDefault();
+    setError("");
+
+    try {
+      if (editing === null) {
+        await createCategory(name, description, parentId, position);
+      } else {
+        await updateCategory(editing, name, description, parentId, position);
+      }
+      edit(null);
+      await load();
+    } catch (err) {
+      const errorMessage = handleApiError(err, navigate);
+      if (errorMessage) {
+        setError(errorMessage);
+      }
+    }
+  }
+
+  async function handleDelete(category: Category) {
+    if (
+      !window.confirm(
+        `Delete ${category.name}? Its subcategories and topics move up to its parent`,
+      )
+    ) {
+      return;
+    }
+
+    setError("");
+    try {
+      await deleteCategory(category.id);
+      if (editing === category.id) {
+        edit(null);
+      }
+      await load();
+    } catch (err) {
+      const errorMessage = handleApiError(err, navigate);
+      if (errorMessage) {
+        setError(errorMessage);
+      }
+    }
+  }
+
+  return (
+    <div className="edit">
+      <form onSubmit={(e) => e.preventDefault()}>
+        <h1>Categories</h1>
+        {error && <ErrorMessage error={error} />}
+        {categories.length === 0 ? (
+          <p>No categories yet</p>
+        ) : (
+          flattenCategories(categories).map(({ category, depth }) => (
+            <div
+              key={category.id}
+              className="submissions"
+              style={{ paddingLeft: depth * 16 }}
+            >
+              <span style={{ flexGrow: 1 }}>{category.name}</span>
+              <Button type="button" onClick={() => edit(category)}>
+                Edit
+              </Button>
+              <Button
+                type="button"
+                color="error"
+                onClick={() => handleDelete(category)}
+              >
+                Delete
+              </Button>
+            </div>
+          ))
+        )}
+      </form>
+
+      <form onSubmit={handleSubmit}>
+        <h1>{editing === null ? "New category" : "Edit category"}</h1>
+        <TextField
+          label="Name"
+          variant="outlined"
+          fullWidth
+          value={name}
+          onChange={(e) => setName(e.target.value)}
+          className="field-input"
+          slotProps={{ htmlInput: { maxLength: 50 } }}
+        />
+        <TextField
+          label="Description (optional)"
+          multiline
+          minRows={2}
+          maxRows={6}
+          variant="outlined"
+          value={description}
+          onChange={(e) => setDescription(e.target.value)}
+          className="field-input"
+          slotProps={{ htmlInput: { maxLength: 1000 } }}
+        />
+        <CategorySelect
+          label="Parent"
+          categories={categories}
+          value={parentId}
+          onChange={setParentId}
+          emptyLabel="None (top level)"
+          exclude={editing ?? undefined}
+          className="field-input"
+        />
+        <TextField
+          label="Position"
+          type="number"
+          variant="outlined"
+          value={position}
+          onChange={(e) => setPosition(Number(e.target.value))}
+          className="field-input"
+        />
+        <div className="submissions">
+          <Button
+            type="button"
+            onClick={() =>
+              editing === null ? navigate("/topics") : edit(null)
+            }
+          >
+            {editing === null ? "Back" : "Cancel"}
+          </Button>
+          <Button type="submit" disabled={emptyFields(name)}>
+            {editing === null ? "Create" : "Save"}
+          </Button>
+        </div>
+      </form>
+    </div>
+  );
+}
+
+export default CategoriesPage;
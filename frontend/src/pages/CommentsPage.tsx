@@ -1,6 +1,6 @@
 import { useNavigate, useParams } from "react-router-dom";
 import CommentsList from "../components/comments/CommentsList";
-import { Box, Button, CircularProgress } from "@mui/material";
+import { Alert, Box, Button, CircularProgress } from "@mui/material";
 import ArrowBackIcon from "@mui/icons-material/ArrowBack";
 import PostCard from "../components/posts/PostCard";
 import { useEffect, useState } from "react";
@@ -57,20 +57,33 @@ function CommentsPage() {
             marginRight: 10,
           }}
         >
-          <Button
-            variant="contained"
-            size="small"
-            onClick={() => navigate("create")}
-            sx={{
-              margin: 1,
-              backgroundColor: "#006f80",
-              "&:hover": { backgroundColor: "#005f6e" },
-            }}
-          >
-            New Comment
-          </Button>
+          {post && !post.locked_at && (
+            <Button
+              variant="contained"
+              size="small"
+              onClick={() => navigate("create")}
+              sx={{
+                margin: 1,
+                backgroundColor: "#006f80",
+                "&:hover": { backgroundColor: "#005f6e" },
+              }}
+            >
+              New Comment
+            </Button>
+          )}
         </div>
       </div>
+      {post?.locked_at && (
+        <Alert
+          variant="outlined"
+          severity="info"
+          sx={{ maxWidth: 800, margin: "0 auto 16px" }}
+        >
+          This post was locked on{" "}
+          {new Date(post.locked_at).toLocaleDateString()} and no longer takes
+          comments.
+        </Alert>
+      )}
       <div className="comments-post">
         {loading ? (
           <Box
@@ -1,24 +1,40 @@
-import { useState } from "react";
+import { useEffect, useState } from "react";
 import { useNavigate } from "react-router-dom";
-import { createTopic } from "../services/api";
+import { createTopic, fetchCategories } from "../services/api";
 import "./Pages.css";
 import ErrorMessage from "../components/common/ErrorMessage";
 import { emptyFields, handleApiError } from "../components/common/Functions";
 import { Button, CircularProgress, TextField } from "@mui/material";
+import CategorySelect from "../components/common/CategorySelect";
+import type { CategoryNode } from "../types";
 
 function CreateTopicPage() {
   const [title, setTitle] = useState("");
+  const [description, setDescription] = useState("");
+  const [categories, setCategories] = useState<CategoryNode[]>([]);
+  const [categoryId, setCategoryId] = useState<number | null>(null);
   const [error, setError] = useState("");
   const [loading, setLoading] = useState(false);
   const navigate = useNavigate();
 
+  useEffect(() => {
+    fetchCategories()
+      .then(setCategories)
+      .catch((err) => {
+        const errorMessage = handleApiError(err, navigate);
+        if (errorMessage) {
+          setError(errorMessage);
+        }
+      });
+  }, [navigate]);
+
   async function handleSubmit(e: React.FormEvent) {
     e.preventDefault();
     setError("");
 
     try {
       setLoading(true);
-      await createTopic(title);
+      await createTopic(title, description, categoryId);
       navigate("/topics");
     } catch (err) {
       const errorMessage = handleApiError(err, navigate);
@@ -44,6 +60,27 @@ function CreateTopicPage() {
           onChange={(e) => setTitle(e.target.value)}
           className="field-input"
         />
+
+        <TextField
+          label="Description (optional)"
+          multiline
+          minRows={2}
+          maxRows={6}
+          variant="outlined"
+          value={description}
+          onChange={(e) => setDescription(e.target.value)}
+          className="field-input"
+          slotProps={{ htmlInput: { maxLength: 1000 } }}
+        />
+
+        <CategorySelect
+          label="Category"
+          categories={categories}
+          value={categoryId}
+          onChange={setCategoryId}
+          emptyLabel="None"
+          className="field-input"
+        />
         <div>
           <div className="submissions">
             <Button type="button" onClick={() => navigate("/topics")}>
@@ -0,0 +1,163 @@
+import { useEffect, useState } from "react";
+import { useNavigate, useParams } from "react-router-dom";
+import {
+  deleteTopic,
+  fetchCategories,
+  fetchTopic,
+  updateTopic,
+} from "../services/api";
+import "./Pages.css";
+import { emptyFields, handleApiError } from "../components/common/Functions";
+import {
+  Button,
+  Checkbox,
+  CircularProgress,
+  FormControlLabel,
+  TextField,
+} from "@mui/material";
+import ErrorMessage from "../components/common/ErrorMessage";
+import CategorySelect from "../components/common/CategorySelect";
+import type { CategoryNode } from "../types";
+
+// Edits a topic's title, description and category, archives it or deletes it
+function EditTopicPage() {
+  const [title, setTitle] = useState("");
+  const [description, setDescription] = useState("");
+  const [categories, setCategories] = useState<CategoryNode[]>([]);
+  const [categoryId, setCategoryId] = useState<number | null>(null);
+  const [archived, setArchived] = useState(false);
+  const [error, setError] = useState("");
+  const [loading, setLoading] = useState(false);
+  const navigate = useNavigate();
+
+  const { topic_id } = useParams();
+  const topicId = Number(topic_id);
+
+  useEffect(() => {
+    async function loadTopic() {
+      try {
+        const [data, tree] = await Promise.all([
+          fetchTopic(topicId),
+          fetchCategories(),
+        ]);
+        setTitle(data.title);
+        setDescription(data.description);
+        setCategories(tree);
+        setCategoryId(data.category_id);
+        setArchived(data.archived_at !== null);
+      } catch (err) {
+        const errorMessage = handleApiError(err, navigate);
+        if (errorMessage) {
+          setError(errorMessage);
+        }
+      }
+    }
+    loadTopic();
+  }, [topicId]);
+
+  async function handleSubmit(e: React.FormEvent) {
+    e.preventDefault();
+    setError("");
+
+    try {
+      setLoading(true);
+      await updateTopic(topicId, title, description, categoryId, archived);
+      navigate(`/topics/${topicId}`);
+    } catch (err) {
+      const errorMessage = handleApiError(err, navigate);
+      if (errorMessage) {
+        setError(errorMessage);
+      }
+    } finally {
+      setLoading(false);
+    }
+  }
+
+  async function handleDelete() {
+    if (
+      !window.confirm(
+        "Delete this topic with all its posts and comments? This cannot be undone",
+      )
+    ) {
+      return;
+    }
+
+    setError("");
+    try {
+      await deleteTopic(topicId);
+      navigate("/topics");
+    } catch (err) {
+      const errorMessage = handleApiError(err, navigate);
+      if (errorMessage) {
+        setError(errorMessage);
+      }
+    }
+  }
+
+  return (
+    <div className="edit">
+      <form onSubmit={handleSubmit}>
+        <h1>Edit topic</h1>
+        {error && <ErrorMessage error={error} />}
+
+        <TextField
+          label="Title"
+          variant="outlined"
+          fullWidth
+          value={title}
+          onChange={(e) => setTitle(e.target.value)}
+          className="field-input"
+        />
+
+        <TextField
+          label="Description (optional)"
+          multiline
+          minRows={2}
+          maxRows={6}
+          variant="outlined"
+          value={description}
+          onChange={(e) => setDescription(e.target.value)}
+          className="field-input"
+          slotProps={{ htmlInput: { maxLength: 1000 } }}
+        />
+
+        <CategorySelect
+          label="Category"
+          categories={categories}
+          value={categoryId}
+          onChange={setCategoryId}
+          emptyLabel="None"
+          className="field-input"
+        />
+
+        <FormControlLabel
+          control={
+            <Checkbox
+              checked={archived}
+              onChange={(e) => setArchived(e.target.checked)}
+            />
+          }
+          label="Archived: no new posts or comments, no edits"
+        />
+
+        <div className="submissions">
+          <Button type="button" onClick={() => navigate(`/topics/${topicId}`)}>
+            Back
+          </Button>
+          <Button type="button" color="error" onClick={handleDelete}>
+            Delete
+          </Button>
+          <Button type="submit" disabled={emptyFields(title) || loading}>
+            {loading ? (
+              <CircularProgress size={24} sx={{ color: "white" }} />
+            ) : (
+              "Save"
+            )}
+          </Button>
+        </div>
+      </form>
+    </div>
+  );
+}
+
+export default EditTopicPage;
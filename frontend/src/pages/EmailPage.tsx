@@ -0,0 +1,127 @@
+import { useEffect, useState } from "react";
+import { changeEmail, resendVerification, validate } from "../services/api";
+import "./Pages.css";
+import { useNavigate } from "react-router-dom";
+import ErrorMessage from "../components/common/ErrorMessage";
+import { Alert, Button, CircularProgress, TextField } from "@mui/material";
+import type { User } from "../types";
+
+// Sets the address "Forgot password?" sends reset links to
+function EmailPage() {
+  const [user, setUser] = useState<User | null>(null);
+  const [email, setEmail] = useState("");
+  const [password, setPassword] = useState("");
+  const [message, setMessage] = useState("");
+  const [error, setError] = useState("");
+  const [loading, setLoading] = useState(false);
+  const navigate = useNavigate();
+
+  async function loadUser() {
+    const data = await validate();
+    setUser(data.user);
+    setEmail(data.user.email ?? "");
+  }
+
+  useEffect(() => {
+    loadUser().catch((err) => {
+      if (err instanceof Error) {
+        setError(err.message);
+      }
+    });
+  }, []);
+
+  async function handleSubmit(e: React.FormEvent) {
+    e.preventDefault();
+    setError("");
+    setMessage("");
+    try {
+      setLoading(true);
+      const data = await changeEmail(email, password, password);
+      setMessage(data.message);
+      setPassword("");
+      await loadUser();
+    } catch (err) {
+      if (err instanceof Error) {
+        setError(err.message);
+      }
+    } finally {
+      setLoading(false);
+    }
+  }
+
+  async function handleResend() {
+    setError("");
+    setMessage("");
+    try {
+      const data = await resendVerification();
+      setMessage(data.message);
+    } catch (err) {
+      if (err instanceof Error) {
+        setError(err.message);
+      }
+    }
+  }
+
+  return (
+    <div className="authentication">
+      <form onSubmit={handleSubmit}>
+        <h1>Email Address</h1>
+        {error && <ErrorMessage error={error} />}
+        {message && (
+          <Alert variant="outlined" severity="success" sx={{ mb: 1 }}>
+            {message}
+          </Alert>
+        )}
+
+        {user?.email && (
+          <p className="auth-link">
+            {user.email_verified
+              ? `${user.email} is verified`
+              : `${user.email} is not verified yet`}
+          </p>
+        )}
+
+        <TextField
+          label="Email (leave empty to remove)"
+          type="email"
+          variant="outlined"
+          fullWidth
+          value={email}
+          onChange={(e) => setEmail(e.target.value)}
+          className="auth-input"
+          autoFocus
+        />
+
+        <TextField
+          label="Current Password (or username if you use single sign-on)"
+          type="password"
+          variant="outlined"
+          fullWidth
+          value={password}
+          onChange={(e) => setPassword(e.target.value)}
+          className="auth-input"
+        />
+
+        <div className="submissions">
+          <Button type="button" onClick={() => navigate(`/topics`)}>
+            Back
+          </Button>
+          {user?.email && !user.email_verified && (
+            <Button type="button" onClick={handleResend}>
+              Resend link
+            </Button>
+          )}
+          <Button type="submit" disabled={password.length === 0}>
+            {loading ? (
+              <CircularProgress size={24} sx={{ color: "white" }} />
+            ) : (
+              "Save"
+            )}
+          </Button>
+        </div>
+      </form>
+    </div>
+  );
+}
+
+export default EmailPage;
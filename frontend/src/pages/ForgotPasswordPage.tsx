@@ -0,0 +1,79 @@
+import { useState } from "react";
+import { forgotPassword } from "../services/api";
+import "./Pages.css";
+import { Link } from "react-router-dom";
+import ErrorMessage from "../components/common/ErrorMessage";
+import { Alert, Button, CircularProgress, TextField } from "@mui/material";
+import { emptyFields } from "../components/common/Functions";
+
+function ForgotPasswordPage() {
+  const [email, setEmail] = useState("");
+  const [message, setMessage] = useState("");
+  const [error, setError] = useState("");
+  const [loading, setLoading] = useState(false);
+
+  async function handleSubmit(e: React.FormEvent) {
+    e.preventDefault();
+    setError("");
+    setMessage("");
+    try {
+      setLoading(true);
+      const data = await forgotPassword(email);
+      setMessage(data.message);
+    } catch (err) {
+      if (err instanceof Error) {
+        setError(err.message);
+      }
+    } finally {
+      setLoading(false);
+    }
+  }
+
+  return (
+    <div className="authentication">
+      <form onSubmit={handleSubmit}>
+        <h1>Forgot Password</h1>
+        {error && <ErrorMessage error={error} />}
+        {message && (
+          <Alert variant="outlined" severity="success" sx={{ mb: 1 }}>
+            {message}
+          </Alert>
+        )}
+
+        <TextField
+          label="Verified email address"
+          type="email"
+          variant="outlined"
+          fullWidth
+          value={email}
+          onChange={(e) => setEmail(e.target.value)}
+          className="auth-input"
+          autoFocus
+        />
+
+        <Button
+          type="submit"
+          variant="contained"
+          fullWidth
+          className="auth-button"
+          disabled={emptyFields(email)}
+        >
+          {loading ? (
+            <CircularProgress sx={{ color: "white" }} />
+          ) : (
+            "SEND RESET LINK"
+          )}
+        </Button>
+
+        <p className="auth-link">
+          Remembered it?{" "}
+          <Link to="/login" className="signup-link">
+            Log in
+          </Link>
+        </p>
+      </form>
+    </div>
+  );
+}
+
+export default ForgotPasswordPage;
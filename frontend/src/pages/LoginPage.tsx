@@ -1,6 +1,12 @@
 import { useEffect, useState } from "react";
-import { login, validate } from "../services/api";
-import { Link } from "react-router-dom";
+import {
+  fetchSingleSignOn,
+  login,
+  singleSignOnURL,
+  validate,
+  verifyTwoFactor,
+} from "../services/api";
+import { Link, useSearchParams } from "react-router-dom";
 import { useNavigate } from "react-router-dom";
 import ErrorMessage from "../components/common/ErrorMessage";
 import { TextField, Button, CircularProgress } from "@mui/material";
@@ -8,18 +14,32 @@ import "./Pages.css";
 import { emptyFields } from "../components/common/Functions";
 
 function LoginPage() {
+  // Single sign-on comes back here with an error, or asking for a code if the
+  // user has two-factor on. Its challenge token is in a cookie.
+  const [searchParams] = useSearchParams();
+  const singleSignOnTwoFactor = searchParams.has("two_factor");
   const [username, setUsername] = useState("");
   const [password, setPassword] = useState("");
-  const [error, setError] = useState("");
+  const [challengeToken, setChallengeToken] = useState("");
+  const [code, setCode] = useState("");
+  const [error, setError] = useState(searchParams.get("sso_error") ?? "");
+  const [singleSignOn, setSingleSignOn] = useState("");
   const [loading, setLoading] = useState(false);
   const navigate = useNavigate();
+  const askForCode = singleSignOnTwoFactor || challengeToken !== "";
 
   async function handleSubmit(e: React.FormEvent) {
     e.preventDefault();
     setError("");
     try {
       setLoading(true);
-      const data = await login(username, password);
+      const data = askForCode
+        ? await verifyTwoFactor(challengeToken, code)
+        : await login(username, password);
+      if (data.two_factor_required) {
+        setChallengeToken(data.challenge_token);
+        return;
+      }
       localStorage.setItem("user", JSON.stringify(data.user));
       navigate("/topics");
     } catch (err) {
@@ -41,7 +61,18 @@ function LoginPage() {
         // No validation means stay at login page
       }
     }
+    async function checkSingleSignOn() {
+      try {
+        const data = await fetchSingleSignOn();
+        if (data.enabled) {
+          setSingleSignOn(data.name);
+        }
+      } catch {
+        // No single sign-on button then
+      }
+    }
     runValidation();
+    checkSingleSignOn();
   }, []);
 
   return (
@@ -50,36 +81,70 @@ function LoginPage() {
         <h1>Login</h1>
         {error && <ErrorMessage error={error} />}
 
-        <TextField
-          label="Username"
-          variant="outlined"
-          fullWidth
-          value={username}
-          onChange={(e) => setUsername(e.target.value)}
-          className="auth-input"
-          autoFocus
-        />
+        {askForCode ? (
+          <TextField
+            label="Authenticator code or recovery code"
+            variant="outlined"
+            fullWidth
+            value={code}
+            onChange={(e) => setCode(e.target.value)}
+            className="auth-input"
+            autoComplete="one-time-code"
+            autoFocus
+          />
+        ) : (
+          <>
+            <TextField
+              label="Username"
+              variant="outlined"
+              fullWidth
+              value={username}
+              onChange={(e) => setUsername(e.target.value)}
+              className="auth-input"
+              autoFocus
+            />
 
-        <TextField
-          label="Password"
-          type="password"
-          variant="outlined"
-          fullWidth
-          value={password}
-          onChange={(e) => setPassword(e.target.value)}
-          className="auth-input"
-        />
+            <TextField
+              label="Password"
+              type="password"
+              variant="outlined"
+              fullWidth
+              value={password}
+              onChange={(e) => setPassword(e.target.value)}
+              className="auth-input"
+            />
+          </>
+        )}
 
         <Button
           type="submit"
           variant="contained"
           fullWidth
           className="auth-button"
-          disabled={emptyFields(username, password)}
+          disabled={
+            askForCode ? emptyFields(code) : emptyFields(username, password)
+          }
         >
           {loading ? <CircularProgress sx={{ color: "white" }} /> : "LOGIN"}
         </Button>
 
+        {singleSignOn && !askForCode && (
+          <Button
+            variant="contained"
+            fullWidth
+            className="auth-button"
+            href={singleSignOnURL}
+          >
+            Sign in with {singleSignOn}
+          </Button>
+        )}
+
+        <p className="auth-link">
+          <Link to="/forgot-password" className="signup-link">
+            Forgot password?
+          </Link>
+        </p>
+
         <p className="auth-link">
           Don't have an account?{" "}
           <Link to="/signup" className="signup-link">
@@ -0,0 +1,115 @@
+import { useState } from "react";
+import { resetForgottenPassword } from "../services/api";
+import "./Pages.css";
+import { Link, useNavigate, useSearchParams } from "react-router-dom";
+import ErrorMessage from "../components/common/ErrorMessage";
+import { Button, CircularProgress, TextField } from "@mui/material";
+import { emptyFields } from "../components/common/Functions";
+import {
+  describeProblems,
+  passwordProblems,
+  usePasswordPolicy,
+} from "../utils/PasswordPolicy";
+
+// Where the link emailed by "Forgot password?" lands
+function NewPasswordPage() {
+  const [searchParams] = useSearchParams();
+  const token = searchParams.get("token") ?? "";
+  const [newPassword, setNewPassword] = useState("");
+  const [verifiedPassword, setVerifiedPassword] = useState("");
+  const [error, setError] = useState("");
+  const [loading, setLoading] = useState(false);
+  const navigate = useNavigate();
+  const policy = usePasswordPolicy();
+
+  function passwordsNotMatch(): boolean {
+    return verifiedPassword.length > 0 && newPassword !== verifiedPassword;
+  }
+
+  function notFulfillPasswordRequirement(): boolean {
+    return passwordProblems(policy, newPassword).length > 0;
+  }
+
+  async function handleSubmit(e: React.FormEvent) {
+    e.preventDefault();
+    setError("");
+    try {
+      setLoading(true);
+      await resetForgottenPassword(token, newPassword);
+      navigate("/login");
+    } catch (err) {
+      if (err instanceof Error) {
+        setError(err.message);
+      }
+    } finally {
+      setLoading(false);
+    }
+  }
+
+  return (
+    <div className="authentication">
+      <form onSubmit={handleSubmit}>
+        <h1>Choose a New Password</h1>
+        {!token && <ErrorMessage error="This link is missing its token" />}
+        {error && <ErrorMessage error={error} />}
+
+        <TextField
+          label="New Password"
+          type="password"
+          variant="outlined"
+          fullWidth
+          value={newPassword}
+          error={notFulfillPasswordRequirement()}
+          onChange={(e) => setNewPassword(e.target.value)}
+          helperText={
+            notFulfillPasswordRequirement()
+              ? describeProblems(passwordProblems(policy, newPassword))
+              : ""
+          }
+          className="auth-input"
+          autoFocus
+        />
+
+        <TextField
+          label="Confirm New Password"
+          type="password"
+          variant="outlined"
+          fullWidth
+          value={verifiedPassword}
+          error={passwordsNotMatch()}
+          onChange={(e) => setVerifiedPassword(e.target.value)}
+          helperText={passwordsNotMatch() ? "Password does not match" : ""}
+          className="auth-input"
+        />
+
+        <Button
+          type="submit"
+          variant="contained"
+          fullWidth
+          className="auth-button"
+          disabled={
+            !token ||
+            emptyFields(newPassword, verifiedPassword) ||
+            notFulfillPasswordRequirement() ||
+            passwordsNotMatch()
+          }
+        >
+          {loading ? (
+            <CircularProgress sx={{ color: "white" }} />
+          ) : (
+            "RESET PASSWORD"
+          )}
+        </Button>
+
+        <p className="auth-link">
+          Link expired?{" "}
+          <Link to="/forgot-password" className="signup-link">
+            Send a new one
+          </Link>
+        </p>
+      </form>
+    </div>
+  );
+}
+
+export default NewPasswordPage;
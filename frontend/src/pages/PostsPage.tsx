@@ -1,12 +1,33 @@
+import { useEffect, useState } from "react";
 import { useNavigate, useParams } from "react-router-dom";
 import PostsList from "../components/posts/PostsList";
-import { Button } from "@mui/material";
+import { Alert, Button } from "@mui/material";
 import ArrowBackIcon from "@mui/icons-material/ArrowBack";
+import { fetchTopic } from "../services/api";
+import type { Topic } from "../types";
+import ErrorMessage from "../components/common/ErrorMessage";
+import {
+  getCurrentUserId,
+  handleApiError,
+} from "../components/common/Functions";
 
 function PostsPage() {
   const navigate = useNavigate();
   const { topic_id } = useParams();
   const topicId = Number(topic_id);
+  const [topic, setTopic] = useState<Topic | null>(null);
+  const [error, setError] = useState("");
+
+  useEffect(() => {
+    fetchTopic(topicId)
+      .then((data) => setTopic(data))
+      .catch((err) => {
+        const errorMessage = handleApiError(err, navigate);
+        if (errorMessage) {
+          setError(errorMessage);
+        }
+      });
+  }, [topicId]);
 
   return (
     <>
@@ -18,7 +39,7 @@ function PostsPage() {
             onClick={() => navigate("/topics")}
           />
         </div>
-        <h1>Posts</h1>
+        <h1>{topic ? topic.title : "Posts"}</h1>
         <div
           style={{
             flex: 1,
@@ -27,20 +48,49 @@ function PostsPage() {
             marginRight: 10,
           }}
         >
-          <Button
-            variant="contained"
-            size="small"
-            onClick={() => navigate("create")}
-            sx={{
-              margin: 1,
-              backgroundColor: "#006f80",
-              "&:hover": { backgroundColor: "#005f6e" },
-            }}
-          >
-            New Post
-          </Button>
+          {topic?.created_by === getCurrentUserId() && (
+            <Button
+              variant="contained"
+              size="small"
+              onClick={() => navigate("edit")}
+              sx={{
+                margin: 1,
+                backgroundColor: "#006f80",
+                "&:hover": { backgroundColor: "#005f6e" },
+              }}
+            >
+              Edit Topic
+            </Button>
+          )}
+          {topic && !topic.archived_at && (
+            <Button
+              variant="contained"
+              size="small"
+              onClick={() => navigate("create")}
+              sx={{
+                margin: 1,
+                backgroundColor: "#006f80",
+                "&:hover": { backgroundColor: "#005f6e" },
+              }}
+            >
+              New Post
+            </Button>
+          )}
         </div>
       </div>
+      {error && <ErrorMessage error={error} />}
+      {topic?.description && <p>{topic.description}</p>}
+      {topic?.archived_at && (
+        <Alert
+          variant="outlined"
+          severity="info"
+          sx={{ maxWidth: 800, margin: "0 auto 16px" }}
+        >
+          This topic was archived on{" "}
+          {new Date(topic.archived_at).toLocaleDateString()}. Its posts and
+          comments can no longer be changed.
+        </Alert>
+      )}
       <PostsList topic_id={topicId} />
     </>
   );
@@ -0,0 +1,110 @@
+import { useEffect, useState } from "react";
+import { useNavigate, useParams } from "react-router-dom";
+import { Avatar, Button, Card, CardContent, Typography } from "@mui/material";
+import ArrowBackIcon from "@mui/icons-material/ArrowBack";
+import { fetchProfile } from "../services/api";
+import ErrorMessage from "../components/common/ErrorMessage";
+import { getCurrentUserId, handleApiError } from "../components/common/Functions";
+import type { Profile } from "../types";
+
+// Shows what anyone signed in can see about a user
+function ProfilePage() {
+  const navigate = useNavigate();
+  const { user_id } = useParams();
+  const userId = Number(user_id);
+  const [profile, setProfile] = useState<Profile | null>(null);
+  const [error, setError] = useState("");
+
+  useEffect(() => {
+    setError("");
+    fetchProfile(userId)
+      .then(setProfile)
+      .catch((err) => {
+        const message = handleApiError(err, navigate);
+        if (message) {
+          setError(message);
+        }
+      });
+  }, [userId, navigate]);
+
+  return (
+    <>
+      <div className="top">
+        <div style={{ flex: 1, display: "flex", justifyContent: "flex-start" }}>
+          <Button
+            startIcon={<ArrowBackIcon />}
+            sx={{ color: "#006f80" }}
+            onClick={() => navigate(-1)}
+          />
+        </div>
+        <h1>Profile</h1>
+        <div
+          style={{
+            flex: 1,
+            display: "flex",
+            justifyContent: "flex-end",
+            marginRight: 10,
+          }}
+        >
+          {userId === getCurrentUserId() && (
+            <Button
+              variant="contained"
+              size="small"
+              onClick={() => navigate("/account")}
+              sx={{
+                margin: 1,
+                backgroundColor: "#006f80",
+                "&:hover": { backgroundColor: "#005f6e" },
+              }}
+            >
+              Edit Profile
+            </Button>
+          )}
+        </div>
+      </div>
+
+      {error && <ErrorMessage error={error} />}
+
+      {profile && (
+        <Card sx={{ maxWidth: 600, margin: "0 auto", textAlign: "left" }}>
+          <CardContent>
+            <div style={{ display: "flex", alignItems: "center", gap: 16 }}>
+              <Avatar
+                src={profile.avatar_url || undefined}
+                alt={profile.username}
+                sx={{ width: 64, height: 64 }}
+              />
+              <div>
+                <Typography variant="h5" sx={{ color: "#006f80" }}>
+                  {profile.display_name || profile.username}
+                </Typography>
+                <Typography variant="body2" color="text.secondary">
+                  @{profile.username} · {profile.role} · joined{" "}
+                  {new Date(profile.created_at).toLocaleDateString([], {
+                    dateStyle: "medium",
+                  })}
+                </Typography>
+              </div>
+            </div>
+
+            {profile.bio && (
+              <Typography
+                variant="body1"
+                sx={{ marginTop: 2, whiteSpace: "pre-wrap" }}
+              >
+                {profile.bio}
+              </Typography>
+            )}
+
+            <Typography variant="body2" sx={{ marginTop: 2 }}>
+              {profile.post_count} posts · {profile.comment_count} comments ·{" "}
+              {profile.reputation} reputation
+            </Typography>
+          </CardContent>
+        </Card>
+      )}
+    </>
+  );
+}
+
+export default ProfilePage;
@@ -4,7 +4,15 @@ import "./Pages.css";
 import { useNavigate } from "react-router-dom";
 import ErrorMessage from "../components/common/ErrorMessage";
 import { Button, CircularProgress, TextField } from "@mui/material";
-import { emptyFields } from "../components/common/Functions";
+import {
+  emptyFields,
+  getCurrentUsername,
+} from "../components/common/Functions";
+import {
+  describeProblems,
+  passwordProblems,
+  usePasswordPolicy,
+} from "../utils/PasswordPolicy";
 
 function ResetPasswordPage() {
   const [oldPassword, setOldPassword] = useState("");
@@ -13,20 +21,16 @@ function ResetPasswordPage() {
   const [error, setError] = useState("");
   const [loading, setLoading] = useState(false);
   const navigate = useNavigate();
+  const policy = usePasswordPolicy();
 
   function passwordsNotMatch(): boolean {
     return verifiedPassword.length > 0 && newPassword !== verifiedPassword;
   }
 
   function notFulfillPasswordRequirement(): boolean {
-    if (newPassword.length > 0) {
-      const hasMinLength = newPassword.length >= 8;
-      const hasUppercase = /[A-Z]/.test(newPassword);
-      const hasSpecialChar = /[^A-Za-z0-9]/.test(newPassword);
-      return !(hasMinLength && hasUppercase && hasSpecialChar);
-    } else {
-      return false;
-    }
+    return (
+      passwordProblems(policy, newPassword, getCurrentUsername()).length > 0
+    );
   }
 
   async function handleSubmit(e: React.FormEvent) {
@@ -72,7 +76,9 @@ function ResetPasswordPage() {
           onChange={(e) => setNewPassword(e.target.value)}
           helperText={
             notFulfillPasswordRequirement()
-              ? "Password must be at least 8 characters, include 1 uppercase & 1 special character"
+              ? describeProblems(
+                  passwordProblems(policy, newPassword, getCurrentUsername()),
+                )
               : ""
           }
           className="auth-input"
@@ -0,0 +1,142 @@
+import { useEffect, useState } from "react";
+import { useNavigate, useParams } from "react-router-dom";
+import { Box, Button, MenuItem, TextField, Typography } from "@mui/material";
+import ArrowBackIcon from "@mui/icons-material/ArrowBack";
+import {
+  fetchRevisionDiff,
+  fetchRevisions,
+  type RevisionKind,
+} from "../services/api";
+import type { DiffChunk, Revision, RevisionDiff } from "../types";
+import ErrorMessage from "../components/common/ErrorMessage";
+import { handleApiError } from "../components/common/Functions";
+
+const chunkStyles = {
+  equal: {},
+  insert: { backgroundColor: "rgba(46, 160, 67, 0.3)" },
+  delete: {
+    backgroundColor: "rgba(248, 81, 73, 0.3)",
+    textDecoration: "line-through",
+  },
+};
+
+// Shows the lines of a diff, inserted ones in green and deleted ones in red
+function DiffView({ chunks }: { chunks: DiffChunk[] }) {
+  return (
+    <Box sx={{ whiteSpace: "pre-wrap", textAlign: "left", mb: 2 }}>
+      {chunks.map((chunk, i) => (
+        <Box key={i} sx={chunkStyles[chunk.op]}>
+          {/* Chunks are whole lines, each chunk a block of its own */}
+          {chunk.text.replace(/\n$/, "")}
+        </Box>
+      ))}
+    </Box>
+  );
+}
+
+function revisionLabel(revision: Revision) {
+  const editedAt = new Date(revision.edited_at).toLocaleString([], {
+    dateStyle: "medium",
+    timeStyle: "short",
+  });
+  return `#${revision.number} by ${revision.editor}, ${editedAt}`;
+}
+
+// Lists the revisions of a post, or of a comment when the route names one,
+// and shows what changed between any two of them
+function RevisionsPage() {
+  const { topic_id, post_id, comment_id } = useParams();
+  const kind: RevisionKind = comment_id ? "comments" : "posts";
+  const id = Number(comment_id ?? post_id);
+  const [revisions, setRevisions] = useState<Revision[]>([]);
+  const [from, setFrom] = useState(0);
+  const [to, setTo] = useState(0);
+  const [revisionDiff, setRevisionDiff] = useState<RevisionDiff | null>(null);
+  const [error, setError] = useState("");
+  const navigate = useNavigate();
+
+  function showError(err: unknown) {
+    const errorMessage = handleApiError(err, navigate);
+    if (errorMessage) {
+      setError(errorMessage);
+    }
+  }
+
+  useEffect(() => {
+    fetchRevisions(kind, id)
+      .then((data) => {
+        const latest = data[data.length - 1].number;
+        setRevisions(data);
+        setTo(latest);
+        setFrom(Math.max(latest - 1, 1));
+      })
+      .catch(showError);
+  }, [kind, id]);
+
+  useEffect(() => {
+    if (from === 0 || to === 0) {
+      return;
+    }
+    setError("");
+    fetchRevisionDiff(kind, id, from, to)
+      .then(setRevisionDiff)
+      .catch(showError);
+  }, [kind, id, from, to]);
+
+  return (
+    <>
+      <div className="top">
+        <div style={{ flex: 1, display: "flex", justifyContent: "flex-start" }}>
+          <Button
+            startIcon={<ArrowBackIcon />}
+            sx={{ color: "#006f80" }}
+            onClick={() => navigate(`/topics/${topic_id}/${post_id}`)}
+          />
+        </div>
+        <h1>{kind === "posts" ? "Post history" : "Comment history"}</h1>
+        <div style={{ flex: 1 }} />
+      </div>
+      <Box sx={{ maxWidth: 800, margin: "0 auto", px: 2 }}>
+        {error && <ErrorMessage error={error} />}
+        <Box sx={{ display: "flex", gap: 2, mb: 2 }}>
+          {[
+            { label: "From", value: from, onChange: setFrom },
+            { label: "To", value: to, onChange: setTo },
+          ].map(({ label, value, onChange }) => (
+            <TextField
+              key={label}
+              select
+              label={label}
+              variant="outlined"
+              fullWidth
+              value={value || ""}
+              onChange={(e) => onChange(Number(e.target.value))}
+              className="field-input"
+            >
+              {revisions.map((revision) => (
+                <MenuItem key={revision.number} value={revision.number}>
+                  {revisionLabel(revision)}
+                </MenuItem>
+              ))}
+            </TextField>
+          ))}
+        </Box>
+
+        {revisionDiff && (
+          <>
+            {revisionDiff.title && (
+              <Typography variant="h5" component="div">
+                <DiffView chunks={revisionDiff.title} />
+              </Typography>
+            )}
+            <Typography variant="body2" component="div">
+              <DiffView chunks={revisionDiff.content} />
+            </Typography>
+          </>
+        )}
+      </Box>
+    </>
+  );
+}
+
+export default RevisionsPage;
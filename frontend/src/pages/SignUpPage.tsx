@@ -5,6 +5,11 @@ import { Link, useNavigate } from "react-router-dom";
 import ErrorMessage from "../components/common/ErrorMessage";
 import { Button, CircularProgress, TextField } from "@mui/material";
 import { emptyFields } from "../components/common/Functions";
+import {
+  describeProblems,
+  passwordProblems,
+  usePasswordPolicy,
+} from "../utils/PasswordPolicy";
 
 function SignUpPage() {
   const [username, setUsername] = useState("");
@@ -13,6 +18,7 @@ function SignUpPage() {
   const [error, setError] = useState("");
   const [loading, setLoading] = useState(false);
   const navigate = useNavigate();
+  const policy = usePasswordPolicy();
 
   function notFulfillUsername(): boolean {
     return (
@@ -25,14 +31,7 @@ function SignUpPage() {
   }
 
   function notFulfillPasswordRequirement(): boolean {
-    if (password.length > 0) {
-      const hasMinLength = password.length >= 8;
-      const hasUppercase = /[A-Z]/.test(password);
-      const hasSpecialChar = /[^A-Za-z0-9]/.test(password);
-      return !(hasMinLength && hasUppercase && hasSpecialChar);
-    } else {
-      return false; // None -> will be blocked by the button
-    }
+    return passwordProblems(policy, password, username).length > 0;
   }
 
   async function handleSubmit(e: React.FormEvent) {
@@ -83,7 +82,7 @@ function SignUpPage() {
           onChange={(e) => setPassword(e.target.value)}
           helperText={
             notFulfillPasswordRequirement()
-              ? "Password must be at least 8 characters, include 1 uppercase & 1 special character"
+              ? describeProblems(passwordProblems(policy, password, username))
               : ""
           }
           className="auth-input"
@@ -0,0 +1,58 @@
+import { useEffect, useRef, useState } from "react";
+import { verifyEmail } from "../services/api";
+import "./Pages.css";
+import { Link, useSearchParams } from "react-router-dom";
+import ErrorMessage from "../components/common/ErrorMessage";
+import { Alert, CircularProgress } from "@mui/material";
+
+// Where the link emailed to verify an address lands. It works logged in or not.
+function VerifyEmailPage() {
+  const [searchParams] = useSearchParams();
+  const token = searchParams.get("token") ?? "";
+  const [message, setMessage] = useState("");
+  const [error, setError] = useState("");
+  // Tokens are single-use, so verify once even when effects run twice
+  const started = useRef(false);
+
+  useEffect(() => {
+    if (started.current) {
+      return;
+    }
+    started.current = true;
+
+    async function runVerification() {
+      try {
+        const data = await verifyEmail(token);
+        setMessage(data.message);
+      } catch (err) {
+        if (err instanceof Error) {
+          setError(err.message);
+        }
+      }
+    }
+    runVerification();
+  }, [token]);
+
+  return (
+    <div className="authentication">
+      <form>
+        <h1>Verify Email</h1>
+        {error && <ErrorMessage error={error} />}
+        {message && (
+          <Alert variant="outlined" severity="success" sx={{ mb: 1 }}>
+            {message}
+          </Alert>
+        )}
+        {!error && !message && <CircularProgress />}
+
+        <p className="auth-link">
+          <Link to="/topics" className="signup-link">
+            Continue to the forum
+          </Link>
+        </p>
+      </form>
+    </div>
+  );
+}
+
+export default VerifyEmailPage;
@@ -1,7 +1,43 @@
+import type {
+  Comment,
+  Page,
+  Post,
+  Revision,
+  RevisionDiff,
+  Sort,
+  TopicSort,
+} from "../types";
 import { UnauthorisedError } from "../utils/Error";
 
 const API_BASE_URL = import.meta.env["VITE_API_URL"];
 
+// Access tokens are short-lived. On a 401 ask the backend for a new one with
+// the refresh cookie, once, and retry. Concurrent requests share the refresh.
+let refreshing: Promise<boolean> | null = null;
+
+function refreshSession(): Promise<boolean> {
+  if (!refreshing) {
+    refreshing = fetch(`${API_BASE_URL}/api/users/refresh`, {
+      method: "POST",
+      credentials: "include",
+    })
+      .then((response) => response.ok)
+      .catch(() => false)
+      .finally(() => {
+        refreshing = null;
+      });
+  }
+  return refreshing;
+}
+
+async function apiFetch(input: string, init?: RequestInit) {
+  const response = await fetch(input, init);
+  if (response.status != 401 || !(await refreshSession())) {
+    return response;
+  }
+  return await fetch(input, init);
+}
+
 async function handleResponse(response: Response, errorMessage: string) {
   if (!response.ok) {
     if (response.status == 401) {
@@ -11,6 +47,12 @@ async function handleResponse(response: Response, errorMessage: string) {
     try {
       const errorData = await response.json();
       backendError = errorData.error || errorMessage;
+      // A password the policy turned away comes with the rules it broke
+      if (Array.isArray(errorData.violations)) {
+        backendError = errorData.violations
+          .map((violation: { message: string }) => violation.message)
+          .join(". ");
+      }
     } catch {
       // JSON parsing failed, use generic message
     }
@@ -21,6 +63,23 @@ async function handleResponse(response: Response, errorMessage: string) {
   return await response.json();
 }
 
+// Lists are paginated by the backend. Fetch the page after cursor, the first
+// one if it is null, and leave it to the caller to ask for more.
+async function fetchPage<T>(
+  url: string,
+  key: string,
+  cursor: string | null,
+  errorMessage: string,
+): Promise<Page<T>> {
+  const params = cursor ? `&cursor=${encodeURIComponent(cursor)}` : "";
+  const response = await apiFetch(`${url}${params}`, {
+    credentials: "include",
+  });
+
+  const data = await handleResponse(response, errorMessage);
+  return { items: data[key], next_cursor: data.next_cursor };
+}
+
 // Users
 export const login = async (username: string, password: string) => {
   const response = await fetch(`${API_BASE_URL}/api/users/login`, {
@@ -43,6 +102,45 @@ export const login = async (username: string, password: string) => {
   return await response.json();
 };
 
+// Second login step for users with two-factor authentication, with the
+// challenge token login returned and a code or a recovery code. After single
+// sign-on the challenge token is empty and the cookie carries it.
+export const verifyTwoFactor = async (
+  challengeToken: string,
+  code: string,
+) => {
+  // Authenticator codes are 6 digits, recovery codes look like "abcde-fghij"
+  const value = code.replace(/\s/g, "");
+  const isRecoveryCode = !/^\d{6}$/.test(value);
+  const response = await fetch(`${API_BASE_URL}/api/users/login/2fa`, {
+    method: "POST",
+    headers: {
+      "Content-Type": "application/json",
+    },
+    credentials: "include",
+    body: JSON.stringify({
+      challenge_token: challengeToken || undefined,
+      [isRecoveryCode ? "recovery_code" : "code"]: value,
+    }),
+  });
+
+  if (!response.ok) {
+    const errorData = await response.json();
+    throw new Error(errorData.error || "Login failed");
+  }
+
+  return await response.json();
+};
+
+// Single sign-on is a redirect to the identity provider, not a fetch
+export const singleSignOnURL = `${API_BASE_URL}/api/users/oidc/login`;
+
+export const fetchSingleSignOn = async () => {
+  const response = await fetch(`${API_BASE_URL}/api/users/oidc`);
+
+  return handleResponse(response, "Failed to check single sign-on");
+};
+
 export const signUp = async (username: string, password: string) => {
   const response = await fetch(`${API_BASE_URL}/api/users/signup`, {
     method: "POST",
@@ -82,7 +180,7 @@ export const logOut = async () => {
 };
 
 export const resetPassword = async (password: string, new_password: string) => {
-  const response = await fetch(`${API_BASE_URL}/api/users/changepassword`, {
+  const response = await apiFetch(`${API_BASE_URL}/api/users/changepassword`, {
     method: "PUT",
     headers: {
       "Content-Type": "application/json",
@@ -97,17 +195,261 @@ export const resetPassword = async (password: string, new_password: string) => {
   return handleResponse(response, "Failed to reset password");
 };
 
+export const fetchPasswordPolicy = async () => {
+  const response = await fetch(`${API_BASE_URL}/api/users/password-policy`);
+
+  return handleResponse(response, "Failed to fetch password policy");
+};
+
+// Email. Users who sign in only through single sign-on confirm with their
+// username instead of a password.
+export const changeEmail = async (
+  email: string,
+  password: string,
+  username: string,
+) => {
+  const response = await apiFetch(`${API_BASE_URL}/api/users/email`, {
+    method: "PUT",
+    headers: {
+      "Content-Type": "application/json",
+    },
+    credentials: "include",
+    body: JSON.stringify({
+      email,
+      password,
+      username,
+    }),
+  });
+
+  return handleResponse(response, "Failed to change email address");
+};
+
+export const resendVerification = async () => {
+  const response = await apiFetch(`${API_BASE_URL}/api/users/email/resend`, {
+    method: "POST",
+    credentials: "include",
+  });
+
+  return handleResponse(response, "Failed to send verification email");
+};
+
+export const verifyEmail = async (token: string) => {
+  const response = await fetch(`${API_BASE_URL}/api/users/email/verify`, {
+    method: "POST",
+    headers: {
+      "Content-Type": "application/json",
+    },
+    body: JSON.stringify({ token }),
+  });
+
+  return handleResponse(response, "Failed to verify email address");
+};
+
+export const forgotPassword = async (email: string) => {
+  const response = await fetch(`${API_BASE_URL}/api/users/forgot-password`, {
+    method: "POST",
+    headers: {
+      "Content-Type": "application/json",
+    },
+    body: JSON.stringify({ email }),
+  });
+
+  return handleResponse(response, "Failed to send reset link");
+};
+
+export const resetForgottenPassword = async (
+  token: string,
+  new_password: string,
+) => {
+  const response = await fetch(`${API_BASE_URL}/api/users/reset-password`, {
+    method: "POST",
+    headers: {
+      "Content-Type": "application/json",
+    },
+    body: JSON.stringify({
+      token,
+      new_password,
+    }),
+  });
+
+  return handleResponse(response, "Failed to reset password");
+};
+
+// Profiles
+export const fetchProfile = async (user_id: number) => {
+  const response = await apiFetch(`${API_BASE_URL}/api/users/${user_id}`, {
+    credentials: "include",
+  });
+
+  return handleResponse(response, "Failed to fetch profile");
+};
+
+export const updateProfile = async (
+  user_id: number,
+  display_name: string,
+  bio: string,
+  avatar_url: string,
+) => {
+  const response = await apiFetch(`${API_BASE_URL}/api/users/${user_id}`, {
+    method: "PATCH",
+    headers: {
+      "Content-Type": "application/json",
+    },
+    credentials: "include",
+    body: JSON.stringify({
+      display_name,
+      bio,
+      avatar_url,
+    }),
+  });
+
+  return handleResponse(response, "Failed to update profile");
+};
+
+export const changeUsername = async (user_id: number, username: string) => {
+  const response = await apiFetch(
+    `${API_BASE_URL}/api/users/${user_id}/username`,
+    {
+      method: "PUT",
+      headers: {
+        "Content-Type": "application/json",
+      },
+      credentials: "include",
+      body: JSON.stringify({ username }),
+    },
+  );
+
+  return handleResponse(response, "Failed to change username");
+};
+
+// Users who sign in only through single sign-on confirm with their username
+// instead of a password
+export const deleteAccount = async (
+  user_id: number,
+  policy: "anonymise" | "delete",
+  password: string,
+  username: string,
+) => {
+  const response = await apiFetch(`${API_BASE_URL}/api/users/${user_id}`, {
+    method: "DELETE",
+    headers: {
+      "Content-Type": "application/json",
+    },
+    credentials: "include",
+    body: JSON.stringify({
+      policy,
+      password,
+      username,
+    }),
+  });
+
+  return handleResponse(response, "Failed to delete account");
+};
+
+// Categories
+export const fetchCategories = async () => {
+  const response = await apiFetch(`${API_BASE_URL}/api/categories`, {
+    credentials: "include",
+  });
+
+  return handleResponse(response, "Failed to retrieve categories");
+};
+
+export const createCategory = async (
+  name: string,
+  description: string,
+  parent_id: number | null,
+  position: number,
+) => {
+  const response = await apiFetch(`${API_BASE_URL}/api/categories`, {
+    method: "POST",
+    headers: {
+      "Content-Type": "application/json",
+    },
+    credentials: "include",
+    body: JSON.stringify({
+      name,
+      description,
+      parent_id,
+      position,
+    }),
+  });
+
+  return handleResponse(response, "Failed to create category");
+};
+
+export const updateCategory = async (
+  category_id: number,
+  name: string,
+  description: string,
+  parent_id: number | null,
+  position: number,
+) => {
+  const response = await apiFetch(
+    `${API_BASE_URL}/api/categories/${category_id}`,
+    {
+      method: "PUT",
+      headers: {
+        "Content-Type": "application/json",
+      },
+      credentials: "include",
+      body: JSON.stringify({
+        name,
+        description,
+        parent_id,
+        position,
+      }),
+    },
+  );
+
+  return handleResponse(response, "Failed to edit category");
+};
+
+export const deleteCategory = async (category_id: number) => {
+  const response = await apiFetch(
+    `${API_BASE_URL}/api/categories/${category_id}`,
+    {
+      method: "DELETE",
+      headers: {
+        "Content-Type": "application/json",
+      },
+      credentials: "include",
+    },
+  );
+
+  return handleResponse(response, "Failed to delete category");
+};
+
 // Topics
-export const fetchTopics = async () => {
-  const response = await fetch(`${API_BASE_URL}/api/topics`, {
+export const fetchTopics = async (
+  sort: TopicSort = "oldest",
+  category_id: number | null = null,
+) => {
+  const params = new URLSearchParams({ sort });
+  if (category_id !== null) {
+    params.set("category_id", String(category_id));
+  }
+  const response = await apiFetch(`${API_BASE_URL}/api/topics?${params}`, {
     credentials: "include",
   });
 
   return handleResponse(response, "Failed to retrieve topics");
 };
 
-export const createTopic = async (title: string) => {
-  const response = await fetch(`${API_BASE_URL}/api/topics`, {
+export const fetchTopic = async (topic_id: number) => {
+  const response = await apiFetch(`${API_BASE_URL}/api/topics/${topic_id}`, {
+    credentials: "include",
+  });
+
+  return handleResponse(response, "Failed to retrieve topic");
+};
+
+export const createTopic = async (
+  title: string,
+  description: string,
+  category_id: number | null,
+) => {
+  const response = await apiFetch(`${API_BASE_URL}/api/topics`, {
     method: "POST",
     headers: {
       "Content-Type": "application/json",
@@ -115,26 +457,98 @@ export const createTopic = async (title: string) => {
     credentials: "include",
     body: JSON.stringify({
       title,
+      description,
+      category_id,
     }),
   });
 
   return handleResponse(response, "Failed to create topic");
 };
 
+export const updateTopic = async (
+  topic_id: number,
+  title: string,
+  description: string,
+  category_id: number | null,
+  archived: boolean,
+) => {
+  const response = await apiFetch(`${API_BASE_URL}/api/topics/${topic_id}`, {
+    method: "PUT",
+    headers: {
+      "Content-Type": "application/json",
+    },
+    credentials: "include",
+    body: JSON.stringify({
+      title,
+      description,
+      category_id,
+      archived,
+    }),
+  });
+
+  return handleResponse(response, "Failed to edit topic");
+};
+
+export const deleteTopic = async (topic_id: number) => {
+  const response = await apiFetch(`${API_BASE_URL}/api/topics/${topic_id}`, {
+    method: "DELETE",
+    headers: {
+      "Content-Type": "application/json",
+    },
+    credentials: "include",
+  });
+
+  return handleResponse(response, "Failed to delete topic");
+};
+
 // Posts
-export const fetchPosts = async (topicId: number) => {
-  const response = await fetch(
-    `${API_BASE_URL}/api/posts?topic_id=${topicId}`,
+export const fetchPosts = async (
+  topicId: number,
+  sort: Sort = "newest",
+  cursor: string | null = null,
+) => {
+  return fetchPage<Post>(
+    `${API_BASE_URL}/api/posts?topic_id=${topicId}&sort=${sort}`,
+    "posts",
+    cursor,
+    "Failed to retrieve posts",
+  );
+};
+
+export const setPostPinned = async (post_id: number, pinned: boolean) => {
+  const response = await apiFetch(
+    `${API_BASE_URL}/api/posts/${post_id}/pinned`,
+    {
+      method: "PUT",
+      headers: {
+        "Content-Type": "application/json",
+      },
+      credentials: "include",
+      body: JSON.stringify({ pinned }),
+    },
+  );
+
+  return handleResponse(response, "Failed to pin post");
+};
+
+export const setPostLocked = async (post_id: number, locked: boolean) => {
+  const response = await apiFetch(
+    `${API_BASE_URL}/api/posts/${post_id}/locked`,
     {
+      method: "PUT",
+      headers: {
+        "Content-Type": "application/json",
+      },
       credentials: "include",
+      body: JSON.stringify({ locked }),
     },
   );
 
-  return handleResponse(response, "Failed to retrieve posts");
+  return handleResponse(response, "Failed to lock post");
 };
 
 export const fetchSinglePost = async (post_id: number) => {
-  const response = await fetch(`${API_BASE_URL}/api/posts/${post_id}`, {
+  const response = await apiFetch(`${API_BASE_URL}/api/posts/${post_id}`, {
     credentials: "include",
   });
 
@@ -146,7 +560,7 @@ export const createPost = async (
   title: string,
   content: string,
 ) => {
-  const response = await fetch(`${API_BASE_URL}/api/posts`, {
+  const response = await apiFetch(`${API_BASE_URL}/api/posts`, {
     method: "POST",
     headers: {
       "Content-Type": "application/json",
@@ -167,7 +581,7 @@ export const editPost = async (
   title: string,
   content: string,
 ) => {
-  const response = await fetch(`${API_BASE_URL}/api/posts/${post_id}`, {
+  const response = await apiFetch(`${API_BASE_URL}/api/posts/${post_id}`, {
     method: "PUT",
     headers: {
       "Content-Type": "application/json",
@@ -183,7 +597,7 @@ export const editPost = async (
 };
 
 export const deletePost = async (post_id: number) => {
-  const response = await fetch(`${API_BASE_URL}/api/posts/${post_id}`, {
+  const response = await apiFetch(`${API_BASE_URL}/api/posts/${post_id}`, {
     method: "DELETE",
     headers: {
       "Content-Type": "application/json",
@@ -195,7 +609,7 @@ export const deletePost = async (post_id: number) => {
 };
 
 export const createPostReaction = async (post_id: number, reaction: number) => {
-  const response = await fetch(
+  const response = await apiFetch(
     `${API_BASE_URL}/api/posts/${post_id}/reactions`,
     {
       method: "POST",
@@ -213,19 +627,21 @@ export const createPostReaction = async (post_id: number, reaction: number) => {
 };
 
 // Comments
-export const fetchComments = async (post_id: number) => {
-  const response = await fetch(
-    `${API_BASE_URL}/api/comments?post_id=${post_id}`,
-    {
-      credentials: "include",
-    },
+export const fetchComments = async (
+  post_id: number,
+  sort: Sort = "newest",
+  cursor: string | null = null,
+) => {
+  return fetchPage<Comment>(
+    `${API_BASE_URL}/api/comments?post_id=${post_id}&sort=${sort}`,
+    "comments",
+    cursor,
+    "Failed to retrieve comments",
   );
-
-  return handleResponse(response, "Failed to retrieve comments");
 };
 
 export const fetchSingleComment = async (comment_id: number) => {
-  const response = await fetch(`${API_BASE_URL}/api/comments/${comment_id}`, {
+  const response = await apiFetch(`${API_BASE_URL}/api/comments/${comment_id}`, {
     credentials: "include",
   });
 
@@ -233,7 +649,7 @@ export const fetchSingleComment = async (comment_id: number) => {
 };
 
 export const createComment = async (post_id: number, content: string) => {
-  const response = await fetch(`${API_BASE_URL}/api/comments`, {
+  const response = await apiFetch(`${API_BASE_URL}/api/comments`, {
     method: "POST",
     headers: {
       "Content-Type": "application/json",
@@ -249,7 +665,7 @@ export const createComment = async (post_id: number, content: string) => {
 };
 
 export const editComment = async (comment_id: number, content: string) => {
-  const response = await fetch(`${API_BASE_URL}/api/comments/${comment_id}`, {
+  const response = await apiFetch(`${API_BASE_URL}/api/comments/${comment_id}`, {
     method: "PUT",
     headers: {
       "Content-Type": "application/json",
@@ -264,7 +680,7 @@ export const editComment = async (comment_id: number, content: string) => {
 };
 
 export const deleteComment = async (comment_id: number) => {
-  const response = await fetch(`${API_BASE_URL}/api/comments/${comment_id}`, {
+  const response = await apiFetch(`${API_BASE_URL}/api/comments/${comment_id}`, {
     method: "DELETE",
     headers: {
       "Content-Type": "application/json",
@@ -279,7 +695,7 @@ export const createCommentReaction = async (
   comment_id: number,
   reaction: number,
 ) => {
-  const response = await fetch(
+  const response = await apiFetch(
     `${API_BASE_URL}/api/comments/${comment_id}/reactions`,
     {
       method: "POST",
@@ -296,10 +712,49 @@ export const createCommentReaction = async (
   return handleResponse(response, "Failed to react to comment");
 };
 
+// Revisions of posts and comments
+
+export type RevisionKind = "posts" | "comments";
+
+export const fetchRevisions = async (
+  kind: RevisionKind,
+  id: number,
+): Promise<Revision[]> => {
+  const response = await apiFetch(
+    `${API_BASE_URL}/api/${kind}/${id}/revisions`,
+    {
+      credentials: "include",
+    },
+  );
+
+  const data = await handleResponse(response, "Failed to retrieve revisions");
+  return data.revisions;
+};
+
+export const fetchRevisionDiff = async (
+  kind: RevisionKind,
+  id: number,
+  from: number,
+  to: number,
+): Promise<RevisionDiff> => {
+  const params = new URLSearchParams({
+    from: String(from),
+    to: String(to),
+  });
+  const response = await apiFetch(
+    `${API_BASE_URL}/api/${kind}/${id}/revisions/diff?${params}`,
+    {
+      credentials: "include",
+    },
+  );
+
+  return handleResponse(response, "Failed to compare revisions");
+};
+
 // Validation
 
 export const validate = async () => {
-  const response = await fetch(`${API_BASE_URL}/api/users/validate`, {
+  const response = await apiFetch(`${API_BASE_URL}/api/users/validate`, {
     credentials: "include",
   });
 
@@ -3,15 +3,70 @@ export interface User {
   id: number;
   username: string;
   password?: string;
+  role: "member" | "moderator" | "admin";
+  display_name?: string;
+  bio?: string;
+  avatar_url?: string;
+  email?: string;
+  email_verified?: boolean;
   created_at: string;
 }
 
+// Profile
+export interface Profile extends User {
+  post_count: number;
+  comment_count: number;
+  reputation: number;
+}
+
+// Category
+export interface Category {
+  id: number;
+  name: string;
+  description: string;
+  parent_id: number | null;
+  position: number;
+  created_at: string;
+}
+
+// Category with its subcategories, as GET /api/categories nests them
+export interface CategoryNode extends Category {
+  children: CategoryNode[];
+}
+
 // Topic
 export interface Topic {
   id: number;
   title: string;
+  description: string;
+  category_id: number | null;
   created_by: number;
   created_at: string;
+  // Posts and comments in an archived topic can no longer be changed
+  archived_at: string | null;
+}
+
+// Topic with its activity, as listed on the topics page
+export interface TopicStats extends Topic {
+  post_count: number;
+  comment_count: number;
+  // The latest post or comment, or the topic's creation if it has none
+  last_activity_at: string;
+  last_poster_id: number | null;
+  last_poster: string | null;
+}
+
+export type TopicSort = "oldest" | "newest" | "activity";
+
+// Order of posts and comments. popular is likes minus dislikes, and hot is
+// popular decayed by age.
+export type Sort = "newest" | "oldest" | "likes" | "popular" | "hot";
+
+// One page of a list, next_cursor fetches the page after it and is null on
+// the last one
+export interface Page<T> {
+  items: T[];
+  next_cursor: string | null;
 }
 
 // Post
@@ -28,12 +83,42 @@ export interface Post {
   dislike_count: number;
   net_score: number;
   user_reaction: number | null;
+  // Set while the post is pinned to the top of its topic
+  pinned_at: string | null;
+  // Set while the post takes no new comments
+  locked_at: string | null;
+}
+
+// Revision is one version of a post or comment, numbered from 1, the
+// original. Comments have no title.
+export interface Revision {
+  number: number;
+  title?: string;
+  content: string;
+  edited_by: number;
+  editor: string;
+  edited_at: string;
+}
+
+// DiffChunk is a run of lines kept, inserted or deleted between two revisions
+export interface DiffChunk {
+  op: "equal" | "insert" | "delete";
+  text: string;
+}
+
+export interface RevisionDiff {
+  from: Revision;
+  to: Revision;
+  title?: DiffChunk[];
+  content: DiffChunk[];
 }
 
 // Comment
 export interface Comment {
   id: number;
   post_id: number;
+  topic_id: number;
+  parent_id: number | null;
   content: string;
   created_by: number;
   created_at: string;
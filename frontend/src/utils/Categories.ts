@@ -0,0 +1,32 @@
+import type { CategoryNode } from "../types";
+
+// A category as a row of a flat list, indented by its depth in the tree
+export interface CategoryOption {
+  category: CategoryNode;
+  depth: number;
+}
+
+// Lays the category tree out depth first, each category followed by its
+// subcategories, for selects and lists
+export function flattenCategories(
+  nodes: CategoryNode[],
+  depth = 0,
+): CategoryOption[] {
+  return nodes.flatMap((category) => [
+    { category, depth },
+    ...flattenCategories(category.children, depth + 1),
+  ]);
+}
+
+// Leaves out a category and its subcategories, wherever it is in the tree
+export function withoutCategory(
+  nodes: CategoryNode[],
+  id: number,
+): CategoryNode[] {
+  return nodes
+    .filter((category) => category.id !== id)
+    .map((category) => ({
+      ...category,
+      children: withoutCategory(category.children, id),
+    }));
+}
@@ -0,0 +1,90 @@
+import { useEffect, useState } from "react";
+import { fetchPasswordPolicy } from "../services/api";
+
+// The rules the backend holds new passwords to. The breached password check
+// only happens there.
+export interface PasswordPolicy {
+  min_length: number;
+  max_length: number;
+  max_bytes: number;
+  require_upper: boolean;
+  require_lower: boolean;
+  require_digit: boolean;
+  require_symbol: boolean;
+  disallow_username: boolean;
+}
+
+// Until the backend answers, assume its defaults
+const defaultPolicy: PasswordPolicy = {
+  min_length: 8,
+  max_length: 64,
+  max_bytes: 0,
+  require_upper: false,
+  require_lower: false,
+  require_digit: false,
+  require_symbol: false,
+  disallow_username: true,
+};
+
+export function usePasswordPolicy(): PasswordPolicy {
+  const [policy, setPolicy] = useState(defaultPolicy);
+
+  useEffect(() => {
+    fetchPasswordPolicy()
+      .then((data) => setPolicy(data.policy))
+      .catch(() => {
+        // The backend checks again on submit
+      });
+  }, []);
+
+  return policy;
+}
+
+// passwordProblems lists the rules password breaks, mirroring the backend
+export function passwordProblems(
+  policy: PasswordPolicy,
+  password: string,
+  username = "",
+): string[] {
+  if (password.length === 0) {
+    return []; // None -> will be blocked by the button
+  }
+
+  const problems: string[] = [];
+  const length = [...password].length;
+  if (length < policy.min_length) {
+    problems.push(`at least ${policy.min_length} characters`);
+  }
+  if (policy.max_length > 0 && length > policy.max_length) {
+    problems.push(`at most ${policy.max_length} characters`);
+  } else if (
+    policy.max_bytes > 0 &&
+    new TextEncoder().encode(password).length > policy.max_bytes
+  ) {
+    problems.push(`at most ${policy.max_bytes} bytes`);
+  }
+  if (policy.require_upper && !/\p{Lu}/u.test(password)) {
+    problems.push("an uppercase letter");
+  }
+  if (policy.require_lower && !/\p{Ll}/u.test(password)) {
+    problems.push("a lowercase letter");
+  }
+  if (policy.require_digit && !/\p{Nd}/u.test(password)) {
+    problems.push("a digit");
+  }
+  if (policy.require_symbol && !/[^\p{L}\p{Nd}]/u.test(password)) {
+    problems.push("a symbol or space");
+  }
+  if (
+    policy.disallow_username &&
+    username.length > 0 &&
+    password.toLowerCase().includes(username.toLowerCase())
+  ) {
+    problems.push("no username");
+  }
+  return problems;
+}
+
+export function describeProblems(problems: string[]): string {
+  return problems.length > 0 ? `Password needs ${problems.join(", ")}` : "";
+}